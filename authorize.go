@@ -0,0 +1,48 @@
+package repositorysdk
+
+import (
+	"context"
+	"reflect"
+)
+
+// Operation is the kind of operation performed by a repository.
+type Operation string
+
+const (
+	OperationFindAll Operation = "find_all"
+	OperationFindOne Operation = "find_one"
+	OperationCreate  Operation = "create"
+	OperationUpdate  Operation = "update"
+	OperationDelete  Operation = "delete"
+)
+
+// Authorizer decides whether an operation on an entity is allowed, it is evaluated by the gorm repository before the
+// operation is executed. A non-nil error aborts the operation and is returned to the caller as it is.
+type Authorizer interface {
+	Authorize(ctx context.Context, op Operation, entityType string, entity interface{}) error
+}
+
+// AuthorizerFunc is an adapter to allow the use of an ordinary function as an Authorizer.
+type AuthorizerFunc func(ctx context.Context, op Operation, entityType string, entity interface{}) error
+
+// Authorize calls f(ctx, op, entityType, entity).
+func (f AuthorizerFunc) Authorize(ctx context.Context, op Operation, entityType string, entity interface{}) error {
+	return f(ctx, op, entityType, entity)
+}
+
+// WithAuthorizer enables the authorization of every operation of the repository by the given authorizer.
+func WithAuthorizer(authorizer Authorizer) GormOption {
+	return func(o *gormOptions) {
+		o.authorizer = authorizer
+	}
+}
+
+// entityTypeName returns the name of the entity type without the pointer indirections, e.g. "User" for *User.
+func entityTypeName[T any]() string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
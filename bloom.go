@@ -0,0 +1,121 @@
+package repositorysdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type BloomRepository interface {
+	BloomReserve(key string, errorRate float64, capacity int64) error
+	BloomAdd(key string, item interface{}) (bool, error)
+	BloomExists(key string, item interface{}) (bool, error)
+}
+
+type bloomRepository struct {
+	repo RedisRepository
+}
+
+// NewBloomRepository function that create a new instance of BloomRepository backed by the module RedisBloom, e.g. to
+// tell that an email is surely not registered before querying the database. The module is looked up once, so the
+// service can fall back to the database when it is not loaded.
+//
+// Parameters:
+// - repo: the redis repository, its key prefix applies to the filters.
+//
+// Returns:
+// - BloomRepository: the Bloom filters.
+// - error: ErrModuleUnavailable if RedisBloom is not loaded, otherwise an error if something goes wrong.
+func NewBloomRepository(repo RedisRepository) (BloomRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := requireRedisCommand(ctx, repo, "BF.ADD"); err != nil {
+		return nil, err
+	}
+
+	return &bloomRepository{repo: repo}, nil
+}
+
+// BloomReserve creates an empty Bloom filter by using the command `BF.RESERVE`, a filter which is not reserved is
+// created by BloomAdd with the default error rate of 0.01 and capacity of 100.
+//
+// Parameters:
+// - key: the filter key.
+// - errorRate: the expected rate of false positives, e.g. 0.001.
+// - capacity: the number of items the filter holds before it is scaled.
+//
+// Returns:
+// - error: an error if the filter already exists or something goes wrong, otherwise nil.
+func (r *bloomRepository) BloomReserve(key string, errorRate float64, capacity int64) (err error) {
+	defer wrapError(&err, "BloomReserve", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return moduleError(r.repo.GetUniversalClient().Do(ctx, "BF.RESERVE", redisKey(r.repo, key), errorRate, capacity).Err())
+}
+
+// BloomAdd adds an item to a Bloom filter by using the command `BF.ADD`.
+//
+// Parameters:
+// - key: the filter key.
+// - item: the item to be added.
+//
+// Returns:
+// - bool: true if the item was not in the filter yet, false if it may have been added before.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *bloomRepository) BloomAdd(key string, item interface{}) (added bool, err error) {
+	defer wrapError(&err, "BloomAdd", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	added, err = r.repo.GetUniversalClient().Do(ctx, "BF.ADD", redisKey(r.repo, key), item).Bool()
+	return added, moduleError(err)
+}
+
+// BloomExists checks whether an item may be in a Bloom filter by using the command `BF.EXISTS`.
+//
+// Parameters:
+// - key: the filter key.
+// - item: the item to be checked.
+//
+// Returns:
+// - bool: false if the item is surely not in the filter, true if it may be in it.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *bloomRepository) BloomExists(key string, item interface{}) (exists bool, err error) {
+	defer wrapError(&err, "BloomExists", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err = r.repo.GetUniversalClient().Do(ctx, "BF.EXISTS", redisKey(r.repo, key), item).Bool()
+	return exists, moduleError(err)
+}
+
+// requireRedisCommand returns ErrModuleUnavailable if the server does not know the command, by using the command
+// `COMMAND INFO` which is allowed on the managed servers where `MODULE LIST` is not.
+func requireRedisCommand(ctx context.Context, repo RedisRepository, name string) error {
+	info, err := repo.GetUniversalClient().Do(ctx, "COMMAND", "INFO", name).Slice()
+	if err != nil {
+		return err
+	}
+
+	if len(info) == 0 || info[0] == nil {
+		return fmt.Errorf("%w: unknown command %s", ErrModuleUnavailable, name)
+	}
+
+	return nil
+}
+
+// moduleError maps the error of a command unknown to the server, e.g. after its module was unloaded, to
+// ErrModuleUnavailable.
+func moduleError(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), "ERR unknown command") {
+		return fmt.Errorf("%w: %v", ErrModuleUnavailable, err)
+	}
+
+	return err
+}
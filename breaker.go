@@ -0,0 +1,139 @@
+package repositorysdk
+
+import (
+	"errors"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchtransport"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerConfig is a struct that holds the settings of a circuit breaker, which fails the calls fast with
+// ErrCircuitOpen after Threshold consecutive failures, and lets a single probe call through once Cooldown has elapsed.
+// The circuit is closed again when the probe succeeds.
+type CircuitBreakerConfig struct {
+	Threshold int           `mapstructure:"threshold"`
+	Cooldown  time.Duration `mapstructure:"cooldown"`
+}
+
+// GetCooldown returns the time the circuit stays open before a probe call is let through.
+// If the value is not set, the default value of 30 seconds is returned.
+//
+// Returns:
+// - time.Duration: the cooldown of the circuit.
+func (c *CircuitBreakerConfig) GetCooldown() time.Duration {
+	if c.Cooldown == 0 {
+		return 30 * time.Second
+	}
+
+	return c.Cooldown
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// newCircuitBreaker returns the circuit breaker of conf, nil when the threshold is not set. A nil breaker lets every
+// call through.
+func newCircuitBreaker(conf CircuitBreakerConfig) *circuitBreaker {
+	if conf.Threshold <= 0 {
+		return nil
+	}
+
+	return &circuitBreaker{threshold: conf.Threshold, cooldown: conf.GetCooldown()}
+}
+
+// allow reports whether a call can be made, and whether it is the probe of an open circuit. The caller must report
+// the outcome of an allowed call with done.
+func (b *circuitBreaker) allow() (allowed bool, probe bool) {
+	if b == nil {
+		return true, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true, false
+	}
+
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false, false
+	}
+
+	b.probing = true
+	return true, true
+}
+
+// done records the outcome of a call, the circuit is opened for the cooldown when the failures reach the threshold.
+// While the circuit is open only the outcome of the probe counts, so the calls made before the circuit opened neither
+// close it nor let another probe through.
+func (b *circuitBreaker) done(probe bool, success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !probe && b.failures >= b.threshold {
+		return
+	}
+
+	if probe {
+		b.probing = false
+	}
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// breakerTransport is the transport of an OpenSearch client which fails the requests fast while the circuit is open.
+// The 429 and 5xx responses which are left after the retries count as failures. The metrics and the node discovery
+// of the wrapped transport are forwarded, so Metrics and DiscoverNodes of the client keep working.
+type breakerTransport struct {
+	transport opensearchtransport.Interface
+	breaker   *circuitBreaker
+}
+
+func (t *breakerTransport) Perform(req *http.Request) (*http.Response, error) {
+	allowed, probe := t.breaker.allow()
+	if !allowed {
+		return nil, ErrCircuitOpen
+	}
+
+	res, err := t.transport.Perform(req)
+	t.breaker.done(probe, err == nil && res.StatusCode != http.StatusTooManyRequests && res.StatusCode < 500)
+
+	return res, err
+}
+
+func (t *breakerTransport) Metrics() (opensearchtransport.Metrics, error) {
+	measurable, ok := t.transport.(opensearchtransport.Measurable)
+	if !ok {
+		return opensearchtransport.Metrics{}, errors.New("transport is missing method Metrics()")
+	}
+
+	return measurable.Metrics()
+}
+
+func (t *breakerTransport) DiscoverNodes() error {
+	discoverable, ok := t.transport.(opensearchtransport.Discoverable)
+	if !ok {
+		return errors.New("transport is missing method DiscoverNodes()")
+	}
+
+	return discoverable.DiscoverNodes()
+}
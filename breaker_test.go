@@ -0,0 +1,37 @@
+package repositorysdk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerClosesOnTheProbeOnly(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{Threshold: 1, Cooldown: 20 * time.Millisecond})
+
+	// a call starts while the circuit is closed, and another one opens it
+	if allowed, _ := b.allow(); !allowed {
+		t.Fatal("the closed circuit rejected a call")
+	}
+	_, probe := b.allow()
+	b.done(probe, false)
+
+	// the success of the call made before the circuit opened is not a probe
+	b.done(false, true)
+	if allowed, _ := b.allow(); allowed {
+		t.Fatal("the circuit was closed by a call made before it opened")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	allowed, probe := b.allow()
+	if !allowed || !probe {
+		t.Fatalf("allow after the cooldown = %v, %v, want the probe", allowed, probe)
+	}
+	if allowed, _ := b.allow(); allowed {
+		t.Fatal("a second call was let through during the probe")
+	}
+
+	b.done(probe, true)
+	if allowed, _ := b.allow(); !allowed {
+		t.Fatal("the circuit is still open after the probe succeeded")
+	}
+}
@@ -0,0 +1,398 @@
+package repositorysdk
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ClientSideCacheConfig is a struct that holds the settings of the client-side caching.
+type ClientSideCacheConfig struct {
+	// MaxEntries is the maximum number of caches held in memory, the least recently used is evicted first. 0 means
+	// 10000.
+	MaxEntries int
+	// TTL is the time a cache is held in memory, it bounds the staleness when redis fails to notify an invalidation.
+	// 0 means 10 minutes.
+	TTL time.Duration
+	// Prefixes are the prefixes of the keys held in memory, e.g. "config:". The keys with another prefix are always
+	// read from redis. No prefix means every key of the repository.
+	Prefixes []string
+}
+
+type clientSideCache struct {
+	RedisRepository
+	conf     ClientSideCacheConfig
+	local    *lruCache
+	prefixes []string
+	tracking *redis.Client
+	pubsub   *redis.PubSub
+	live     *atomic.Bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewClientSideCache function that create a new instance of RedisRepository which holds the caches read by GetCache
+// and GetOrSetCache in a bounded in-process LRU, invalidated by the server-assisted client-side caching of redis 6+.
+// The keys of the prefixes are tracked in the broadcasting mode, so redis notifies every write of these keys, whoever
+// made it, e.g. WatchTransaction, RunScript, or another service.
+//
+// go-redis v8 speaks RESP2, which has no push messages, so the invalidations are redirected to a dedicated connection
+// subscribed to ClientTrackingChannel. The memory is cleared whenever this connection is made again, and nothing is
+// held in memory while it is down. Close stops the tracking and closes the connection.
+//
+// Parameters:
+// - repo: the repository, its client must be a *redis.Client as the tracking is made per node.
+// - conf: the settings of the client-side caching.
+//
+// Returns:
+// - TieredCache: the repository.
+// - error: an error if the client is not supported or the tracking cannot be enabled, e.g. redis is older than 6.
+func NewClientSideCache(repo RedisRepository, conf ClientSideCacheConfig) (TieredCache, error) {
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = 10000
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = 10 * time.Minute
+	}
+
+	client, ok := repo.GetUniversalClient().(*redis.Client)
+	if !ok {
+		return nil, fmt.Errorf("client-side caching is not supported by %T", repo.GetUniversalClient())
+	}
+
+	prefixes := make([]string, len(conf.Prefixes))
+	for i, prefix := range conf.Prefixes {
+		prefixes[i] = redisKey(repo, prefix)
+	}
+	if len(prefixes) == 0 {
+		prefixes = []string{redisKey(repo, "")}
+	}
+
+	c := &clientSideCache{
+		RedisRepository: repo,
+		conf:            conf,
+		local:           newLRUCache(conf.MaxEntries),
+		prefixes:        prefixes,
+		live:            &atomic.Bool{},
+		done:            make(chan struct{}),
+	}
+
+	opt := *client.Options()
+	opt.PoolSize = 1
+	opt.MinIdleConns = 0
+	onConnect := opt.OnConnect
+	opt.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		if onConnect != nil {
+			if err := onConnect(ctx, cn); err != nil {
+				return err
+			}
+		}
+		return c.enableTracking(ctx, cn)
+	}
+	c.tracking = redis.NewClient(&opt)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c.pubsub = c.tracking.Subscribe(ctx, ClientTrackingChannel)
+	if _, err := c.pubsub.Receive(ctx); err != nil {
+		_ = c.pubsub.Close()
+		_ = c.tracking.Close()
+		return nil, fmt.Errorf("enable client-side caching: %w", err)
+	}
+	c.live.Store(true)
+
+	ctx, c.cancel = context.WithCancel(context.Background())
+	go c.receive(ctx)
+
+	return c, nil
+}
+
+// enableTracking makes redis notify the writes of the prefixes to the connection cn itself, which is about to
+// subscribe to the invalidations. The memory is cleared as the invalidations sent to the previous connection are lost.
+func (c *clientSideCache) enableTracking(ctx context.Context, cn *redis.Conn) error {
+	c.live.Store(false)
+	c.local.clear()
+
+	id, err := cn.ClientID(ctx).Result()
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{"CLIENT", "TRACKING", "on", "REDIRECT", id, "BCAST"}
+	for _, prefix := range c.prefixes {
+		args = append(args, "PREFIX", prefix)
+	}
+
+	cmd := redis.NewCmd(ctx, args...)
+	_ = cn.Process(ctx, cmd)
+
+	return cmd.Err()
+}
+
+// receive evicts the caches invalidated by redis until ctx is done.
+func (c *clientSideCache) receive(ctx context.Context) {
+	defer close(c.done)
+
+	for {
+		msg, err := c.pubsub.Receive(ctx)
+		if err != nil {
+			// a flush of the database is notified without keys, which go-redis fails to parse
+			c.local.clear()
+			if ctx.Err() != nil {
+				return
+			}
+			if isConnectionError(err) {
+				c.live.Store(false)
+				if !sleepContext(ctx, time.Second) {
+					return
+				}
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// the connection is made again and tracked
+			c.local.clear()
+			c.live.Store(true)
+		case *redis.Message:
+			for _, key := range msg.PayloadSlice {
+				c.local.remove(key)
+			}
+		}
+	}
+}
+
+// valueCodec returns the codec of the wrapped repository.
+func (c *clientSideCache) valueCodec() Codec {
+	return codecOf(c.RedisRepository)
+}
+
+// maxTTLJitter returns the ttl jitter of the wrapped repository.
+func (c *clientSideCache) maxTTLJitter() float64 {
+	return ttlJitterOf(c.RedisRepository)
+}
+
+// redisKey returns the key stored in redis for the key of the repository.
+func (c *clientSideCache) redisKey(key string) string {
+	return redisKey(c.RedisRepository, key)
+}
+
+// tracked returns the key stored in redis for the key of the repository, and whether it is held in memory.
+func (c *clientSideCache) tracked(key string) (string, bool) {
+	key = redisKey(c.RedisRepository, key)
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return key, true
+		}
+	}
+
+	return key, false
+}
+
+// GetCache retrieves the cache from memory, or from redis when it is not held in memory.
+func (c *clientSideCache) GetCache(key string, value interface{}) (err error) {
+	k, ok := c.tracked(key)
+	if !ok {
+		return c.RedisRepository.GetCache(key, value)
+	}
+	if v, ok := c.local.get(k); ok {
+		return codecOf(c.RedisRepository).Unmarshal(v, value)
+	}
+
+	defer wrapError(&err, "GetCache", "", key, time.Now())
+
+	gen := c.local.generation()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	v, err := c.GetUniversalClient().Get(ctx, k).Bytes()
+	if err != nil {
+		return err
+	}
+	if c.live.Load() {
+		c.local.set(k, v, c.conf.TTL, gen)
+	}
+
+	return codecOf(c.RedisRepository).Unmarshal(v, value)
+}
+
+// GetOrSetCache retrieves the cache from memory, or from redis or the loader when it is not held in memory.
+func (c *clientSideCache) GetOrSetCache(key string, ttl int, dest interface{}, loader func() (interface{}, error)) error {
+	k, ok := c.tracked(key)
+	if !ok {
+		return c.RedisRepository.GetOrSetCache(key, ttl, dest, loader)
+	}
+
+	codec := codecOf(c.RedisRepository)
+	if v, ok := c.local.get(k); ok {
+		return codec.Unmarshal(v, dest)
+	}
+
+	gen := c.local.generation()
+	if err := c.RedisRepository.GetOrSetCache(key, ttl, dest, loader); err != nil {
+		return err
+	}
+
+	if v, err := codec.Marshal(dest); err == nil && c.live.Load() {
+		c.local.set(k, v, c.conf.TTL, gen)
+	}
+
+	return nil
+}
+
+// GetOrSetCacheD is GetOrSetCache with the ttl as a duration.
+func (c *clientSideCache) GetOrSetCacheD(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error {
+	return c.GetOrSetCache(key, ttlSeconds(ttl), dest, loader)
+}
+
+// SaveCache saves the cache and evicts it from memory.
+func (c *clientSideCache) SaveCache(key string, value interface{}, ttl int) error {
+	defer c.evict(key)
+	return c.RedisRepository.SaveCache(key, value, ttl)
+}
+
+// SaveCacheD is SaveCache with the ttl as a duration.
+func (c *clientSideCache) SaveCacheD(key string, value interface{}, ttl time.Duration) error {
+	return c.SaveCache(key, value, ttlSeconds(ttl))
+}
+
+// SaveCacheNX saves the cache if it does not exist and evicts it from memory.
+func (c *clientSideCache) SaveCacheNX(key string, value interface{}, ttl int) (bool, error) {
+	defer c.evict(key)
+	return c.RedisRepository.SaveCacheNX(key, value, ttl)
+}
+
+// SaveCacheNXD is SaveCacheNX with the ttl as a duration.
+func (c *clientSideCache) SaveCacheNXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.SaveCacheNX(key, value, ttlSeconds(ttl))
+}
+
+// SaveCacheXX saves the cache if it exists and evicts it from memory.
+func (c *clientSideCache) SaveCacheXX(key string, value interface{}, ttl int) (bool, error) {
+	defer c.evict(key)
+	return c.RedisRepository.SaveCacheXX(key, value, ttl)
+}
+
+// SaveCacheXXD is SaveCacheXX with the ttl as a duration.
+func (c *clientSideCache) SaveCacheXXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.SaveCacheXX(key, value, ttlSeconds(ttl))
+}
+
+// SaveMultiCache saves the caches and evicts them from memory.
+func (c *clientSideCache) SaveMultiCache(values map[string]interface{}, ttl int) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	defer c.evict(keys...)
+
+	return c.RedisRepository.SaveMultiCache(values, ttl)
+}
+
+// SaveMultiCacheD is SaveMultiCache with the ttl as a duration.
+func (c *clientSideCache) SaveMultiCacheD(values map[string]interface{}, ttl time.Duration) error {
+	return c.SaveMultiCache(values, ttlSeconds(ttl))
+}
+
+// GetDelCache retrieves and removes the cache from redis and evicts it from memory.
+func (c *clientSideCache) GetDelCache(key string, dest interface{}) error {
+	defer c.evict(key)
+	return c.RedisRepository.GetDelCache(key, dest)
+}
+
+// RemoveCache removes the cache and evicts it from memory.
+func (c *clientSideCache) RemoveCache(key string) error {
+	defer c.evict(key)
+	return c.RedisRepository.RemoveCache(key)
+}
+
+// CopyCache copies the cache and evicts the copy from memory.
+func (c *clientSideCache) CopyCache(src string, dst string, replace bool) (bool, error) {
+	defer c.evict(dst)
+	return c.RedisRepository.CopyCache(src, dst, replace)
+}
+
+// RenameCache renames the cache and evicts both keys from memory.
+func (c *clientSideCache) RenameCache(src string, dst string) error {
+	defer c.evict(src, dst)
+	return c.RedisRepository.RenameCache(src, dst)
+}
+
+// RemoveCacheByPattern removes the caches matching the pattern and clears the memory.
+func (c *clientSideCache) RemoveCacheByPattern(pattern string) (int64, error) {
+	defer c.local.clear()
+	return c.RedisRepository.RemoveCacheByPattern(pattern)
+}
+
+// IncrementCache increments the counter and evicts it from memory.
+func (c *clientSideCache) IncrementCache(key string, by int64, ttl int) (int64, error) {
+	defer c.evict(key)
+	return c.RedisRepository.IncrementCache(key, by, ttl)
+}
+
+// IncrementCacheD is IncrementCache with the ttl as a duration.
+func (c *clientSideCache) IncrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return c.IncrementCache(key, by, ttlSeconds(ttl))
+}
+
+// DecrementCache decrements the counter and evicts it from memory.
+func (c *clientSideCache) DecrementCache(key string, by int64, ttl int) (int64, error) {
+	defer c.evict(key)
+	return c.RedisRepository.DecrementCache(key, by, ttl)
+}
+
+// DecrementCacheD is DecrementCache with the ttl as a duration.
+func (c *clientSideCache) DecrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return c.DecrementCache(key, by, ttlSeconds(ttl))
+}
+
+// Pipeline sends the writes of the pipeline and evicts their keys from memory.
+func (c *clientSideCache) Pipeline(fn func(p RedisPipeline) error) error {
+	recorded := &keyRecordingPipeline{}
+	defer func() {
+		c.evict(recorded.keys...)
+	}()
+
+	return c.RedisRepository.Pipeline(func(p RedisPipeline) error {
+		recorded.RedisPipeline = p
+		return fn(recorded)
+	})
+}
+
+// WithContext returns a copy of the repository whose calls run under ctx, the copy shares the memory and the tracking
+// of the repository.
+func (c *clientSideCache) WithContext(ctx context.Context) RedisRepository {
+	t := *c
+	t.RedisRepository = c.RedisRepository.WithContext(ctx)
+
+	return &t
+}
+
+// Close stops the tracking, closes its connection, and clears the memory.
+func (c *clientSideCache) Close() error {
+	c.cancel()
+	err := c.pubsub.Close()
+	<-c.done
+	c.live.Store(false)
+	c.local.clear()
+
+	if cerr := c.tracking.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// evict evicts the keys from memory without waiting for the notification of redis, so the writes are read back at
+// once.
+func (c *clientSideCache) evict(keys ...string) {
+	for _, key := range keys {
+		c.local.remove(redisKey(c.RedisRepository, key))
+	}
+}
@@ -0,0 +1,92 @@
+package repositorysdk
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"sync"
+)
+
+// forEachRedisNode calls fn with the client of every master of a cluster, one at a time, or with the client itself
+// when it is not a cluster client. It is used by the commands which only see the keys of a single node, e.g. `SCAN`.
+func forEachRedisNode(ctx context.Context, client redis.UniversalClient, fn func(ctx context.Context, node redis.UniversalClient) error) error {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return fn(ctx, client)
+	}
+
+	// ForEachMaster calls fn concurrently
+	var mu sync.Mutex
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return fn(ctx, node)
+	})
+}
+
+// multiGet retrieves the values of the keys as the command `MGET` does, nil for the missing keys. On a cluster the keys
+// are retrieved by a pipeline of `GET`, as they may not share a hash slot.
+func multiGet(ctx context.Context, client redis.UniversalClient, keys []string) ([]interface{}, error) {
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		return client.MGet(ctx, keys...).Result()
+	}
+
+	cmds := make([]*redis.StringCmd, len(keys))
+	if _, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		v, err := cmd.Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+// unlinkKeys removes the keys with the command `UNLINK`. On a cluster the keys are removed by a pipeline of `UNLINK`,
+// as they may not share a hash slot.
+func unlinkKeys(ctx context.Context, client redis.UniversalClient, keys ...string) (int64, error) {
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		return client.Unlink(ctx, keys...).Result()
+	}
+
+	cmds := make([]*redis.IntCmd, len(keys))
+	if _, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Unlink(ctx, key)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	for _, cmd := range cmds {
+		removed += cmd.Val()
+	}
+
+	return removed, nil
+}
+
+// redisDB returns the database selected by the client, a cluster only has the database 0.
+func redisDB(client redis.UniversalClient) int {
+	if c, ok := client.(*redis.Client); ok {
+		return c.Options().DB
+	}
+
+	return 0
+}
@@ -0,0 +1,481 @@
+// Command mockgen generates the package mocks, with a mock of every exported interface of repositorysdk. It is run by
+// go:generate from the package mocks, so the mocks follow the interfaces whenever they change.
+//
+// A mock holds a function field per method, named after the method with the suffix Func, and a method panics if its
+// function is not set. The mock of an interface which embeds another one embeds its mock.
+//
+// Usage:
+//
+//	mockgen -source <dir of repositorysdk> -output <file>
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const sdkPath = "github.com/PromptSnapshot/repositorysdk"
+
+func main() {
+	source := flag.String("source", "..", "the directory of the package repositorysdk")
+	output := flag.String("output", "mocks_gen.go", "the path of the generated file")
+	flag.Parse()
+
+	src, err := generate(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// mockedInterface is an exported interface of the package and the file which declares it.
+type mockedInterface struct {
+	name    string
+	spec    *ast.TypeSpec
+	iface   *ast.InterfaceType
+	imports map[string]string
+}
+
+func generate(dir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, ok := pkgs["repositorysdk"]
+	if !ok {
+		return nil, fmt.Errorf("package repositorysdk not found in %s", dir)
+	}
+
+	types := map[string]bool{}
+	var ifaces []mockedInterface
+	for _, file := range pkg.Files {
+		imports := fileImports(file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				types[spec.Name.Name] = true
+
+				iface, ok := spec.Type.(*ast.InterfaceType)
+				if !ok || !spec.Name.IsExported() || !mockable(iface) {
+					continue
+				}
+				ifaces = append(ifaces, mockedInterface{name: spec.Name.Name, spec: spec, iface: iface, imports: imports})
+			}
+		}
+	}
+	sort.Slice(ifaces, func(i, j int) bool {
+		return ifaces[i].name < ifaces[j].name
+	})
+
+	g := &generator{fset: fset, types: types, imports: map[string]string{sdkPath: "repositorysdk"}}
+	for _, iface := range ifaces {
+		if err := g.mock(iface); err != nil {
+			return nil, fmt.Errorf("mock %s: %w", iface.name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by mockgen; DO NOT EDIT.\n\npackage mocks\n\n")
+	g.writeImports(&out)
+	out.Write(g.body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+// fileImports returns the import paths of the file by the name they are referred to.
+func fileImports(file *ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		if spec.Name != nil {
+			imports[spec.Name.Name] = p
+		} else {
+			imports[packageName(p)] = p
+		}
+	}
+
+	return imports
+}
+
+// packageName guesses the name of a package from its path, github.com/go-redis/redis/v8 is named redis.
+func packageName(p string) string {
+	base := path.Base(p)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(p))
+	}
+
+	return base
+}
+
+// mockable reports whether the interface can be implemented outside of the package and is not a constraint.
+func mockable(iface *ast.InterfaceType) bool {
+	for _, field := range iface.Methods.List {
+		switch field.Type.(type) {
+		case *ast.FuncType:
+			if !field.Names[0].IsExported() {
+				return false
+			}
+		case *ast.Ident, *ast.IndexExpr, *ast.IndexListExpr, *ast.SelectorExpr:
+		default:
+			// a union or an approximation element of a constraint
+			return false
+		}
+	}
+
+	return true
+}
+
+type generator struct {
+	fset    *token.FileSet
+	types   map[string]bool
+	imports map[string]string
+	body    bytes.Buffer
+}
+
+func (g *generator) mock(iface mockedInterface) error {
+	mock := "Mock" + iface.name
+
+	typeParams := map[string]bool{}
+	var params, args, constraints []string
+	if iface.spec.TypeParams != nil {
+		for _, field := range iface.spec.TypeParams.List {
+			constraint, err := g.expr(field.Type, iface.imports, typeParams)
+			if err != nil {
+				return err
+			}
+			for _, name := range field.Names {
+				typeParams[name.Name] = true
+				params = append(params, name.Name+" "+constraint)
+				args = append(args, name.Name)
+				constraints = append(constraints, constraint)
+			}
+		}
+	}
+
+	var typeParamList, typeArgList string
+	if len(params) > 0 {
+		typeParamList = "[" + strings.Join(params, ", ") + "]"
+		typeArgList = "[" + strings.Join(args, ", ") + "]"
+	}
+
+	fmt.Fprintf(&g.body, "// %s is a mock of repositorysdk.%s, a method panics if its function is not set.\n", mock, iface.name)
+	fmt.Fprintf(&g.body, "type %s%s struct {\n", mock, typeParamList)
+
+	var methods []*ast.Field
+	for _, field := range iface.iface.Methods.List {
+		if _, ok := field.Type.(*ast.FuncType); ok {
+			methods = append(methods, field)
+			continue
+		}
+
+		embedded, err := g.embeddedMock(field.Type, iface.imports, typeParams)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.body, "\t%s\n", embedded)
+	}
+
+	for _, field := range methods {
+		fn, err := g.expr(field.Type, iface.imports, typeParams)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.body, "\t%sFunc %s\n", field.Names[0].Name, fn)
+	}
+	g.body.WriteString("}\n\n")
+
+	// the generic interfaces are checked with their constraints as type arguments
+	target := "repositorysdk." + iface.name
+	instance := mock
+	if len(constraints) > 0 {
+		target += "[" + strings.Join(constraints, ", ") + "]"
+		instance += "[" + strings.Join(constraints, ", ") + "]"
+	}
+	fmt.Fprintf(&g.body, "var _ %s = (*%s)(nil)\n\n", target, instance)
+
+	for _, field := range methods {
+		if err := g.method(mock+typeArgList, mock, field, iface.imports, typeParams); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// embeddedMock returns the mock embedded for an interface embedded in another one.
+func (g *generator) embeddedMock(e ast.Expr, imports map[string]string, typeParams map[string]bool) (string, error) {
+	var name string
+	var typeArgs []ast.Expr
+	switch t := e.(type) {
+	case *ast.Ident:
+		name = t.Name
+	case *ast.IndexExpr:
+		ident, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("unsupported embedded interface %s", g.source(e))
+		}
+		name, typeArgs = ident.Name, []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		ident, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("unsupported embedded interface %s", g.source(e))
+		}
+		name, typeArgs = ident.Name, t.Indices
+	default:
+		return "", fmt.Errorf("unsupported embedded interface %s", g.source(e))
+	}
+
+	if !g.types[name] || !ast.IsExported(name) {
+		return "", fmt.Errorf("unsupported embedded interface %s", g.source(e))
+	}
+
+	if len(typeArgs) == 0 {
+		return "Mock" + name, nil
+	}
+
+	args := make([]string, len(typeArgs))
+	for i, arg := range typeArgs {
+		s, err := g.expr(arg, imports, typeParams)
+		if err != nil {
+			return "", err
+		}
+		args[i] = s
+	}
+
+	return "Mock" + name + "[" + strings.Join(args, ", ") + "]", nil
+}
+
+// method writes the method of the mock which calls its function field.
+func (g *generator) method(receiver string, mock string, field *ast.Field, imports map[string]string, typeParams map[string]bool) error {
+	name := field.Names[0].Name
+	fn := field.Type.(*ast.FuncType)
+
+	var params, args []string
+	i := 0
+	for _, param := range fn.Params.List {
+		names := param.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+
+		for range names {
+			arg := "p" + strconv.Itoa(i)
+			i++
+
+			if ellipsis, ok := param.Type.(*ast.Ellipsis); ok {
+				t, err := g.expr(ellipsis.Elt, imports, typeParams)
+				if err != nil {
+					return err
+				}
+				params = append(params, arg+" ..."+t)
+				args = append(args, arg+"...")
+				continue
+			}
+
+			t, err := g.expr(param.Type, imports, typeParams)
+			if err != nil {
+				return err
+			}
+			params = append(params, arg+" "+t)
+			args = append(args, arg)
+		}
+	}
+
+	var results []string
+	if fn.Results != nil {
+		for _, result := range fn.Results.List {
+			t, err := g.expr(result.Type, imports, typeParams)
+			if err != nil {
+				return err
+			}
+			for j := 0; j < len(result.Names) || j == 0; j++ {
+				results = append(results, t)
+			}
+		}
+	}
+
+	resultList := strings.Join(results, ", ")
+	if len(results) > 1 {
+		resultList = "(" + resultList + ")"
+	}
+
+	fmt.Fprintf(&g.body, "func (m *%s) %s(%s) %s {\n", receiver, name, strings.Join(params, ", "), resultList)
+	fmt.Fprintf(&g.body, "\tif m.%sFunc == nil {\n\t\tpanic(%s)\n\t}\n", name, strconv.Quote(mock+"."+name+" is not set"))
+	if len(results) > 0 {
+		fmt.Fprintf(&g.body, "\treturn m.%sFunc(%s)\n}\n\n", name, strings.Join(args, ", "))
+	} else {
+		fmt.Fprintf(&g.body, "\tm.%sFunc(%s)\n}\n\n", name, strings.Join(args, ", "))
+	}
+
+	return nil
+}
+
+// expr returns the source of the type expression as seen from the package mocks: the types of repositorysdk are
+// qualified, and the packages it refers to are imported.
+func (g *generator) expr(e ast.Expr, imports map[string]string, typeParams map[string]bool) (string, error) {
+	var err error
+	qualified := qualify(e, func(x ast.Expr) ast.Expr {
+		switch t := x.(type) {
+		case *ast.Ident:
+			if g.types[t.Name] && !typeParams[t.Name] {
+				return &ast.SelectorExpr{X: ast.NewIdent("repositorysdk"), Sel: ast.NewIdent(t.Name)}
+			}
+		case *ast.SelectorExpr:
+			pkg, ok := t.X.(*ast.Ident)
+			if !ok {
+				break
+			}
+			p, ok := imports[pkg.Name]
+			if !ok {
+				err = fmt.Errorf("unknown package %s", pkg.Name)
+				break
+			}
+			return &ast.SelectorExpr{X: ast.NewIdent(g.qualifier(p, pkg.Name)), Sel: ast.NewIdent(t.Sel.Name)}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return g.source(qualified), nil
+}
+
+// qualifier returns the name the package is imported under, name unless it is taken by another package.
+func (g *generator) qualifier(p string, name string) string {
+	if q, ok := g.imports[p]; ok {
+		return q
+	}
+
+	taken := map[string]bool{}
+	for _, q := range g.imports {
+		taken[q] = true
+	}
+
+	q := name
+	for i := 2; taken[q]; i++ {
+		q = name + strconv.Itoa(i)
+	}
+	g.imports[p] = q
+
+	return q
+}
+
+func (g *generator) source(e ast.Expr) string {
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, g.fset, e)
+	return b.String()
+}
+
+func (g *generator) writeImports(w *bytes.Buffer) {
+	paths := make([]string, 0, len(g.imports))
+	for p := range g.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	w.WriteString("import (\n")
+	for _, p := range paths {
+		if q := g.imports[p]; q != packageName(p) {
+			fmt.Fprintf(w, "\t%s %s\n", q, strconv.Quote(p))
+		} else {
+			fmt.Fprintf(w, "\t%s\n", strconv.Quote(p))
+		}
+	}
+	w.WriteString(")\n\n")
+}
+
+// qualify returns a copy of the type expression where every identifier and selector replaced by fn is substituted,
+// fn returns nil to keep the expression. The copy holds no position, so it is printed on a single line.
+func qualify(e ast.Expr, fn func(ast.Expr) ast.Expr) ast.Expr {
+	if e == nil {
+		return nil
+	}
+	if r := fn(e); r != nil {
+		return r
+	}
+
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(t.X, fn)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: qualify(t.Elt, fn)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualify(t.Key, fn), Value: qualify(t.Value, fn)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: t.Dir, Value: qualify(t.Value, fn)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: qualify(t.Elt, fn)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: qualify(t.X, fn), Index: qualify(t.Index, fn)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(t.Indices))
+		for i, index := range t.Indices {
+			indices[i] = qualify(index, fn)
+		}
+		return &ast.IndexListExpr{X: qualify(t.X, fn), Indices: indices}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: qualifyFields(t.Params, fn), Results: qualifyFields(t.Results, fn)}
+	case *ast.InterfaceType:
+		if len(t.Methods.List) == 0 {
+			// printed as interface{} rather than over two lines
+			return ast.NewIdent("interface{}")
+		}
+		return &ast.InterfaceType{Methods: qualifyFields(t.Methods, fn)}
+	case *ast.StructType:
+		if len(t.Fields.List) == 0 {
+			return ast.NewIdent("struct{}")
+		}
+		return &ast.StructType{Fields: qualifyFields(t.Fields, fn)}
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{X: qualify(t.X, fn)}
+	case *ast.SelectorExpr:
+		return &ast.SelectorExpr{X: qualify(t.X, fn), Sel: ast.NewIdent(t.Sel.Name)}
+	case *ast.Ident:
+		return ast.NewIdent(t.Name)
+	}
+
+	return e
+}
+
+func qualifyFields(fields *ast.FieldList, fn func(ast.Expr) ast.Expr) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+
+	out := &ast.FieldList{}
+	for _, field := range fields.List {
+		names := make([]*ast.Ident, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = ast.NewIdent(name.Name)
+		}
+		out.List = append(out.List, &ast.Field{Names: names, Type: qualify(field.Type, fn)})
+	}
+
+	return out
+}
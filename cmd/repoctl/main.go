@@ -0,0 +1,46 @@
+// Command repoctl runs the maintenance tasks of a service against its config, see repositorysdk.MaintenanceTool.
+//
+// This command knows no entity, so migrate, seed, and reindex-opensearch have nothing to do. A service builds its own
+// repoctl by copying this file and filling the entities and the seeders of the tool.
+//
+// Usage:
+//
+//	repoctl -config config.json <command> [flags]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/PromptSnapshot/repositorysdk"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	configPath := flag.String("config", "config.json", "the path of the json config of the service")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: repoctl -config <path> <command> [flags]\n\n%s\n", repositorysdk.MaintenanceUsage)
+	}
+	flag.Parse()
+
+	conf, err := repositorysdk.LoadMaintenanceConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	tool := &repositorysdk.MaintenanceTool{
+		Config: *conf,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := tool.Run(ctx, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		stop()
+		os.Exit(1)
+	}
+}
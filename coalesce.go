@@ -0,0 +1,162 @@
+package repositorysdk
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CoalescingWriterConfig is a struct that holds the settings of a coalescing writer.
+type CoalescingWriterConfig struct {
+	// Window is the buffering time of a write, the buffered caches are written once it elapses. 0 means 100
+	// milliseconds.
+	Window time.Duration
+	// MaxEntries is the number of buffered keys which makes the writer flush before the window elapses, it bounds the
+	// memory and the size of the pipelines. 0 means 1000.
+	MaxEntries int
+	// OnError is the handler of the errors of the writes made when the window elapses, it may be nil.
+	OnError func(err error)
+}
+
+type CoalescingWriter interface {
+	SaveCache(key string, value interface{}, ttl int) error
+	Flush() error
+	Close() error
+}
+
+type coalescingWriter struct {
+	repo RedisRepository
+	conf CoalescingWriterConfig
+
+	mu      sync.Mutex
+	pending map[string]coalescedWrite
+	timer   *time.Timer
+	closed  bool
+
+	// flushMu is held across the writes of a flush, so a flush never overtakes the older values of the previous one
+	flushMu sync.Mutex
+}
+
+type coalescedWrite struct {
+	value encodedValue
+	ttl   int
+}
+
+// NewCoalescingWriter function that create a new instance of CoalescingWriter which buffers the writes of the caches
+// for the window and writes only the last value of each key, in a single pipeline per ttl. The errors of the writes
+// made when the window elapses are passed to onError, which may be nil.
+func NewCoalescingWriter(repo RedisRepository, window time.Duration, onError func(err error)) CoalescingWriter {
+	return NewCoalescingWriterWithConfig(repo, CoalescingWriterConfig{Window: window, OnError: onError})
+}
+
+// NewCoalescingWriterWithConfig function that create a new instance of CoalescingWriter as NewCoalescingWriter does,
+// the buffered caches are also written as soon as conf.MaxEntries keys are buffered, e.g. for the write-behind of a
+// high volume of caches whose round trips are the bottleneck.
+func NewCoalescingWriterWithConfig(repo RedisRepository, conf CoalescingWriterConfig) CoalescingWriter {
+	if conf.Window <= 0 {
+		conf.Window = 100 * time.Millisecond
+	}
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = 1000
+	}
+
+	return &coalescingWriter{
+		repo:    repo,
+		conf:    conf,
+		pending: map[string]coalescedWrite{},
+	}
+}
+
+// SaveCache buffers the cache, it replaces the value buffered for the same key within the window. The value is
+// encoded right away, so it can be modified after the call. The call which buffers the MaxEntries-th key writes the
+// buffered caches itself, so the callers are slowed down rather than the memory grown when redis lags behind.
+//
+// Parameters:
+// - key: the cache key.
+// - value: the cache value to be saved.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+//
+// Returns:
+// - error: an error if the value cannot be encoded, the writer is closed, or the buffered caches cannot be written,
+// otherwise nil.
+func (w *coalescingWriter) SaveCache(key string, value interface{}, ttl int) (err error) {
+	defer wrapError(&err, "SaveCache", "", key, time.Now())
+
+	v, err := codecOf(w.repo).Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+
+	w.pending[key] = coalescedWrite{value: v, ttl: ttl}
+	full := len(w.pending) >= w.conf.MaxEntries
+	if w.timer == nil && !full {
+		w.timer = time.AfterFunc(w.conf.Window, w.flushWindow)
+	}
+	w.mu.Unlock()
+
+	if full {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Flush writes the buffered caches right away, in a pipeline per ttl. The pipelines are all sent even if one of them
+// fails. The flushes are serialized, a flush waits for the writes of the previous one, so the last value of a key is
+// the one left in redis.
+//
+// Returns:
+// - error: the errors of the failed pipelines joined, otherwise nil.
+func (w *coalescingWriter) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = map[string]coalescedWrite{}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+
+	byTTL := map[int]map[string]interface{}{}
+	for key, write := range pending {
+		if byTTL[write.ttl] == nil {
+			byTTL[write.ttl] = map[string]interface{}{}
+		}
+		byTTL[write.ttl][key] = write.value
+	}
+
+	// every group is written, a failed group does not drop the others
+	var errs []error
+	for ttl, values := range byTTL {
+		errs = append(errs, w.repo.SaveMultiCache(values, ttl))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close flushes the buffered caches, the writer rejects the writes made after it is closed.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (w *coalescingWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	return w.Flush()
+}
+
+func (w *coalescingWriter) flushWindow() {
+	if err := w.Flush(); err != nil && w.conf.OnError != nil {
+		w.conf.OnError(err)
+	}
+}
@@ -0,0 +1,65 @@
+package repositorysdk
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingMultiCache is a RedisRepository whose first SaveMultiCache waits until it is released.
+type blockingMultiCache struct {
+	RedisRepository
+
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+
+	mu     sync.Mutex
+	stored map[string]string
+}
+
+func (r *blockingMultiCache) SaveMultiCache(values map[string]interface{}, ttl int) error {
+	first := false
+	r.once.Do(func() { first = true })
+	if first {
+		close(r.entered)
+		<-r.release
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, value := range values {
+		r.stored[key] = string(value.(encodedValue))
+	}
+	return nil
+}
+
+func TestCoalescingWriterKeepsTheLastValueOfConcurrentFlushes(t *testing.T) {
+	repo := &blockingMultiCache{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+		stored:  map[string]string{},
+	}
+	w := NewCoalescingWriterWithConfig(repo, CoalescingWriterConfig{Window: time.Hour, MaxEntries: 1})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = w.SaveCache("presence", 1, 0)
+	}()
+	<-repo.entered
+
+	// the newer value is flushed while the older one is still being written
+	go func() {
+		defer wg.Done()
+		_ = w.SaveCache("presence", 2, 0)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	if got := repo.stored["presence"]; got != "2" {
+		t.Errorf("stored value = %s, want the last value 2", got)
+	}
+}
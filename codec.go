@@ -0,0 +1,68 @@
+package repositorysdk
+
+import (
+	"encoding/json"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Codec encodes the values saved by RedisRepository and decodes the values it retrieves.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the Codec which encodes the values in json, it is the default codec of RedisRepository.
+type JSONCodec struct{}
+
+// Marshal encodes v in json.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes the json data into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// RedisOption is a function that configures the optional behaviours of a redis repository.
+type RedisOption func(*redisOptions)
+
+type redisOptions struct {
+	codec           Codec
+	earlyRefresh    float64
+	metrics         RedisMetrics
+	tracer          trace.Tracer
+	hashFieldExpiry bool
+	ttlJitter       float64
+}
+
+// WithCodec sets the codec of the values saved and retrieved by the repository, such as msgpack or protobuf for the
+// large cached objects. The caches written with another codec cannot be read back, so the keys should be changed or
+// flushed when the codec is changed.
+func WithCodec(codec Codec) RedisOption {
+	return func(o *redisOptions) {
+		o.codec = codec
+	}
+}
+
+// encodedValue is a value which is already encoded by the codec of the repository, it is saved as it is.
+type encodedValue []byte
+
+// encode encodes the value with the codec, an encodedValue is returned as it is.
+func encode(codec Codec, value interface{}) ([]byte, error) {
+	if v, ok := value.(encodedValue); ok {
+		return v, nil
+	}
+
+	return codec.Marshal(value)
+}
+
+// codecOf returns the codec of the repository, the codec of the wrapped repository for the wrappers. It is used by
+// the helpers which encode the values before passing them to the repository.
+func codecOf(repo RedisRepository) Codec {
+	if c, ok := repo.(interface{ valueCodec() Codec }); ok {
+		return c.valueCodec()
+	}
+
+	return JSONCodec{}
+}
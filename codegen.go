@@ -0,0 +1,296 @@
+package repositorysdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateConfig is a struct that holds the settings of the code generated from a SchemaRegistry.
+type GenerateConfig struct {
+	// Output is the path of the generated file, e.g. repository_gen.go.
+	Output string
+	// Package is the name of the package of the generated file.
+	Package string
+	// PackagePath is the import path of the package of the generated file, the entities of this package are referred
+	// to without qualifier. It may be empty when the entities live in another package.
+	PackagePath string
+}
+
+// Generate writes the per-entity code of the registry into conf.Output, it is meant to be run by go:generate from a
+// small main package which imports the registry of the service, e.g.
+//
+//	//go:generate go run ./cmd/schemagen
+//
+// For every entity it emits a typed repository constructor, a cache key builder, the OpenSearch mapping of the
+// entities with searchable fields, and a mock of its GormRepository whose methods call the function fields.
+//
+// Parameters:
+// - conf: the output of the generated code.
+//
+// Returns:
+// - error: an error if the code cannot be generated or written, otherwise nil.
+func (r *SchemaRegistry) Generate(conf GenerateConfig) error {
+	if conf.Output == "" {
+		return fmt.Errorf("missing output of the generated code")
+	}
+
+	src, err := r.GenerateSource(conf)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(conf.Output, src, 0o644)
+}
+
+// GenerateSource returns the code written by Generate, formatted by gofmt.
+func (r *SchemaRegistry) GenerateSource(conf GenerateConfig) ([]byte, error) {
+	if conf.Package == "" {
+		return nil, fmt.Errorf("missing package of the generated code")
+	}
+
+	imports := newCodegenImports(conf.PackagePath)
+	sdk := imports.qualifier(reflect.TypeOf(PaginationMetadata{}).PkgPath())
+	imports.qualifier("gorm.io/gorm")
+
+	var body bytes.Buffer
+	for _, s := range r.Schemas() {
+		if err := generateEntity(&body, imports, sdk, s); err != nil {
+			return nil, fmt.Errorf("generate %s: %w", s.Name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by repositorysdk; DO NOT EDIT.\n\npackage %s\n\n", conf.Package)
+	imports.write(&out)
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+func generateEntity(w *bytes.Buffer, imports *codegenImports, sdk string, s EntitySchema) error {
+	entity := imports.typeString(s.Type)
+	repo := fmt.Sprintf("%s.GormRepository[%s]", sdk, entity)
+	mock := "Mock" + s.Name + "Repository"
+
+	fmt.Fprintf(w, "// New%sRepository function that create a new instance of GormRepository for %s.\n", s.Name, entity)
+	fmt.Fprintf(w, "func New%sRepository(db *gorm.DB, opts ...%s.GormOption) %s {\n", s.Name, sdk, repo)
+	fmt.Fprintf(w, "\treturn %s.NewGormRepository[%s](db, opts...)\n}\n\n", sdk, entity)
+
+	fmt.Fprintf(w, "// %sCacheKey returns the cache key of the %s with the given id.\n", s.Name, entity)
+	fmt.Fprintf(w, "func %sCacheKey(id string) string {\n\treturn %s + id\n}\n\n", s.Name, strconv.Quote(s.CacheKeyPrefix))
+
+	if mapping := s.SearchMapping(); mapping != nil {
+		b, err := json.MarshalIndent(mapping, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "// %sSearchMapping is the OpenSearch mapping of the index %s.\n", s.Name, s.Table)
+		fmt.Fprintf(w, "const %sSearchMapping = %s\n\n", s.Name, quoteCodegenString(string(b)))
+	}
+
+	fmt.Fprintf(w, "// %s is a mock of GormRepository for %s, a method panics if its function is not set.\n", mock, entity)
+	fmt.Fprintf(w, "type %s struct {\n", mock)
+	for i := 0; i < s.repoType.NumMethod(); i++ {
+		m := s.repoType.Method(i)
+		fmt.Fprintf(w, "\t%sFunc %s\n", m.Name, imports.typeString(m.Type))
+	}
+	fmt.Fprintf(w, "}\n\nvar _ %s = (*%s)(nil)\n\n", repo, mock)
+
+	for i := 0; i < s.repoType.NumMethod(); i++ {
+		m := s.repoType.Method(i)
+		params, args := imports.params(m.Type)
+
+		fmt.Fprintf(w, "func (m *%s) %s(%s) %s {\n", mock, m.Name, params, imports.results(m.Type))
+		fmt.Fprintf(w, "\tif m.%sFunc == nil {\n\t\tpanic(%s)\n\t}\n", m.Name, strconv.Quote(mock+"."+m.Name+" is not set"))
+		if m.Type.NumOut() > 0 {
+			fmt.Fprintf(w, "\treturn m.%sFunc(%s)\n}\n\n", m.Name, args)
+		} else {
+			fmt.Fprintf(w, "\tm.%sFunc(%s)\n}\n\n", m.Name, args)
+		}
+	}
+
+	return nil
+}
+
+// quoteCodegenString returns s as a raw string literal, or as an interpreted one if it contains a backquote.
+func quoteCodegenString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+
+	return "`" + s + "`"
+}
+
+// codegenImports holds the packages imported by the generated code and their qualifiers.
+type codegenImports struct {
+	self       string
+	qualifiers map[string]string
+	used       map[string]bool
+}
+
+func newCodegenImports(self string) *codegenImports {
+	return &codegenImports{
+		self:       self,
+		qualifiers: map[string]string{},
+		used:       map[string]bool{},
+	}
+}
+
+// qualifier returns the qualifier of the package, it is imported under another name if its name is already taken.
+func (c *codegenImports) qualifier(pkgPath string) string {
+	if q, ok := c.qualifiers[pkgPath]; ok {
+		return q
+	}
+
+	base := path.Base(pkgPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		// github.com/foo/bar/v2 is named bar
+		base = path.Base(path.Dir(pkgPath))
+	}
+	base = strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, base)
+
+	q := base
+	for i := 2; c.used[q]; i++ {
+		q = base + strconv.Itoa(i)
+	}
+
+	c.qualifiers[pkgPath] = q
+	c.used[q] = true
+
+	return q
+}
+
+func (c *codegenImports) write(w *bytes.Buffer) {
+	paths := make([]string, 0, len(c.qualifiers))
+	for p := range c.qualifiers {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	w.WriteString("import (\n")
+	for _, p := range paths {
+		if q := c.qualifiers[p]; q != path.Base(p) {
+			fmt.Fprintf(w, "\t%s %s\n", q, strconv.Quote(p))
+		} else {
+			fmt.Fprintf(w, "\t%s\n", strconv.Quote(p))
+		}
+	}
+	w.WriteString(")\n\n")
+}
+
+// typeString returns the Go source of the type t.
+func (c *codegenImports) typeString(t reflect.Type) string {
+	if t.Name() != "" {
+		name := t.Name()
+		if i := strings.IndexByte(name, '['); i >= 0 {
+			// an instantiated generic type, e.g. GormRepository[*example.com/model.User]
+			name = name[:i] + c.typeArgs(name[i:])
+		}
+		if t.PkgPath() == "" || t.PkgPath() == c.self {
+			return name
+		}
+		return c.qualifier(t.PkgPath()) + "." + name
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + c.typeString(t.Elem())
+	case reflect.Slice:
+		return "[]" + c.typeString(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), c.typeString(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", c.typeString(t.Key()), c.typeString(t.Elem()))
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + c.typeString(t.Elem())
+		case reflect.SendDir:
+			return "chan<- " + c.typeString(t.Elem())
+		}
+		return "chan " + c.typeString(t.Elem())
+	case reflect.Func:
+		params := make([]string, t.NumIn())
+		for i := range params {
+			if t.IsVariadic() && i == len(params)-1 {
+				params[i] = "..." + c.typeString(t.In(i).Elem())
+				continue
+			}
+			params[i] = c.typeString(t.In(i))
+		}
+		return strings.TrimSpace("func(" + strings.Join(params, ", ") + ") " + c.results(t))
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}"
+		}
+	case reflect.Struct:
+		if t.NumField() == 0 {
+			return "struct{}"
+		}
+	}
+
+	// the unnamed interfaces and structs are not used by the repositories
+	return t.String()
+}
+
+// typeArgPattern matches the types of the type arguments named by reflect, which are qualified by the full import
+// path of their package, e.g. example.com/model.User.
+var typeArgPattern = regexp.MustCompile(`([\w.~-]+(?:/[\w.~-]+)*)\.(\w+)`)
+
+// typeArgs returns the Go source of the type arguments of an instantiated generic type, as named by reflect.
+func (c *codegenImports) typeArgs(args string) string {
+	return typeArgPattern.ReplaceAllStringFunc(args, func(name string) string {
+		m := typeArgPattern.FindStringSubmatch(name)
+		if m[1] == c.self {
+			return m[2]
+		}
+		return c.qualifier(m[1]) + "." + m[2]
+	})
+}
+
+// params returns the parameters of the function type t, named p0, p1, ..., and the arguments passing them on.
+func (c *codegenImports) params(t reflect.Type) (string, string) {
+	params := make([]string, t.NumIn())
+	args := make([]string, t.NumIn())
+	for i := 0; i < t.NumIn(); i++ {
+		name := "p" + strconv.Itoa(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			params[i] = name + " ..." + c.typeString(t.In(i).Elem())
+			args[i] = name + "..."
+			continue
+		}
+		params[i] = name + " " + c.typeString(t.In(i))
+		args[i] = name
+	}
+
+	return strings.Join(params, ", "), strings.Join(args, ", ")
+}
+
+// results returns the results of the function type t.
+func (c *codegenImports) results(t reflect.Type) string {
+	results := make([]string, t.NumOut())
+	for i := 0; i < t.NumOut(); i++ {
+		results[i] = c.typeString(t.Out(i))
+	}
+
+	if len(results) > 1 {
+		return "(" + strings.Join(results, ", ") + ")"
+	}
+
+	return strings.Join(results, "")
+}
@@ -0,0 +1,31 @@
+package repositorysdk
+
+import (
+	"github.com/PromptSnapshot/repositorysdk/testdata/model"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSourceEntityOfAnotherPackage(t *testing.T) {
+	r := NewSchemaRegistry()
+	RegisterEntity[*model.User](r)
+
+	src, err := r.GenerateSource(GenerateConfig{Package: "repo"})
+	if err != nil {
+		t.Fatalf("generate source: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "repository_gen.go", src, parser.AllErrors); err != nil {
+		t.Fatalf("parse generated source: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		`"github.com/PromptSnapshot/repositorysdk/testdata/model"`,
+		"repositorysdk.GormRepository[*model.User]",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated source is missing %s\n%s", want, src)
+		}
+	}
+}
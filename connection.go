@@ -1,24 +1,40 @@
 package repositorysdk
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
+	"github.com/opensearch-project/opensearch-go/v2"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
+	"math/rand"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
 )
 
 // PostgresDatabaseConfig is a struct that holds the configuration details required to establish a connection
 // with a PostgreSQL database.
 type PostgresDatabaseConfig struct {
-	Host        string `mapstructure:"host"`
-	Port        int    `mapstructure:"port"`
-	User        string `mapstructure:"username"`
-	Password    string `mapstructure:"password"`
-	Name        string `mapstructure:"name"`
-	SSL         string `mapstructure:"ssl"`
-	MaxIdleConn int    `mapstructure:"max_idle_conn"`
-	MaxOpenConn int    `mapstructure:"max_open_conn"`
+	Host               string   `mapstructure:"host"`
+	Port               int      `mapstructure:"port"`
+	User               string   `mapstructure:"username"`
+	Password           string   `mapstructure:"password"`
+	Name               string   `mapstructure:"name"`
+	SSL                string   `mapstructure:"ssl"`
+	MaxIdleConn        int      `mapstructure:"max_idle_conn"`
+	MaxOpenConn        int      `mapstructure:"max_open_conn"`
+	MinIdleConn        int      `mapstructure:"min_idle_conn"`
+	RequiredExtensions []string `mapstructure:"required_extensions"`
+	PreflightQuery     string   `mapstructure:"preflight_query"`
+	TablePrefix        string   `mapstructure:"table_prefix"`
+	TableSuffix        string   `mapstructure:"table_suffix"`
 }
 
 // GetMaxIdleConn returns the maximum number of idle connections in the connection pool.
@@ -48,6 +64,9 @@ func (c *PostgresDatabaseConfig) GetMaxOpenConn() int {
 }
 
 // InitPostgresDatabase initializes a connection to a PostgreSQL database using the given configuration details.
+// When configured, MinIdleConn connections are opened up front, and the required extensions and the preflight query
+// are checked, so a misconfigured database fails at startup instead of at the first request. The table names are
+// prefixed and suffixed with TablePrefix and TableSuffix, see UseTableNaming.
 //
 // Parameters:
 // - conf: a pointer to a PostgresDatabaseConfig struct containing the database configuration details.
@@ -70,6 +89,12 @@ func InitPostgresDatabase(conf *PostgresDatabaseConfig, isDebug bool) (*gorm.DB,
 		return nil, err
 	}
 
+	if conf.TablePrefix != "" || conf.TableSuffix != "" {
+		if err := UseTableNaming(db, conf.TablePrefix, conf.TableSuffix); err != nil {
+			return nil, err
+		}
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
@@ -78,15 +103,259 @@ func InitPostgresDatabase(conf *PostgresDatabaseConfig, isDebug bool) (*gorm.DB,
 	sqlDB.SetMaxIdleConns(conf.GetMaxIdleConn())
 	sqlDB.SetMaxOpenConns(conf.GetMaxOpenConn())
 
+	if err := warmUpPostgres(sqlDB, conf.MinIdleConn); err != nil {
+		return nil, err
+	}
+
+	if err := preflightPostgres(db, conf); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
 
+// warmUpPostgres opens n connections of the pool, at most the maximum open connections, and releases them as idle
+// connections.
+func warmUpPostgres(sqlDB *sql.DB, n int) error {
+	if limit := sqlDB.Stats().MaxOpenConnections; limit > 0 && n > limit {
+		n = limit
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("warm up postgres connection %d of %d: %w", i+1, n, err)
+		}
+		conns = append(conns, conn)
+
+		if err := conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("warm up postgres connection %d of %d: %w", i+1, n, err)
+		}
+	}
+
+	return nil
+}
+
+// preflightPostgres verifies that the required extensions are installed and that the preflight query succeeds.
+func preflightPostgres(db *gorm.DB, conf *PostgresDatabaseConfig) error {
+	if len(conf.RequiredExtensions) > 0 {
+		var installed []string
+		if err := db.
+			Raw("SELECT extname FROM pg_extension WHERE extname IN ?", conf.RequiredExtensions).
+			Scan(&installed).
+			Error; err != nil {
+			return fmt.Errorf("check postgres extensions: %w", err)
+		}
+
+		for _, ext := range conf.RequiredExtensions {
+			if !containsString(installed, ext) {
+				return fmt.Errorf("postgres extension %q is not installed in database %q, run `CREATE EXTENSION IF NOT EXISTS %q;` as a superuser", ext, conf.Name, ext)
+			}
+		}
+	}
+
+	if conf.PreflightQuery != "" {
+		if err := db.Exec(conf.PreflightQuery).Error; err != nil {
+			return fmt.Errorf("postgres preflight query %q failed: %w", conf.PreflightQuery, err)
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InitPostgresShards initializes one connection per PostgreSQL shard using the given configuration details.
+// The connections are returned in the order of the configurations, which is the order of the shard indexes.
+//
+// Parameters:
+// - confs: the configuration details of each shard.
+// - isDebug: a boolean value to enable or disable the GORM logging mode.
+//
+// Returns:
+// - []*gorm.DB: the GORM database objects of the shards.
+// - error: an error if something goes wrong, otherwise nil.
+func InitPostgresShards(confs []*PostgresDatabaseConfig, isDebug bool) ([]*gorm.DB, error) {
+	dbs := make([]*gorm.DB, 0, len(confs))
+	for _, conf := range confs {
+		db, err := InitPostgresDatabase(conf, isDebug)
+		if err != nil {
+			return nil, err
+		}
+
+		dbs = append(dbs, db)
+	}
+
+	return dbs, nil
+}
+
 // RedisConfig is a struct that holds the configuration details required to establish a connection
 // with a Redis database.
 type RedisConfig struct {
-	Host     string `mapstructure:"host"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
+	Host            string         `mapstructure:"host"`
+	Password        string         `mapstructure:"password"`
+	DB              int            `mapstructure:"db"`
+	TLS             RedisTLSConfig `mapstructure:"tls"`
+	RedisPoolConfig `mapstructure:",squash"`
+}
+
+// RedisPoolConfig is a struct that holds the settings of the connection pool and the timeouts of a Redis client.
+type RedisPoolConfig struct {
+	PoolSize     int           `mapstructure:"pool_size"`
+	MinIdleConns int           `mapstructure:"min_idle_conns"`
+	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	MaxRetries   int           `mapstructure:"max_retries"`
+}
+
+// GetPoolSize returns the maximum number of connections of the pool, per node on a cluster.
+// If the value is not set, the default value of 10 connections per CPU is returned.
+//
+// Returns:
+// - int: the maximum number of connections.
+func (c *RedisPoolConfig) GetPoolSize() int {
+	if c.PoolSize <= 0 {
+		return 10 * runtime.GOMAXPROCS(0)
+	}
+
+	return c.PoolSize
+}
+
+// GetMinIdleConns returns the minimum number of idle connections kept open by the pool.
+// If the value is not set, the default value of 0 is returned.
+//
+// Returns:
+// - int: the minimum number of idle connections.
+func (c *RedisPoolConfig) GetMinIdleConns() int {
+	if c.MinIdleConns <= 0 {
+		return 0
+	}
+
+	return c.MinIdleConns
+}
+
+// GetDialTimeout returns the timeout of establishing a new connection.
+// If the value is not set, the default value of 5 seconds is returned.
+//
+// Returns:
+// - time.Duration: the timeout of establishing a connection.
+func (c *RedisPoolConfig) GetDialTimeout() time.Duration {
+	if c.DialTimeout <= 0 {
+		return 5 * time.Second
+	}
+
+	return c.DialTimeout
+}
+
+// GetReadTimeout returns the timeout of reading the reply of a command.
+// If the value is not set, the default value of 3 seconds is returned, a negative value disables the timeout.
+//
+// Returns:
+// - time.Duration: the timeout of reading a reply.
+func (c *RedisPoolConfig) GetReadTimeout() time.Duration {
+	if c.ReadTimeout == 0 {
+		return 3 * time.Second
+	}
+
+	return c.ReadTimeout
+}
+
+// GetWriteTimeout returns the timeout of writing a command.
+// If the value is not set, the read timeout is returned, a negative value disables the timeout.
+//
+// Returns:
+// - time.Duration: the timeout of writing a command.
+func (c *RedisPoolConfig) GetWriteTimeout() time.Duration {
+	if c.WriteTimeout == 0 {
+		return c.GetReadTimeout()
+	}
+
+	return c.WriteTimeout
+}
+
+// GetMaxRetries returns the maximum number of retries of a command which failed on a network error.
+// If the value is not set, the default value of 3 is returned, a negative value disables the retries.
+//
+// Returns:
+// - int: the maximum number of retries.
+func (c *RedisPoolConfig) GetMaxRetries() int {
+	if c.MaxRetries == 0 {
+		return 3
+	}
+
+	return c.MaxRetries
+}
+
+// RedisTLSConfig is a struct that holds the TLS settings of a connection with Redis, e.g. for the managed servers
+// which require the in-transit encryption.
+type RedisTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// Config builds the TLS config of the connection.
+//
+// Returns:
+// - *tls.Config: the TLS config, nil if TLS is not enabled.
+// - error: an error if the certificates cannot be loaded, otherwise nil.
+func (c RedisTLSConfig) Config() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read redis CA file: %w", err)
+		}
+
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in redis CA file %s", c.CAFile)
+		}
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load redis client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
 }
 
 // InitRedisConnect initializes a connection to a Redis database using the given configuration details.
@@ -98,11 +367,232 @@ type RedisConfig struct {
 // - *redis.Client: a pointer to the Redis client object.
 // - error: an error if something goes wrong, otherwise nil.
 func InitRedisConnect(conf *RedisConfig) (cache *redis.Client, err error) {
+	tlsConfig, err := conf.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+
 	cache = redis.NewClient(&redis.Options{
-		Addr:     conf.Host,
-		Password: conf.Password,
-		DB:       conf.DB,
+		Addr:         conf.Host,
+		Password:     conf.Password,
+		DB:           conf.DB,
+		TLSConfig:    tlsConfig,
+		PoolSize:     conf.GetPoolSize(),
+		MinIdleConns: conf.GetMinIdleConns(),
+		DialTimeout:  conf.GetDialTimeout(),
+		ReadTimeout:  conf.GetReadTimeout(),
+		WriteTimeout: conf.GetWriteTimeout(),
+		MaxRetries:   conf.GetMaxRetries(),
 	})
 
 	return
 }
+
+// RedisClusterConfig is a struct that holds the configuration details required to establish a connection
+// with a Redis Cluster.
+type RedisClusterConfig struct {
+	Addrs           []string       `mapstructure:"addrs"`
+	Username        string         `mapstructure:"username"`
+	Password        string         `mapstructure:"password"`
+	ReadOnly        bool           `mapstructure:"read_only"`
+	RouteByLatency  bool           `mapstructure:"route_by_latency"`
+	TLS             RedisTLSConfig `mapstructure:"tls"`
+	RedisPoolConfig `mapstructure:",squash"`
+}
+
+// InitRedisCluster initializes a connection to a Redis Cluster using the given configuration details, the client
+// plugs into NewRedisRepository as the single-node client does.
+//
+// The commands of several keys, e.g. `BPopList` or the scripts, need the keys in the same hash slot, which is given
+// by the part of the keys between braces, e.g. "{user:1}:profile" and "{user:1}:settings".
+//
+// Parameters:
+// - conf: a pointer to a RedisClusterConfig struct containing the cluster configuration details.
+//
+// Returns:
+// - *redis.ClusterClient: a pointer to the Redis Cluster client object.
+// - error: an error if something goes wrong, otherwise nil.
+func InitRedisCluster(conf *RedisClusterConfig) (cache *redis.ClusterClient, err error) {
+	if len(conf.Addrs) == 0 {
+		return nil, errors.New("missing addrs of the redis cluster")
+	}
+
+	tlsConfig, err := conf.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	cache = redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:          conf.Addrs,
+		Username:       conf.Username,
+		Password:       conf.Password,
+		ReadOnly:       conf.ReadOnly,
+		RouteByLatency: conf.RouteByLatency,
+		TLSConfig:      tlsConfig,
+		PoolSize:       conf.GetPoolSize(),
+		MinIdleConns:   conf.GetMinIdleConns(),
+		DialTimeout:    conf.GetDialTimeout(),
+		ReadTimeout:    conf.GetReadTimeout(),
+		WriteTimeout:   conf.GetWriteTimeout(),
+		MaxRetries:     conf.GetMaxRetries(),
+	})
+
+	return
+}
+
+// RedisSentinelConfig is a struct that holds the configuration details required to establish a connection
+// with a Redis master managed by Sentinel.
+type RedisSentinelConfig struct {
+	MasterName       string         `mapstructure:"master_name"`
+	SentinelAddrs    []string       `mapstructure:"sentinel_addrs"`
+	SentinelPassword string         `mapstructure:"sentinel_password"`
+	Password         string         `mapstructure:"password"`
+	DB               int            `mapstructure:"db"`
+	TLS              RedisTLSConfig `mapstructure:"tls"`
+	RedisPoolConfig  `mapstructure:",squash"`
+}
+
+// InitRedisSentinel initializes a connection to the Redis master named by the configuration, whose address is asked to
+// the sentinels. The client follows the master on failover, and plugs into NewRedisRepository as the single-node
+// client does.
+//
+// Parameters:
+// - conf: a pointer to a RedisSentinelConfig struct containing the sentinel configuration details.
+//
+// Returns:
+// - *redis.Client: a pointer to the Redis client object.
+// - error: an error if something goes wrong, otherwise nil.
+func InitRedisSentinel(conf *RedisSentinelConfig) (cache *redis.Client, err error) {
+	if conf.MasterName == "" {
+		return nil, errors.New("missing master name of the redis sentinel")
+	}
+	if len(conf.SentinelAddrs) == 0 {
+		return nil, errors.New("missing addrs of the redis sentinels")
+	}
+
+	tlsConfig, err := conf.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	cache = redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:       conf.MasterName,
+		SentinelAddrs:    conf.SentinelAddrs,
+		SentinelPassword: conf.SentinelPassword,
+		Password:         conf.Password,
+		DB:               conf.DB,
+		TLSConfig:        tlsConfig,
+		PoolSize:         conf.GetPoolSize(),
+		MinIdleConns:     conf.GetMinIdleConns(),
+		DialTimeout:      conf.GetDialTimeout(),
+		ReadTimeout:      conf.GetReadTimeout(),
+		WriteTimeout:     conf.GetWriteTimeout(),
+		MaxRetries:       conf.GetMaxRetries(),
+	})
+
+	return
+}
+
+// OpenSearchConfig is a struct that holds the configuration details required to establish a connection
+// with an OpenSearch cluster.
+type OpenSearchConfig struct {
+	Addresses          []string             `mapstructure:"addresses"`
+	Username           string               `mapstructure:"username"`
+	Password           string               `mapstructure:"password"`
+	InsecureSkipVerify bool                 `mapstructure:"insecure_skip_verify"`
+	MaxRetries         int                  `mapstructure:"max_retries"`
+	RetryBackoff       time.Duration        `mapstructure:"retry_backoff"`
+	MaxRetryBackoff    time.Duration        `mapstructure:"max_retry_backoff"`
+	CircuitBreaker     CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+}
+
+// GetMaxRetries returns the maximum number of retries of a request.
+// If the value is not set, the default value of 3 is returned, a negative value disables the retries.
+//
+// Returns:
+// - int: the maximum number of retries.
+func (c *OpenSearchConfig) GetMaxRetries() int {
+	if c.MaxRetries == 0 {
+		return 3
+	}
+
+	return c.MaxRetries
+}
+
+// GetRetryBackoff returns the waiting time before the first retry of a request.
+// If the value is not set, the default value of 100 milliseconds is returned.
+//
+// Returns:
+// - time.Duration: the waiting time before the first retry.
+func (c *OpenSearchConfig) GetRetryBackoff() time.Duration {
+	if c.RetryBackoff == 0 {
+		return 100 * time.Millisecond
+	}
+
+	return c.RetryBackoff
+}
+
+// GetMaxRetryBackoff returns the maximum waiting time between the retries of a request.
+// If the value is not set, the default value of 5 seconds is returned.
+//
+// Returns:
+// - time.Duration: the maximum waiting time between the retries.
+func (c *OpenSearchConfig) GetMaxRetryBackoff() time.Duration {
+	if c.MaxRetryBackoff == 0 {
+		return 5 * time.Second
+	}
+
+	return c.MaxRetryBackoff
+}
+
+// InitOpenSearchClient initializes a client of an OpenSearch cluster using the given configuration details.
+// The requests failing with a network error, a timeout, 429, or 5xx are retried on the next node with an exponential
+// backoff, the nodes which cannot be reached are left out until they recover. When CircuitBreaker is set, the
+// requests fail fast with ErrCircuitOpen after the consecutive failures, so the rolling restarts of the cluster do not
+// hold up every request.
+//
+// Parameters:
+// - conf: a pointer to an OpenSearchConfig struct containing the cluster configuration details.
+//
+// Returns:
+// - *opensearch.Client: a pointer to the OpenSearch client object.
+// - error: an error if something goes wrong, otherwise nil.
+func InitOpenSearchClient(conf *OpenSearchConfig) (*opensearch.Client, error) {
+	backoff, maxBackoff := conf.GetRetryBackoff(), conf.GetMaxRetryBackoff()
+
+	client, err := opensearch.NewClient(opensearch.Config{
+		Addresses: conf.Addresses,
+		Username:  conf.Username,
+		Password:  conf.Password,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify},
+		},
+		RetryOnStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		DisableRetry:         conf.GetMaxRetries() < 0,
+		EnableRetryOnTimeout: true,
+		MaxRetries:           conf.GetMaxRetries(),
+		RetryBackoff: func(attempt int) time.Duration {
+			d := backoff
+			for i := 1; i < attempt && d < maxBackoff; i++ {
+				d = nextBackoff(d, maxBackoff)
+			}
+			// the jitter spreads the retries of the clients hitting the same restarting node
+			return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if breaker := newCircuitBreaker(conf.CircuitBreaker); breaker != nil {
+		client.Transport = &breakerTransport{transport: client.Transport, breaker: breaker}
+	}
+
+	return client, nil
+}
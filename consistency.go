@@ -0,0 +1,264 @@
+package repositorysdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ConsistencyProblem is the kind of divergence between an entity and its copy in another store.
+type ConsistencyProblem string
+
+const (
+	// ConsistencyMissing is a copy which does not exist, it is only reported for the search index as the caches are
+	// filled lazily.
+	ConsistencyMissing ConsistencyProblem = "missing"
+	// ConsistencyStale is a copy whose content differs from the entity.
+	ConsistencyStale ConsistencyProblem = "stale"
+)
+
+const (
+	ConsistencyStoreCache  = "redis"
+	ConsistencyStoreSearch = "opensearch"
+)
+
+// ConsistencyIssue is a struct that holds a divergence between an entity and its copy in another store.
+type ConsistencyIssue struct {
+	Store    string
+	ID       string
+	Key      string
+	Problem  ConsistencyProblem
+	Repaired bool
+}
+
+// ConsistencyReport is a struct that holds the result of a consistency check.
+type ConsistencyReport struct {
+	Checked int
+	Issues  []ConsistencyIssue
+}
+
+// ConsistencyCheckConfig is a struct that holds the stores compared by CheckConsistency.
+type ConsistencyCheckConfig[T Entity] struct {
+	// Cache holds the cached copies of the entities, nil skips the cache.
+	Cache RedisRepository
+	// CacheKey returns the cache key of an entity, it is required with Cache.
+	CacheKey func(entity T) string
+	// Search holds the documents of the entities, it must implement SearchDocumentReader. Nil skips the search index.
+	Search SearchIndexer
+	// Repair removes the stale caches, so they are loaded again, and indexes the missing and stale documents.
+	Repair bool
+	// BatchSize is the number of rows read per query, 0 means MaximumQueryEntities.
+	BatchSize int
+}
+
+// CheckConsistency compares the entities of db against their cached copies and their search documents, by id and by
+// a hash of their content, and reports the divergences. The contents are compared in json, with the timestamps
+// normalized to UTC, so the caches must be encoded by a codec which decodes into the entity.
+//
+// Parameters:
+// - ctx: the context of the check.
+// - db: the GORM database connection, it may be scoped to check a subset of the entities.
+// - conf: the stores to be compared.
+//
+// Returns:
+// - *ConsistencyReport: the number of entities checked and the divergences found.
+// - error: an error if something goes wrong, otherwise nil.
+func CheckConsistency[T Entity](ctx context.Context, db *gorm.DB, conf ConsistencyCheckConfig[T]) (report *ConsistencyReport, err error) {
+	defer wrapError(&err, "CheckConsistency", entityTypeName[T](), "", time.Now())
+
+	if conf.Cache != nil && conf.CacheKey == nil {
+		return nil, errors.New("missing CacheKey for the cache")
+	}
+
+	var reader SearchDocumentReader
+	if conf.Search != nil {
+		var ok bool
+		if reader, ok = conf.Search.(SearchDocumentReader); !ok {
+			return nil, fmt.Errorf("search indexer %T does not implement SearchDocumentReader", conf.Search)
+		}
+	}
+
+	batchSize := conf.BatchSize
+	if batchSize <= 0 {
+		batchSize = MaximumQueryEntities
+	}
+
+	report = &ConsistencyReport{}
+
+	var entities []T
+	if err := db.WithContext(ctx).FindInBatches(&entities, batchSize, func(tx *gorm.DB, batch int) error {
+		report.Checked += len(entities)
+
+		if conf.Cache != nil {
+			issues, err := checkCacheConsistency(conf, entities)
+			if err != nil {
+				return err
+			}
+			report.Issues = append(report.Issues, issues...)
+		}
+
+		if reader != nil {
+			issues, err := checkSearchConsistency(ctx, conf, reader, entities)
+			if err != nil {
+				return err
+			}
+			report.Issues = append(report.Issues, issues...)
+		}
+
+		return nil
+	}).Error; err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// checkCacheConsistency compares the entities against their cached copies.
+func checkCacheConsistency[T Entity](conf ConsistencyCheckConfig[T], entities []T) ([]ConsistencyIssue, error) {
+	keys := make([]string, len(entities))
+	for i, entity := range entities {
+		keys[i] = conf.CacheKey(entity)
+	}
+
+	cached := make(map[string]json.RawMessage, len(keys))
+	if err := conf.Cache.GetMultiCache(keys, cached); err != nil {
+		return nil, err
+	}
+
+	codec := codecOf(conf.Cache)
+
+	var issues []ConsistencyIssue
+	for i, entity := range entities {
+		raw, ok := cached[keys[i]]
+		if !ok {
+			continue
+		}
+
+		var copied T
+		if err := codec.Unmarshal(raw, &copied); err == nil && sameContent(entity, copied) {
+			continue
+		}
+
+		issue := ConsistencyIssue{Store: ConsistencyStoreCache, ID: entityID(entity), Key: keys[i], Problem: ConsistencyStale}
+		if conf.Repair {
+			if err := conf.Cache.RemoveCache(keys[i]); err != nil {
+				return nil, err
+			}
+			issue.Repaired = true
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+// checkSearchConsistency compares the entities against their search documents.
+func checkSearchConsistency[T Entity](ctx context.Context, conf ConsistencyCheckConfig[T], reader SearchDocumentReader, entities []T) ([]ConsistencyIssue, error) {
+	byIndex := map[string][]T{}
+	for _, entity := range entities {
+		if _, ok := searchDocument(entity); ok {
+			byIndex[entity.TableName()] = append(byIndex[entity.TableName()], entity)
+		}
+	}
+
+	var issues []ConsistencyIssue
+	for index, indexed := range byIndex {
+		ids := make([]string, len(indexed))
+		for i, entity := range indexed {
+			ids[i] = entityID(entity)
+		}
+
+		documents, err := reader.GetDocuments(ctx, index, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, entity := range indexed {
+			expected, _ := searchDocument(entity)
+
+			issue := ConsistencyIssue{Store: ConsistencyStoreSearch, ID: ids[i], Key: index}
+			if document, ok := documents[ids[i]]; !ok {
+				issue.Problem = ConsistencyMissing
+			} else if !sameContent(expected, document) {
+				issue.Problem = ConsistencyStale
+			} else {
+				continue
+			}
+
+			if conf.Repair {
+				if err := conf.Search.Index(ctx, index, ids[i], expected); err != nil {
+					return nil, err
+				}
+				issue.Repaired = true
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// sameContent reports whether a and b have the same content hash.
+func sameContent(a interface{}, b interface{}) bool {
+	ha, err := contentHash(a)
+	if err != nil {
+		return false
+	}
+
+	hb, err := contentHash(b)
+	if err != nil {
+		return false
+	}
+
+	return ha == hb
+}
+
+// contentHash hashes the canonical json of v, the keys of the objects are sorted and the timestamps are normalized to
+// UTC, so the copies decoded from another store hash as the original.
+func contentHash(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var generic interface{}
+	if err := d.Decode(&generic); err != nil {
+		return "", err
+	}
+
+	b, err = json.Marshal(normalizeContent(generic))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func normalizeContent(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalizeContent(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalizeContent(e)
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	return v
+}
@@ -2,3 +2,53 @@ package repositorysdk
 
 const MaximumQueryEntities = 1000
 const MinimumQueryEntities = 5
+
+// ScanBatchSize is the number of keys hinted to each `SCAN` call when iterating over the keyspace.
+const ScanBatchSize = 1000
+
+// NamespaceStatsSampleSize is the maximum number of keys measured with `MEMORY USAGE` when estimating the memory
+// usage of a namespace.
+const NamespaceStatsSampleSize = 100
+
+// CacheTagPrefix is the key prefix of the sets which hold the cache keys attached to a tag.
+const CacheTagPrefix = "repositorysdk:tag:"
+
+// QuotaKeyPrefix is the key prefix of the usage counters of the tenants.
+const QuotaKeyPrefix = "repositorysdk:quota:"
+
+// CounterKeyPrefix is the key prefix of the buckets of the time series counters.
+const CounterKeyPrefix = "repositorysdk:counter:"
+
+// OutboxPartitions is the number of partitions of the outbox, a partition is delivered by a single relay at a time.
+const OutboxPartitions = 16
+
+// OutboxMaxAttempts is the default number of failed deliveries after which a message of the outbox is dead-lettered.
+const OutboxMaxAttempts = 10
+
+// RateLimitKeyPrefix is the key prefix of the sliding window logs of the rate limiter.
+const RateLimitKeyPrefix = "repositorysdk:ratelimit:"
+
+// TenantKeyPrefix is the key prefix of the keys scoped by NewTenantRedisRepository.
+const TenantKeyPrefix = "repositorysdk:tenant:"
+
+// PriorityQueueKeyPrefix is the key prefix of the sorted sets of the priority queues.
+const PriorityQueueKeyPrefix = "repositorysdk:pqueue:"
+
+// TieredCacheChannel is the default channel of the invalidations broadcast by the tiered caches.
+const TieredCacheChannel = "repositorysdk:tiered:invalidate"
+
+// DelayedSchedulerKeyPrefix is the key prefix of the sorted sets of the delayed schedulers.
+const DelayedSchedulerKeyPrefix = "repositorysdk:scheduler:"
+
+// ReliableQueueKeyPrefix is the key prefix of the lists of the reliable queues.
+const ReliableQueueKeyPrefix = "repositorysdk:rqueue:"
+
+// IdempotencyKeyPrefix is the key prefix of the records of the idempotency stores.
+const IdempotencyKeyPrefix = "repositorysdk:idempotency:"
+
+// ClientTrackingChannel is the channel of the invalidations sent by redis to the connections which redirect the
+// client-side caching.
+const ClientTrackingChannel = "__redis__:invalidate"
+
+// LeaderboardKeyPrefix is the key prefix of the sorted sets of the leaderboards.
+const LeaderboardKeyPrefix = "repositorysdk:leaderboard:"
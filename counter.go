@@ -0,0 +1,171 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// BucketSize is the time span counted by a bucket of a TimeSeriesCounter.
+type BucketSize string
+
+const (
+	BucketMinute BucketSize = "minute"
+	BucketHour   BucketSize = "hour"
+	BucketDay    BucketSize = "day"
+)
+
+// Duration returns the time span of the bucket size.
+func (b BucketSize) Duration() time.Duration {
+	switch b {
+	case BucketMinute:
+		return time.Minute
+	case BucketHour:
+		return time.Hour
+	case BucketDay:
+		return 24 * time.Hour
+	}
+
+	return 0
+}
+
+// DefaultCounterRetention is the retention of the buckets used when NewTimeSeriesCounter is given no retention.
+var DefaultCounterRetention = map[BucketSize]time.Duration{
+	BucketMinute: 24 * time.Hour,
+	BucketHour:   30 * 24 * time.Hour,
+	BucketDay:    365 * 24 * time.Hour,
+}
+
+// TimeBucket is a struct that holds the count of a bucket of a time series, starting at Start.
+type TimeBucket struct {
+	Start time.Time
+	Count int64
+}
+
+type TimeSeriesCounter interface {
+	Increment(ctx context.Context, name string, by int64) error
+	IncrementAt(ctx context.Context, name string, at time.Time, by int64) error
+	Series(ctx context.Context, name string, size BucketSize, from time.Time, to time.Time) ([]TimeBucket, error)
+}
+
+type timeSeriesCounter struct {
+	client    redis.UniversalClient
+	retention map[BucketSize]time.Duration
+}
+
+// NewTimeSeriesCounter function that create a new instance of TimeSeriesCounter which counts the events in time
+// buckets stored in redis. Every increment is counted in a bucket of each size of retention, and a bucket expires once
+// its retention has elapsed. A nil retention means DefaultCounterRetention.
+func NewTimeSeriesCounter(client redis.UniversalClient, retention map[BucketSize]time.Duration) TimeSeriesCounter {
+	if retention == nil {
+		retention = DefaultCounterRetention
+	}
+
+	return &timeSeriesCounter{
+		client:    client,
+		retention: retention,
+	}
+}
+
+// Increment increments the current buckets of the counter.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - name: the name of the counter.
+// - by: the increment.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (c *timeSeriesCounter) Increment(ctx context.Context, name string, by int64) error {
+	return c.IncrementAt(ctx, name, time.Now(), by)
+}
+
+// IncrementAt increments the buckets of the counter which contain the given time by using the command `INCRBY` in a
+// single pipeline, the expiration time of each bucket is set to the end of its retention.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - name: the name of the counter.
+// - at: the time of the event.
+// - by: the increment.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (c *timeSeriesCounter) IncrementAt(ctx context.Context, name string, at time.Time, by int64) (err error) {
+	defer wrapError(&err, "IncrementAt", "", name, time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err = c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for size, retention := range c.retention {
+			start := at.UTC().Truncate(size.Duration())
+			key := counterKey(name, size, start)
+
+			pipe.IncrBy(ctx, key, by)
+			pipe.ExpireAt(ctx, key, start.Add(size.Duration()+retention))
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Series retrieves the buckets of the counter between from and to by using the command `MGET`, the buckets without
+// events are returned with a zero count.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - name: the name of the counter.
+// - size: the bucket size, it must be one of the sizes of the retention.
+// - from: the start of the series, truncated to the bucket size.
+// - to: the end of the series, inclusive.
+//
+// Returns:
+// - []TimeBucket: the buckets in chronological order.
+// - error: an error if something goes wrong, otherwise nil.
+func (c *timeSeriesCounter) Series(ctx context.Context, name string, size BucketSize, from time.Time, to time.Time) (buckets []TimeBucket, err error) {
+	defer wrapError(&err, "Series", "", name, time.Now())
+
+	step := size.Duration()
+	if _, ok := c.retention[size]; !ok || step == 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	for start := from.UTC().Truncate(step); !start.After(to); start = start.Add(step) {
+		buckets = append(buckets, TimeBucket{Start: start})
+		keys = append(keys, counterKey(name, size, start))
+	}
+
+	if len(keys) == 0 {
+		return buckets, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	values, err := multiGet(ctx, c.client, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		if buckets[i].Count, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return buckets, nil
+}
+
+// counterKey returns the key of the bucket of the counter starting at start.
+func counterKey(name string, size BucketSize, start time.Time) string {
+	return CounterKeyPrefix + name + ":" + string(size) + ":" + strconv.FormatInt(start.Unix(), 10)
+}
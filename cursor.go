@@ -0,0 +1,246 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// DefaultCursorSortKey is the sort key of the cursor pagination when CursorPaginationMetadata.SortKey is empty.
+const DefaultCursorSortKey = "created_at"
+
+// CursorPaginationMetadata is a struct that holds the metadata of a cursor pagination, also known as keyset
+// pagination, including the cursor of the requested page and the cursors of the pages next to it. Unlike
+// PaginationMetadata, the cost of a page does not grow with its position and no total is counted.
+type CursorPaginationMetadata struct {
+	// Cursor is the token of the requested page, the NextCursor or the PrevCursor of another page. It is empty for the
+	// first page.
+	Cursor string
+	// ItemsPerPage is the number of items per page, kept between MinimumQueryEntities and MaximumQueryEntities.
+	ItemsPerPage int
+	// SortKey is the field or the column the entities are sorted by, created_at by default. The primary key breaks the
+	// ties, so the pages neither skip nor repeat an entity.
+	SortKey string
+	// Descending sorts the entities from the greatest sort key, e.g. the newest first.
+	Descending bool
+	// ItemCount is the number of items of the page.
+	ItemCount int
+	// NextCursor is the token of the next page, empty on the last page.
+	NextCursor string
+	// PrevCursor is the token of the previous page, empty on the first page.
+	PrevCursor string
+}
+
+// GetItemPerPage is a method that returns the number of items per page, ensuring that the value is within a certain range.
+func (p *CursorPaginationMetadata) GetItemPerPage() int {
+	if p.ItemsPerPage < MinimumQueryEntities {
+		p.ItemsPerPage = MinimumQueryEntities
+	}
+	if p.ItemsPerPage > MaximumQueryEntities {
+		p.ItemsPerPage = MaximumQueryEntities
+	}
+
+	return p.ItemsPerPage
+}
+
+// GetSortKey is a method that returns the sort key, DefaultCursorSortKey if it is empty.
+func (p *CursorPaginationMetadata) GetSortKey() string {
+	if p.SortKey == "" {
+		return DefaultCursorSortKey
+	}
+
+	return p.SortKey
+}
+
+// cursorToken is the decoded cursor, it holds the sort key and the primary key of the entity the page starts after.
+type cursorToken struct {
+	SortKey    string          `json:"k"`
+	Descending bool            `json:"d,omitempty"`
+	Value      json.RawMessage `json:"v"`
+	ID         json.RawMessage `json:"id"`
+	// Prev makes the page end before the entity instead of starting after it.
+	Prev bool `json:"p,omitempty"`
+}
+
+func (t cursorToken) encode() (string, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// keyset is a page of a cursor pagination, resolved against the schema of the entities.
+type keyset struct {
+	meta   *CursorPaginationMetadata
+	sort   *schema.Field
+	id     *schema.Field
+	cursor *cursorToken
+	values []interface{}
+}
+
+// newKeyset resolves the sort key of meta and decodes its cursor, it fails with ErrInvalidCursor if the cursor is
+// malformed or was issued for another sort.
+func newKeyset(db *gorm.DB, meta *CursorPaginationMetadata, entities interface{}) (*keyset, error) {
+	// a statement of its own, the statement of db is shared by the queries of the repository
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entities); err != nil {
+		return nil, err
+	}
+
+	k := &keyset{
+		meta: meta,
+		sort: stmt.Schema.LookUpField(meta.GetSortKey()),
+		id:   stmt.Schema.PrioritizedPrimaryField,
+	}
+	if k.sort == nil || k.sort.DBName == "" {
+		return nil, fmt.Errorf("unknown sort key %s of %s", meta.GetSortKey(), stmt.Schema.Name)
+	}
+	if k.id == nil {
+		return nil, fmt.Errorf("missing primary key of %s", stmt.Schema.Name)
+	}
+
+	if meta.Cursor == "" {
+		return k, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(meta.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	k.cursor = &cursorToken{}
+	if err := json.Unmarshal(b, k.cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if k.cursor.SortKey != k.sort.DBName || k.cursor.Descending != meta.Descending {
+		return nil, ErrInvalidCursor
+	}
+
+	// the values are decoded into the types of the fields, so they are bound as the stored values are
+	value := reflect.New(k.sort.FieldType)
+	id := reflect.New(k.id.FieldType)
+	if json.Unmarshal(k.cursor.Value, value.Interface()) != nil || json.Unmarshal(k.cursor.ID, id.Interface()) != nil {
+		return nil, ErrInvalidCursor
+	}
+	k.values = []interface{}{value.Elem().Interface(), id.Elem().Interface()}
+
+	return k, nil
+}
+
+// backward reports whether the page is read backward, from the cursor to the previous entities.
+func (k *keyset) backward() bool {
+	return k.cursor != nil && k.cursor.Prev
+}
+
+// scope adds the keyset predicate, e.g. `(created_at, id) > (?, ?)`, the ordering, and the limit of the page. One more
+// entity than the page holds is read to know whether another page follows.
+func (k *keyset) scope(db *gorm.DB) *gorm.DB {
+	desc := k.meta.Descending != k.backward()
+
+	if k.cursor != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		db = db.Where(clause.Expr{
+			SQL: "(?, ?) " + op + " (?, ?)",
+			Vars: []interface{}{
+				clause.Column{Table: clause.CurrentTable, Name: k.sort.DBName},
+				clause.Column{Table: clause.CurrentTable, Name: k.id.DBName},
+				k.values[0],
+				k.values[1],
+			},
+		})
+	}
+
+	return db.
+		Clauses(clause.OrderBy{Columns: []clause.OrderByColumn{
+			{Column: clause.Column{Table: clause.CurrentTable, Name: k.sort.DBName}, Desc: desc},
+			{Column: clause.Column{Table: clause.CurrentTable, Name: k.id.DBName}, Desc: desc},
+		}}).
+		Limit(k.meta.GetItemPerPage() + 1)
+}
+
+// finish trims the extra entity of the page, puts a backward page back in order, and sets the cursors of the pages
+// next to it.
+func (k *keyset) finish(ctx context.Context, entities interface{}) error {
+	rv := reflect.ValueOf(entities).Elem()
+
+	more := rv.Len() > k.meta.GetItemPerPage()
+	if more {
+		rv.Set(rv.Slice(0, k.meta.GetItemPerPage()))
+	}
+	if k.backward() {
+		swap := reflect.Swapper(rv.Interface())
+		for i, j := 0, rv.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	k.meta.ItemCount = rv.Len()
+	k.meta.NextCursor, k.meta.PrevCursor = "", ""
+
+	if rv.Len() == 0 {
+		// an empty page past a cursor leads back to the entities on the other side of it
+		if k.cursor == nil {
+			return nil
+		}
+		flipped := *k.cursor
+		flipped.Prev = !flipped.Prev
+		token, err := flipped.encode()
+		if err != nil {
+			return err
+		}
+		if flipped.Prev {
+			k.meta.PrevCursor = token
+		} else {
+			k.meta.NextCursor = token
+		}
+		return nil
+	}
+
+	var err error
+	if more || k.backward() {
+		if k.meta.NextCursor, err = k.token(ctx, rv.Index(rv.Len()-1), false); err != nil {
+			return err
+		}
+	}
+	if (more && k.backward()) || (k.cursor != nil && !k.backward()) {
+		if k.meta.PrevCursor, err = k.token(ctx, rv.Index(0), true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// token returns the cursor of the page starting after the entity, or ending before it when prev is set.
+func (k *keyset) token(ctx context.Context, entity reflect.Value, prev bool) (string, error) {
+	entity = reflect.Indirect(entity)
+	value, _ := k.sort.ValueOf(ctx, entity)
+	id, _ := k.id.ValueOf(ctx, entity)
+
+	v, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	i, err := json.Marshal(id)
+	if err != nil {
+		return "", err
+	}
+
+	return cursorToken{
+		SortKey:    k.sort.DBName,
+		Descending: k.meta.Descending,
+		Value:      v,
+		ID:         i,
+		Prev:       prev,
+	}.encode()
+}
@@ -0,0 +1,204 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"gorm.io/gorm"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// IndexUsage is a struct that holds the usage of an index since the statistics were last reset.
+type IndexUsage struct {
+	Schema    string `json:"schema"`
+	Table     string `json:"table"`
+	Index     string `json:"index"`
+	Scans     int64  `json:"scans"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// SlowQuery is a struct that holds the statistics of a normalized statement of pg_stat_statements.
+type SlowQuery struct {
+	Query     string        `json:"query"`
+	Calls     int64         `json:"calls"`
+	Rows      int64         `json:"rows"`
+	TotalTime time.Duration `json:"total_time"`
+	MeanTime  time.Duration `json:"mean_time"`
+}
+
+// DiagnosticsReport is a struct that holds a sample of the statistics of the database.
+type DiagnosticsReport struct {
+	SampledAt     time.Time    `json:"sampled_at"`
+	UnusedIndexes []IndexUsage `json:"unused_indexes"`
+	SlowQueries   []SlowQuery  `json:"slow_queries"`
+}
+
+// DiagnosticsConfig is a struct that holds the settings of the diagnostics reporter.
+type DiagnosticsConfig struct {
+	// TopQueries is the number of the slowest statements by mean time in the report, 0 means 10.
+	TopQueries int
+	// MinIndexSize is the size in bytes below which an unused index is not reported.
+	MinIndexSize int64
+	// OnReport receives every report, e.g. to export it as metrics, it may be nil.
+	OnReport func(ctx context.Context, report *DiagnosticsReport)
+}
+
+type DiagnosticsReporter interface {
+	Sample(ctx context.Context) (*DiagnosticsReport, error)
+	Latest() *DiagnosticsReport
+	Run(ctx context.Context, interval time.Duration) error
+	ServeHTTP(w http.ResponseWriter, req *http.Request)
+}
+
+type diagnosticsReporter struct {
+	db     *gorm.DB
+	conf   DiagnosticsConfig
+	latest atomic.Pointer[DiagnosticsReport]
+}
+
+// NewDiagnosticsReporter function that create a new instance of DiagnosticsReporter which samples the unused indexes
+// from pg_stat_user_indexes and the slowest statements from pg_stat_statements through the connection of db. The
+// reporter is an http.Handler serving the latest report in json.
+func NewDiagnosticsReporter(db *gorm.DB, conf DiagnosticsConfig) DiagnosticsReporter {
+	if conf.TopQueries <= 0 {
+		conf.TopQueries = 10
+	}
+
+	return &diagnosticsReporter{db: db, conf: conf}
+}
+
+// Sample samples the statistics of the database and keeps the report as the latest one. The primary keys and the
+// unique indexes are never reported as unused, as they enforce a constraint. The slow queries are empty when the
+// extension pg_stat_statements is not installed.
+//
+// Parameters:
+// - ctx: the context of the request.
+//
+// Returns:
+// - *DiagnosticsReport: the report.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *diagnosticsReporter) Sample(ctx context.Context) (report *DiagnosticsReport, err error) {
+	defer wrapError(&err, "Sample", "", "", time.Now())
+
+	db := r.db.WithContext(ctx)
+	report = &DiagnosticsReport{SampledAt: time.Now()}
+
+	if err := db.Raw(`SELECT s.schemaname AS "schema", s.relname AS "table", s.indexrelname AS "index",
+			s.idx_scan AS scans, pg_relation_size(s.indexrelid) AS size_bytes
+		FROM pg_stat_user_indexes s
+		JOIN pg_index i ON i.indexrelid = s.indexrelid
+		WHERE s.idx_scan = 0 AND NOT i.indisunique AND NOT i.indisprimary AND pg_relation_size(s.indexrelid) >= ?
+		ORDER BY size_bytes DESC`, r.conf.MinIndexSize).Scan(&report.UnusedIndexes).Error; err != nil {
+		return nil, err
+	}
+
+	if report.SlowQueries, err = r.slowQueries(db); err != nil {
+		return nil, err
+	}
+
+	r.latest.Store(report)
+	if r.conf.OnReport != nil {
+		r.conf.OnReport(ctx, report)
+	}
+
+	return report, nil
+}
+
+// slowQueries returns the slowest statements of the database by mean time, nil if pg_stat_statements is not installed.
+func (r *diagnosticsReporter) slowQueries(db *gorm.DB) ([]SlowQuery, error) {
+	var installed bool
+	if err := db.Raw("SELECT to_regclass('pg_stat_statements') IS NOT NULL").Scan(&installed).Error; err != nil {
+		return nil, err
+	}
+	if !installed {
+		return nil, nil
+	}
+
+	var version int
+	if err := db.Raw("SELECT current_setting('server_version_num')::int").Scan(&version).Error; err != nil {
+		return nil, err
+	}
+
+	// the timing columns were renamed in PostgreSQL 13
+	total, mean := "total_exec_time", "mean_exec_time"
+	if version < 130000 {
+		total, mean = "total_time", "mean_time"
+	}
+
+	var rows []struct {
+		Query   string
+		Calls   int64
+		Rows    int64
+		TotalMs float64
+		MeanMs  float64
+	}
+	if err := db.Raw(`SELECT query, calls, rows, `+total+` AS total_ms, `+mean+` AS mean_ms
+		FROM pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY mean_ms DESC
+		LIMIT ?`, r.conf.TopQueries).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	queries := make([]SlowQuery, 0, len(rows))
+	for _, row := range rows {
+		queries = append(queries, SlowQuery{
+			Query:     row.Query,
+			Calls:     row.Calls,
+			Rows:      row.Rows,
+			TotalTime: time.Duration(row.TotalMs * float64(time.Millisecond)),
+			MeanTime:  time.Duration(row.MeanMs * float64(time.Millisecond)),
+		})
+	}
+
+	return queries, nil
+}
+
+// Latest returns the latest report, nil if the statistics have not been sampled yet.
+func (r *diagnosticsReporter) Latest() *DiagnosticsReport {
+	return r.latest.Load()
+}
+
+// Run samples the statistics once, then every interval until ctx is done. The failed samples after the first one are
+// ignored and the latest report is kept.
+//
+// Parameters:
+// - ctx: the context which stops the reporter.
+// - interval: the interval between the samples.
+//
+// Returns:
+// - error: the error of the first sample, otherwise the error of ctx when it is done.
+func (r *diagnosticsReporter) Run(ctx context.Context, interval time.Duration) error {
+	if _, err := r.Sample(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		_, _ = r.Sample(ctx)
+	}
+}
+
+// ServeHTTP serves the latest report in json, or samples the statistics when there is no report yet.
+func (r *diagnosticsReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := r.Latest()
+	if report == nil {
+		var err error
+		if report, err = r.Sample(req.Context()); err != nil {
+			http.Error(w, err.Error(), HTTPStatus(err))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
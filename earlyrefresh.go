@@ -0,0 +1,148 @@
+package repositorysdk
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// earlyRefreshKeySuffix is appended to the key of a cache to hold the duration of its loader in milliseconds.
+const earlyRefreshKeySuffix = ":xfetch"
+
+// earlyRefreshMissKeySuffix is appended to the key of a cache to hold the time of the miss of GetCache in
+// milliseconds, until the cache is saved again by SaveCache.
+const earlyRefreshMissKeySuffix = ":xfetch:miss"
+
+// WithEarlyRefresh enables the probabilistic early expiration of GetOrSetCache and GetCache, known as XFetch, so a
+// hot cache is loaded again by a single caller shortly before it expires instead of by all the callers once it has
+// expired. The duration of the loader is saved next to the cache, and every read loads the cache again with a
+// probability which grows as the expiration time comes near and as the loader is slow. The caches without ttl are not
+// refreshed early.
+//
+// GetCache has no loader, so it reports an early miss instead, and the duration of the loader is the time between the
+// miss and the SaveCache which follows it.
+//
+// Parameters:
+// - beta: the eagerness of the refresh, 1 is the recommended value, above 1 favours the early refreshes.
+func WithEarlyRefresh(beta float64) RedisOption {
+	return func(o *redisOptions) {
+		o.earlyRefresh = beta
+	}
+}
+
+func earlyRefreshKey(key string) string {
+	return key + earlyRefreshKeySuffix
+}
+
+func earlyRefreshMissKey(key string) string {
+	return key + earlyRefreshMissKeySuffix
+}
+
+// getCacheEarly is GetCache with WithEarlyRefresh, it reads the cache with its remaining ttl and the duration of its
+// loader in a single round trip. A miss, early or not, is returned as redis.Nil and its time is saved for SaveCache.
+func (r *redisRepository) getCacheEarly(key string, dest interface{}) error {
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	var value *redis.StringCmd
+	var remaining *redis.DurationCmd
+	var delta *redis.StringCmd
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		value = pipe.Get(ctx, key)
+		remaining = pipe.PTTL(ctx, key)
+		delta = pipe.Get(ctx, earlyRefreshKey(key))
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	b, err := value.Bytes()
+	if err == nil {
+		ms, _ := strconv.ParseInt(delta.Val(), 10, 64)
+		if !refreshEarly(time.Duration(ms)*time.Millisecond, remaining.Val(), r.earlyRefresh) {
+			return r.codec.Unmarshal(b, dest)
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	// the first miss is kept, the loader of the caller which missed first is the one measured
+	if err := r.client.SetNX(ctx, earlyRefreshMissKey(key), time.Now().UnixMilli(), time.Minute).Err(); err != nil {
+		return err
+	}
+
+	return redis.Nil
+}
+
+// saveCacheEarly is SaveCache with WithEarlyRefresh, the time since the miss of GetCache, if any, is saved next to
+// the cache as the duration of its loader.
+func (r *redisRepository) saveCacheEarly(ctx context.Context, key string, v []byte, ttl int) error {
+	var missed *redis.StringCmd
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, v, cacheExpiration(ttl))
+		missed = pipe.Get(ctx, earlyRefreshMissKey(key))
+		pipe.Del(ctx, earlyRefreshMissKey(key))
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	since, err := missed.Int64()
+	if err != nil {
+		return nil
+	}
+	delta := time.Since(time.UnixMilli(since))
+
+	return r.client.Set(ctx, earlyRefreshKey(key), delta.Milliseconds(), time.Duration(ttl)*time.Second).Err()
+}
+
+// getOrSetCacheEarly is GetOrSetCache with WithEarlyRefresh, it reads the cache with its remaining ttl and the
+// duration of its loader in a single round trip. It reports whether the loader was called.
+func (r *redisRepository) getOrSetCacheEarly(key string, ttl int, dest interface{}, loader func() (interface{}, error)) (bool, error) {
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	var value *redis.StringCmd
+	var remaining *redis.DurationCmd
+	var delta *redis.StringCmd
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		value = pipe.Get(ctx, key)
+		remaining = pipe.PTTL(ctx, key)
+		delta = pipe.Get(ctx, earlyRefreshKey(key))
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	b, err := value.Bytes()
+	if err == nil {
+		ms, _ := strconv.ParseInt(delta.Val(), 10, 64)
+		if !refreshEarly(time.Duration(ms)*time.Millisecond, remaining.Val(), r.earlyRefresh) {
+			return false, r.codec.Unmarshal(b, dest)
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	b, err = r.loadCache(key, ttl, loader)
+	if err != nil {
+		return true, err
+	}
+
+	return true, r.codec.Unmarshal(b, dest)
+}
+
+// refreshEarly reports whether a cache expiring in remaining, whose loader takes delta, is loaded again now. It
+// draws the early expiration of XFetch, delta * beta * -ln(rand), and compares it with the remaining ttl.
+func refreshEarly(delta time.Duration, remaining time.Duration, beta float64) bool {
+	if delta <= 0 || remaining <= 0 {
+		return false
+	}
+
+	early := float64(delta) * beta * -math.Log(1-rand.Float64())
+	return early >= float64(remaining)
+}
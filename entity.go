@@ -4,6 +4,7 @@ import (
 	gosdk "github.com/PromptSnapshot/gosdk"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"reflect"
 	"time"
 )
 
@@ -42,6 +43,104 @@ func (b *BaseHardDelete) BeforeCreate(_ *gorm.DB) error {
 	return nil
 }
 
+// CloneEntity deep-copies the entity and resets the fields of its embedded Base or BaseHardDelete, so the clone is
+// saved as a new record. The associations are copied as they are, including their ids.
+// The overrides are applied to the clone in order.
+//
+// Parameters:
+// - src: the entity to be cloned.
+// - overrides: functions that modify the clone, e.g. to rename it.
+//
+// Returns:
+// - T: the clone of the entity.
+func CloneEntity[T any](src T, overrides ...func(*T)) T {
+	dst := deepCopy(reflect.ValueOf(&src).Elem(), map[uintptr]reflect.Value{}).Interface().(T)
+	resetBase(reflect.ValueOf(&dst).Elem())
+
+	for _, override := range overrides {
+		override(&dst)
+	}
+
+	return dst
+}
+
+// deepCopy returns a copy of v that shares no pointers, slices, or maps with it.
+// The pointers already copied are tracked in seen, so cyclic associations are copied as cycles.
+func deepCopy(v reflect.Value, seen map[uintptr]reflect.Value) reflect.Value {
+	dst := reflect.New(v.Type()).Elem()
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			break
+		}
+		if p, ok := seen[v.Pointer()]; ok {
+			dst.Set(p)
+			break
+		}
+		p := reflect.New(v.Type().Elem())
+		seen[v.Pointer()] = p
+		p.Elem().Set(deepCopy(v.Elem(), seen))
+		dst.Set(p)
+	case reflect.Interface:
+		if !v.IsNil() {
+			dst.Set(deepCopy(v.Elem(), seen))
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			dst.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				dst.Index(i).Set(deepCopy(v.Index(i), seen))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			dst.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				dst.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+			}
+		}
+	case reflect.Struct:
+		dst.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				dst.Field(i).Set(deepCopy(v.Field(i), seen))
+			}
+		}
+	default:
+		dst.Set(v)
+	}
+
+	return dst
+}
+
+// resetBase zeroes the embedded Base and BaseHardDelete of the entity held by v.
+func resetBase(v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch f.Type() {
+		case reflect.TypeOf(Base{}), reflect.TypeOf(BaseHardDelete{}):
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+}
+
 // PaginationMetadata is a struct that holds pagination metadata including the number of items per page, the current page,
 // the total number of items, and the total number of pages.
 type PaginationMetadata struct {
@@ -0,0 +1,176 @@
+package repositorysdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrKeyNotFound is matched by the errors of the redis commands run on a key which does not exist, such as an empty
+// list or a missing JSON document. It is matched by ErrCacheMiss as well.
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrCacheMiss is matched by the errors of the reads of a cache which does not exist, such as GetCache and
+// GetHashCache.
+var ErrCacheMiss = errors.New("cache miss")
+
+// ErrVersionMismatch is returned when a conditional write is rejected because the stored version differs from the expected one.
+var ErrVersionMismatch = errors.New("cache version mismatch")
+
+// ErrForbidden is returned by an Authorizer when the operation is not allowed.
+var ErrForbidden = errors.New("operation is not allowed")
+
+// ErrQuotaExceeded is returned when a tenant has reached the quota of a resource.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrMissingTenant is returned when a tenant-scoped operation is run with a context that carries no tenant id.
+var ErrMissingTenant = errors.New("missing tenant in context")
+
+// ErrReadOnly is returned when a write is made through a read-only repository.
+var ErrReadOnly = errors.New("repository is read-only")
+
+// ErrTransactionConflict is returned when an optimistic transaction keeps conflicting after its retries.
+var ErrTransactionConflict = errors.New("transaction conflict")
+
+// ErrLockNotAcquired is returned when a lock is held by someone else.
+var ErrLockNotAcquired = errors.New("lock not acquired")
+
+// ErrLockNotHeld is returned when a lock is released or extended after it has expired or been taken by someone else.
+var ErrLockNotHeld = errors.New("lock not held")
+
+// ErrSequentialScan is matched by the violations of the query plan guard.
+var ErrSequentialScan = errors.New("sequential scan over a large table")
+
+// ErrScriptNotFound is returned when a script is run under a name which was not loaded.
+var ErrScriptNotFound = errors.New("script not found")
+
+// ErrWriterClosed is returned when a write is made to a writer which is closed.
+var ErrWriterClosed = errors.New("writer is closed")
+
+// ErrCircuitOpen is returned when a call is failed fast because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ErrModuleUnavailable is returned when a command of a redis module is used while the module is not loaded.
+var ErrModuleUnavailable = errors.New("redis module is not available")
+
+// ErrRequestInProgress is returned when a request is started under an idempotency key whose first request is still
+// being processed.
+var ErrRequestInProgress = errors.New("request already in progress")
+
+// ErrInvalidKey is returned when a cache key is built from an empty part or a part with a space, a control character,
+// or the separator.
+var ErrInvalidKey = errors.New("invalid cache key")
+
+// ErrInvalidCursor is returned when the cursor of a cursor pagination is malformed or was issued for another sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// RepositoryError is the error returned by the repositories, it wraps the underlying error with the operation, the
+// entity or index, the key, and the duration of the call that failed. The underlying error is matched by errors.Is
+// and errors.As through Unwrap.
+type RepositoryError struct {
+	Op       string
+	Entity   string
+	Key      string
+	Duration time.Duration
+	Err      error
+}
+
+// Error returns the message of the underlying error prefixed with the context of the operation.
+func (e *RepositoryError) Error() string {
+	msg := e.Op
+	if e.Entity != "" {
+		msg += " " + e.Entity
+	}
+	if e.Key != "" {
+		msg += " " + strconv.Quote(e.Key)
+	}
+
+	return fmt.Sprintf("%s (%s): %v", msg, e.Duration, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RepositoryError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError replaces a non-nil *err by a RepositoryError, it is deferred by the repository methods with the start
+// time of the call. An error which is already a RepositoryError is left as it is, and redis.Nil is translated into a
+// missError.
+func wrapError(err *error, op string, entity string, key string, start time.Time) {
+	if *err == nil {
+		return
+	}
+
+	var repoErr *RepositoryError
+	if errors.As(*err, &repoErr) {
+		return
+	}
+
+	*err = &RepositoryError{
+		Op:       op,
+		Entity:   entity,
+		Key:      key,
+		Duration: time.Since(start),
+		Err:      translateError(*err, redisReadMethods[op]),
+	}
+}
+
+// missError is the translation of redis.Nil, it is matched by ErrKeyNotFound, by ErrCacheMiss for the reads of a
+// cache, and by redis.Nil through Unwrap for the callers which still check the error of the driver.
+type missError struct {
+	cache bool
+	err   error
+}
+
+// Error returns the message of ErrCacheMiss or ErrKeyNotFound.
+func (e *missError) Error() string {
+	if e.cache {
+		return ErrCacheMiss.Error()
+	}
+
+	return ErrKeyNotFound.Error()
+}
+
+// Is reports whether the error matches ErrKeyNotFound or ErrCacheMiss.
+func (e *missError) Is(target error) bool {
+	return target == ErrKeyNotFound || e.cache && target == ErrCacheMiss
+}
+
+// Unwrap returns the error of the driver.
+func (e *missError) Unwrap() error {
+	return e.err
+}
+
+// translateError translates redis.Nil into a missError, cache tells that the error is the miss of a cache read.
+func translateError(err error, cache bool) error {
+	var miss *missError
+	if !errors.Is(err, redis.Nil) || errors.As(err, &miss) {
+		return err
+	}
+
+	return &missError{cache: cache, err: err}
+}
+
+// isConnectionError reports whether err is caused by the connection to a server, such as a refused or reset
+// connection, a timeout, or an exhausted connection pool, rather than by the request itself.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		strings.Contains(err.Error(), "connection pool timeout")
+}
@@ -0,0 +1,150 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"sync"
+	"time"
+)
+
+// Event is a struct that holds a domain event raised by an entity, including the event type, the id of the aggregate
+// that raised it, the payload, and the headers.
+type Event struct {
+	Type        string
+	AggregateID string
+	Payload     interface{}
+	Headers     map[string]string
+	OccurredAt  time.Time
+}
+
+// DomainEventEmitter is implemented by the entities that raise domain events. The gorm repository collects the events
+// after the entity is created, updated, or deleted and publishes them once the enclosing transaction commits.
+type DomainEventEmitter interface {
+	DomainEvents() []Event
+}
+
+// EventPublisher publishes domain events to a message broker.
+type EventPublisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+type redisStreamEventPublisher struct {
+	client redis.UniversalClient
+	stream string
+}
+
+// NewRedisStreamEventPublisher function that create a new instance of EventPublisher which publishes the events to a redis stream
+func NewRedisStreamEventPublisher(client redis.UniversalClient, stream string) EventPublisher {
+	return &redisStreamEventPublisher{
+		client: client,
+		stream: stream,
+	}
+}
+
+// Publish appends the events to the stream by using the command `XADD` in a single pipeline.
+// The trace context of ctx is propagated in the headers of every event.
+//
+// Parameters:
+// - ctx: the context of the request which holds the trace context.
+// - events: the events to be published.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (p *redisStreamEventPublisher) Publish(ctx context.Context, events ...Event) (err error) {
+	defer wrapError(&err, "Publish", p.stream, "", time.Now())
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err = p.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, event := range events {
+			values, err := encodeEvent(ctx, event)
+			if err != nil {
+				return err
+			}
+
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: p.stream,
+				Values: values,
+			})
+		}
+		return nil
+	})
+
+	return err
+}
+
+// encodeEvent encodes the event into the fields of a stream entry and injects the trace context of ctx into its headers.
+func encodeEvent(ctx context.Context, event Event) (map[string]interface{}, error) {
+	headers := propagation.MapCarrier{}
+	for k, v := range event.Headers {
+		headers[k] = v
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+
+	h, err := json.Marshal(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	occurredAt := event.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	return map[string]interface{}{
+		"type":         event.Type,
+		"aggregate_id": event.AggregateID,
+		"payload":      payload,
+		"headers":      h,
+		"occurred_at":  occurredAt.UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+type pendingEventsKey struct{}
+
+// pendingEvents holds the events raised, and the quota usage counted, inside a transaction until the transaction
+// commits.
+type pendingEvents struct {
+	mu      sync.Mutex
+	events  []Event
+	commits []func() error
+}
+
+func (p *pendingEvents) add(events ...Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+}
+
+// afterCommit defers fn until the transaction commits, it is not called if the transaction rolls back.
+func (p *pendingEvents) afterCommit(fn func() error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.commits = append(p.commits, fn)
+}
+
+// committed calls the functions deferred by afterCommit, it returns their errors joined.
+func (p *pendingEvents) committed() error {
+	var errs []error
+	for _, fn := range p.commits {
+		errs = append(errs, fn())
+	}
+
+	return errors.Join(errs...)
+}
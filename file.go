@@ -0,0 +1,91 @@
+package repositorysdk
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"io"
+	"time"
+)
+
+type ObjectStorage interface {
+	PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// FileEntity is a struct that holds the metadata of an object of the object storage, it is embedded in the entities
+// which are written with CreateFile and DeleteFile.
+type FileEntity struct {
+	Base
+	ObjectKey   string `json:"object_key" gorm:"uniqueIndex"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// FileMetadata returns the metadata of the object, it gives CreateFile and DeleteFile access to the FileEntity
+// embedded in an entity.
+func (f *FileEntity) FileMetadata() *FileEntity {
+	return f
+}
+
+// FileRecord is an entity which embeds FileEntity.
+type FileRecord interface {
+	Entity
+	FileMetadata() *FileEntity
+}
+
+// CreateFile uploads the object and creates the metadata row of the entity. The object is uploaded first, so no
+// transaction is held open during the upload, and it is deleted when the row cannot be created, so a failed call
+// leaves neither an orphaned object nor a dangling row. The object key is generated when it is empty.
+//
+// Parameters:
+// - ctx: the context of the upload.
+// - repo: the repository of the entity.
+// - storage: the object storage.
+// - entity: the entity holding the metadata of the object.
+// - body: the content of the object, of FileEntity.Size bytes.
+//
+// Returns:
+// - error: an error if something goes wrong, joined with the error of the compensation delete if it fails too.
+func CreateFile[T FileRecord](ctx context.Context, repo GormRepository[T], storage ObjectStorage, entity T, body io.Reader) (err error) {
+	file := entity.FileMetadata()
+	if file.ObjectKey == "" {
+		file.ObjectKey = entity.TableName() + "/" + uuid.NewString()
+	}
+
+	defer wrapError(&err, "CreateFile", entityTypeName[T](), file.ObjectKey, time.Now())
+
+	if err := storage.PutObject(ctx, file.ObjectKey, body, file.Size, file.ContentType); err != nil {
+		return err
+	}
+
+	if err := repo.Create(entity); err != nil {
+		return errors.Join(err, storage.DeleteObject(ctx, file.ObjectKey))
+	}
+
+	return nil
+}
+
+// DeleteFile deletes the metadata row of the entity with the given id and its object. The row is soft-deleted first,
+// by the DeletedAt of FileEntity, and the object is deleted once the row is committed, so no row points at a deleted
+// object. When the object cannot be deleted, the soft-deleted row still holds its key, e.g. to clean it up later.
+//
+// Parameters:
+// - ctx: the context of the deletion.
+// - repo: the repository of the entity.
+// - storage: the object storage.
+// - id: the id of the entity.
+// - entity: the entity which receives the deleted row.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func DeleteFile[T FileRecord](ctx context.Context, repo GormRepository[T], storage ObjectStorage, id string, entity T) (err error) {
+	defer wrapError(&err, "DeleteFile", entityTypeName[T](), id, time.Now())
+
+	if err := repo.Delete(id, entity); err != nil {
+		return err
+	}
+
+	return storage.DeleteObject(ctx, entity.FileMetadata().ObjectKey)
+}
@@ -1,28 +1,54 @@
 package repositorysdk
 
 import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 	"math"
+	"reflect"
+	"strings"
+	"time"
 )
 
 type Entity interface {
 	TableName() string
 }
 
-// Pagination returns a function that can be used as a GORM scope to paginate results. It takes a pointer to a slice of the entity type, a pointer to a PaginationMetadata struct, a GORM database instance, and an optional list of additional GORM scopes. It calculates the total number of items that match the query, updates the provided PaginationMetadata struct with the total number of items, total number of pages, and current page number, and returns a GORM scope that can be used to fetch the results for the current page.
-func Pagination(meta *PaginationMetadata, db *gorm.DB) func(db *gorm.DB) *gorm.DB {
+// Pagination returns a function that can be used as a GORM scope to paginate results. It takes a pointer to a PaginationMetadata struct, a GORM database instance of the model, and an optional list of filter scopes. It counts the items that match db and the filter scopes on a session of its own, so the total matches the filtered query and db is left untouched, updates the provided PaginationMetadata struct with the total number of items, total number of pages, and current page number, and returns a GORM scope that applies the filter scopes and fetches the results for the current page. A count that fails is reported by the query using the returned scope.
+func Pagination(meta *PaginationMetadata, db *gorm.DB, scopes ...func(db *gorm.DB) *gorm.DB) func(db *gorm.DB) *gorm.DB {
 	var totalItems int64
-	db.Count(&totalItems)
+	countErr := applyScopes(db, scopes...).Count(&totalItems).Error
 
 	meta.TotalItem = int(totalItems)
 	totalPages := math.Ceil(float64(totalItems) / float64(meta.GetItemPerPage()))
 	meta.TotalPage = int(totalPages)
 
 	return func(db *gorm.DB) *gorm.DB {
+		if countErr != nil {
+			_ = db.AddError(countErr)
+		}
+		for _, scope := range scopes {
+			db = scope(db)
+		}
 		return db.Offset(meta.GetOffset()).Limit(meta.ItemsPerPage)
 	}
 }
 
+// applyScopes applies the scopes right away to a new session of db, unlike db.Scopes which defers them to the
+// execution of the query, so a count made on the returned session sees their conditions and drops their ordering.
+// db itself is left untouched, and the returned session can be shared by several queries.
+func applyScopes(db *gorm.DB, scopes ...func(db *gorm.DB) *gorm.DB) *gorm.DB {
+	db = db.Session(&gorm.Session{})
+	for _, scope := range scopes {
+		db = scope(db)
+	}
+
+	return db.Session(&gorm.Session{})
+}
+
 // FindOneByID returns a function that queries the entity with the given ID and returns the query result.
 func FindOneByID[T Entity](id string, entity T) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -68,36 +94,112 @@ func DeleteWithoutResult[T Entity](id string, entity T) func(db *gorm.DB) *gorm.
 }
 
 type GormRepository[T Entity] interface {
-	FindAll(metadata *PaginationMetadata, entities *[]T) error
+	FindAll(metadata *PaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error
+	FindAllWithRelations(metadata *PaginationMetadata, entities *[]T, relations ...string) error
+	FindAllCursor(metadata *CursorPaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error
 	FindOne(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
 	Create(entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	CreateMany(entities []T, batchSize int, scope ...func(db *gorm.DB) *gorm.DB) error
+	Upsert(entity T, conflictColumns []string, updateColumns []string) error
+	UpsertMany(entities []T, batchSize int, conflictColumns []string, updateColumns []string) error
 	Update(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
 	Delete(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	Clone(src T, overrides ...func(*T)) (T, error)
+	FindAsOf(id string, at time.Time, entity T) error
+	WithTransaction(fns ...func(tx *gorm.DB) error) error
+	WithContext(ctx context.Context) GormRepository[T]
 	GetDB() *gorm.DB
 }
 
+// GormOption is a function that configures the optional behaviours of a gorm repository.
+type GormOption func(*gormOptions)
+
+type gormOptions struct {
+	publisher  EventPublisher
+	onPublish  func(events []Event, err error)
+	authorizer Authorizer
+	quota      QuotaManager
+	history    bool
+	hooks      *HookRegistry
+
+	indexer        SearchIndexer
+	searchSyncMode SearchSyncMode
+	onSearchError  func(op Operation, entity interface{}, err error)
+}
+
+// WithEventPublisher enables publishing the domain events of the entities implementing DomainEventEmitter.
+// The events are published after the entity is written, or after the commit when it is written inside WithTransaction.
+// The write is already committed when the publishing fails, so the error is reported to the handler of
+// WithPublishErrorHandler rather than returned.
+func WithEventPublisher(publisher EventPublisher) GormOption {
+	return func(o *gormOptions) {
+		o.publisher = publisher
+	}
+}
+
+// WithPublishErrorHandler sets the handler of the domain events which cannot be published after their write is
+// committed, e.g. to retry them or to write them elsewhere. The errors are passed to the error handler of
+// OpenTelemetry, otel.Handle, when no handler is set.
+func WithPublishErrorHandler(fn func(events []Event, err error)) GormOption {
+	return func(o *gormOptions) {
+		o.onPublish = fn
+	}
+}
+
 type gormRepository[T Entity] struct {
 	db *gorm.DB
+	gormOptions
 }
 
 // NewGormRepository function that create a new instance of gormRepository[T] with a GORM database connection
-func NewGormRepository[T Entity](db *gorm.DB) GormRepository[T] {
-	return &gormRepository[T]{
+func NewGormRepository[T Entity](db *gorm.DB, opts ...GormOption) GormRepository[T] {
+	r := &gormRepository[T]{
 		db: db,
 	}
+
+	for _, opt := range opts {
+		opt(&r.gormOptions)
+	}
+
+	return r
 }
 
 func (r *gormRepository[T]) GetDB() *gorm.DB {
 	return r.db
 }
 
+// WithContext returns a copy of the repository whose queries run with ctx, so they are cancelled when ctx is done,
+// e.g. when the HTTP request is cancelled or its deadline is exceeded. The tenant, the events, and the authorizer also
+// read their values from ctx.
+//
+// Parameters:
+// - ctx: the context of the queries.
+//
+// Returns:
+// - GormRepository[T]: the repository bound to ctx.
+func (r *gormRepository[T]) WithContext(ctx context.Context) GormRepository[T] {
+	c := *r
+	c.db = r.db.WithContext(ctx)
+
+	return &c
+}
+
 // FindAll the entities with pagination metadata and scopes.
 // Pagination is achieved by using the Pagination function.
+// The scopes filter and order the entities, e.g. a `Where` and an `Order`, the total number of items counts the
+// filtered entities only.
 // The method updates the metadata to reflect the total number of items and the number of items on the current page.
-func (r *gormRepository[T]) FindAll(metadata *PaginationMetadata, entities *[]T) error {
-	if err := r.db.
-		Scopes(Pagination(metadata, r.db)).
-		Find(&entities).
+func (r *gormRepository[T]) FindAll(metadata *PaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "FindAll", entityTypeName[T](), "", time.Now())
+
+	if err := r.authorize(OperationFindAll, nil); err != nil {
+		return err
+	}
+
+	db := r.db.Model(entities)
+	if err := db.
+		Scopes(Pagination(metadata, db, scope...)).
+		Find(entities).
 		Error; err != nil {
 		return err
 	}
@@ -106,59 +208,349 @@ func (r *gormRepository[T]) FindAll(metadata *PaginationMetadata, entities *[]T)
 	return nil
 }
 
-// FindOne finds a single entity with the given id and optional scopes.
-func (r *gormRepository[T]) FindOne(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
+// find finds the entities matching the scopes without pagination, e.g. for the fan-out of a sharded repository.
+func (r *gormRepository[T]) find(entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	if err := r.authorize(OperationFindAll, nil); err != nil {
+		return err
+	}
+
 	return r.db.
 		Scopes(scope...).
-		First(entity, "id = ?", id).
+		Find(entities).
 		Error
 }
 
-// Create a new entity in the database.
-func (r *gormRepository[T]) Create(entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
-	return r.db.
+// FindAllWithRelations the entities with pagination metadata, and preloads the relations of the entities of the page.
+// Each relation is loaded by a single `IN` query over the page, nested relations are named with dots, e.g. "Orders.Items".
+func (r *gormRepository[T]) FindAllWithRelations(metadata *PaginationMetadata, entities *[]T, relations ...string) (err error) {
+	defer wrapError(&err, "FindAllWithRelations", entityTypeName[T](), "", time.Now())
+
+	if err := r.authorize(OperationFindAll, nil); err != nil {
+		return err
+	}
+
+	db := r.db
+	for _, relation := range relations {
+		db = db.Preload(relation)
+	}
+
+	if err := db.
+		Scopes(Pagination(metadata, r.db.Model(entities))).
+		Find(entities).
+		Error; err != nil {
+		return err
+	}
+
+	metadata.ItemCount = len(*entities)
+	return nil
+}
+
+// FindAllCursor the entities of a page of a cursor pagination, the page starts after the entity of the cursor by using
+// a keyset predicate, e.g. `WHERE (created_at, id) > (?, ?)`, so the deep pages cost as much as the first one on an
+// index of the sort key and the primary key.
+// The scopes filter the entities, they should not order them as the entities are ordered by the sort key.
+// The method updates the metadata with the number of items of the page and the cursors of the next and previous pages.
+// A malformed cursor, or a cursor of another sort, fails with ErrInvalidCursor.
+func (r *gormRepository[T]) FindAllCursor(metadata *CursorPaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "FindAllCursor", entityTypeName[T](), "", time.Now())
+
+	if err := r.authorize(OperationFindAll, nil); err != nil {
+		return err
+	}
+
+	page, err := newKeyset(r.db, metadata, entities)
+	if err != nil {
+		return err
+	}
+
+	if err := r.db.
 		Scopes(scope...).
-		Create(entity).
-		Error
+		Scopes(page.scope).
+		Find(entities).
+		Error; err != nil {
+		return err
+	}
+
+	return page.finish(r.context(), entities)
+}
+
+// FindOne finds a single entity with the given id and optional scopes.
+// The authorizer is evaluated on the loaded entity, which is reset if it is denied.
+func (r *gormRepository[T]) FindOne(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "FindOne", entityTypeName[T](), id, time.Now())
+
+	if err := r.db.
+		Scopes(scope...).
+		First(entity, "id = ?", id).
+		Error; err != nil {
+		return err
+	}
+
+	// the row is authorized once loaded, so the authorizer sees its owner or its tenant
+	if err := r.authorize(OperationFindOne, entity); err != nil {
+		resetEntity(entity)
+		return err
+	}
+
+	return nil
+}
+
+// Create a new entity in the database.
+func (r *gormRepository[T]) Create(entity T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "Create", entityTypeName[T](), "", time.Now())
+
+	if err := r.authorize(OperationCreate, entity); err != nil {
+		return err
+	}
+
+	if err := r.checkRowQuota(entity); err != nil {
+		return err
+	}
+
+	if err := r.runHooks(HookBeforeCreate, entity); err != nil {
+		return err
+	}
+
+	if err := r.write(OperationCreate, "", entity, func(db *gorm.DB) error {
+		return db.
+			Scopes(scope...).
+			Create(entity).
+			Error
+	}); err != nil {
+		return err
+	}
+
+	if err := r.addRowUsage(1, entity); err != nil {
+		return err
+	}
+
+	if err := r.runHooks(HookAfterCreate, entity); err != nil {
+		return err
+	}
+
+	r.syncSearchIndexInline(OperationCreate, entity)
+	r.emitEvents(entity)
+
+	return nil
+}
+
+// CreateMany creates the entities in the database with batched inserts, e.g. for the importers, by using the
+// CreateInBatches of GORM. The batches are inserted in a single transaction, unless the default transaction of GORM is
+// skipped, so either all the entities are created or none is. The authorizer, the hooks, the search index, and the
+// domain events apply to every entity as with Create, and the whole batch must fit in the row quota.
+//
+// Parameters:
+// - entities: the entities to be created.
+// - batchSize: the number of entities inserted per statement, 0 means 100.
+// - scope: extends scope (optional).
+//
+// Returns:
+// - error: an error if an entity cannot be created, otherwise nil.
+func (r *gormRepository[T]) CreateMany(entities []T, batchSize int, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "CreateMany", entityTypeName[T](), "", time.Now())
+
+	if len(entities) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	for _, entity := range entities {
+		if err := r.authorize(OperationCreate, entity); err != nil {
+			return err
+		}
+	}
+
+	if err := r.checkRowQuota(entities...); err != nil {
+		return err
+	}
+
+	for _, entity := range entities {
+		if err := r.runHooks(HookBeforeCreate, entity); err != nil {
+			return err
+		}
+	}
+
+	if err := r.writeMany(OperationCreate, entities, func(db *gorm.DB) error {
+		return db.
+			Scopes(scope...).
+			CreateInBatches(entities, batchSize).
+			Error
+	}); err != nil {
+		return err
+	}
+
+	if err := r.addRowUsage(1, entities...); err != nil {
+		return err
+	}
+
+	for _, entity := range entities {
+		if err := r.runHooks(HookAfterCreate, entity); err != nil {
+			return err
+		}
+
+		r.syncSearchIndexInline(OperationCreate, entity)
+		r.emitEvents(entity)
+	}
+
+	return nil
+}
+
+// Upsert creates the entity, or updates the row it conflicts with, by using `INSERT ... ON CONFLICT DO UPDATE`, e.g.
+// for the idempotent ingestion pipelines. The entity is filled back with the stored row, so its id is the id of the
+// updated row on a conflict.
+// The authorizer is asked for both the creation and the update, and the search index and the domain events apply as
+// with Create. The stored rows the entities conflict with are locked first, so the created rows are counted in the row
+// quota and the prior version of the updated rows is archived in the same transaction. The hooks are left out as the
+// write may be either of them.
+//
+// Parameters:
+// - entity: the entity to be created or updated.
+// - conflictColumns: the columns of the unique constraint, the primary key if empty.
+// - updateColumns: the columns updated on a conflict, all the columns but the primary key and the creation time if
+// empty.
+//
+// Returns:
+// - error: an error if the entity cannot be written, otherwise nil.
+func (r *gormRepository[T]) Upsert(entity T, conflictColumns []string, updateColumns []string) (err error) {
+	defer wrapError(&err, "Upsert", entityTypeName[T](), "", time.Now())
+
+	return r.upsert([]T{entity}, 1, conflictColumns, updateColumns)
+}
+
+// UpsertMany upserts the entities with batched inserts in a single transaction, as Upsert does for a single entity.
+//
+// Parameters:
+// - entities: the entities to be created or updated.
+// - batchSize: the number of entities written per statement, 0 means 100.
+// - conflictColumns: the columns of the unique constraint, the primary key if empty.
+// - updateColumns: the columns updated on a conflict, all the columns but the primary key and the creation time if
+// empty.
+//
+// Returns:
+// - error: an error if an entity cannot be written, otherwise nil.
+func (r *gormRepository[T]) UpsertMany(entities []T, batchSize int, conflictColumns []string, updateColumns []string) (err error) {
+	defer wrapError(&err, "UpsertMany", entityTypeName[T](), "", time.Now())
+
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	return r.upsert(entities, batchSize, conflictColumns, updateColumns)
 }
 
 // Update an existing entity with the given id in the database.
 // It returns an error if no entity with the given id is found.
-func (r *gormRepository[T]) Update(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
-	return r.db.
-		Scopes(scope...).
-		Where(id, "id = ?", id).
-		Updates(&entity).
-		First(&entity, "id = ?", id).
-		Error
+// The authorizer is evaluated on the stored row, locked until the update, rather than on the given entity.
+func (r *gormRepository[T]) Update(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "Update", entityTypeName[T](), id, time.Now())
+
+	if err := r.write(OperationUpdate, id, entity, func(db *gorm.DB) error {
+		if err := r.runHooks(HookBeforeUpdate, entity); err != nil {
+			return err
+		}
+
+		return db.
+			Scopes(scope...).
+			Where(id, "id = ?", id).
+			Updates(&entity).
+			First(&entity, "id = ?", id).
+			Error
+	}); err != nil {
+		return err
+	}
+
+	if err := r.runHooks(HookAfterUpdate, entity); err != nil {
+		return err
+	}
+
+	r.syncSearchIndexInline(OperationUpdate, entity)
+	r.emitEvents(entity)
+
+	return nil
 }
 
 // Delete an existing entity with the given id from the database.
 // It returns an error if no entity with the given id is found.
-func (r *gormRepository[T]) Delete(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
-	return r.db.
-		Scopes(scope...).
-		First(&entity, "id = ?", id).
-		Delete(&entity).
-		Error
+// The authorizer is evaluated on the stored row, locked until the removal, rather than on the given entity.
+func (r *gormRepository[T]) Delete(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "Delete", entityTypeName[T](), id, time.Now())
+
+	if err := r.write(OperationDelete, id, entity, func(db *gorm.DB) error {
+		if err := r.runHooks(HookBeforeDelete, entity); err != nil {
+			return err
+		}
+
+		return db.
+			Scopes(scope...).
+			First(&entity, "id = ?", id).
+			Delete(&entity).
+			Error
+	}); err != nil {
+		return err
+	}
+
+	if err := r.addRowUsage(-1, entity); err != nil {
+		return err
+	}
+
+	if err := r.runHooks(HookAfterDelete, entity); err != nil {
+		return err
+	}
+
+	r.syncSearchIndexInline(OperationDelete, entity)
+	r.emitEvents(entity)
+
+	return nil
+}
+
+// Clone creates a copy of the entity as a new record in the database, the copy is made by CloneEntity.
+//
+// Parameters:
+// - src: the entity to be cloned.
+// - overrides: functions that modify the clone before it is created.
+//
+// Returns:
+// - T: the created clone.
+// - error: an error if the clone cannot be created, otherwise nil.
+func (r *gormRepository[T]) Clone(src T, overrides ...func(*T)) (clone T, err error) {
+	defer wrapError(&err, "Clone", entityTypeName[T](), "", time.Now())
+
+	clone = CloneEntity(src, overrides...)
+	if err := r.Create(clone); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return clone, nil
 }
 
 // WithTransaction runs a list of functions inside a single transaction.
+// The domain events raised, and the quota usage counted, through the repositories created with the given tx are
+// published and added after the commit.
 //
 // Parameters:
 // - fns: a list of functions that will be executed within a single transaction.
 //
 // Returns:
 // - error: an error if any of the functions returns an error or the transaction commit fails, otherwise nil.
-func (r *gormRepository[T]) WithTransaction(fns ...func(tx *gorm.DB) error) error {
-	tx := r.db.Begin()
+func (r *gormRepository[T]) WithTransaction(fns ...func(tx *gorm.DB) error) (err error) {
+	defer wrapError(&err, "WithTransaction", entityTypeName[T](), "", time.Now())
+
+	pending := &pendingEvents{}
+
+	tx := r.db.
+		WithContext(context.WithValue(r.context(), pendingEventsKey{}, pending)).
+		Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
 	defer func() {
 		if err := recover(); err != nil {
 			tx.Rollback()
 			panic(err)
-		} else if err := tx.Commit().Error; err != nil {
-			tx.Rollback()
-			panic(err)
 		}
 	}()
 
@@ -169,5 +561,393 @@ func (r *gormRepository[T]) WithTransaction(fns ...func(tx *gorm.DB) error) erro
 		}
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	// the usage is counted once the rows are committed
+	usageErr := pending.committed()
+
+	r.publishEvents(pending.events...)
+
+	return usageErr
+}
+
+// emitEvents publishes the domain events of the entity, or defers them to the commit when the repository runs
+// inside WithTransaction.
+func (r *gormRepository[T]) emitEvents(entity T) {
+	emitter, ok := any(entity).(DomainEventEmitter)
+	if !ok {
+		return
+	}
+
+	if pending, ok := r.context().Value(pendingEventsKey{}).(*pendingEvents); ok {
+		pending.add(emitter.DomainEvents()...)
+		return
+	}
+
+	r.publishEvents(emitter.DomainEvents()...)
+}
+
+// publishEvents publishes the events of a committed write, the error is reported to the publish error handler since
+// the write cannot be undone.
+func (r *gormRepository[T]) publishEvents(events ...Event) {
+	if r.publisher == nil || len(events) == 0 {
+		return
+	}
+
+	err := r.publisher.Publish(r.context(), events...)
+	if err == nil {
+		return
+	}
+
+	if r.onPublish != nil {
+		r.onPublish(events, err)
+		return
+	}
+	otel.Handle(err)
+}
+
+// authorize evaluates the authorizer of the repository, if any, for the operation on the entity.
+func (r *gormRepository[T]) authorize(op Operation, entity interface{}) error {
+	if r.authorizer == nil {
+		return nil
+	}
+
+	return r.authorizer.Authorize(r.context(), op, entityTypeName[T](), entity)
+}
+
+// authorizeStored loads the stored row with the given id, locked until the end of tx, and authorizes the operation on
+// it, so the authorizer sees the owner or the tenant of the row rather than the payload of the caller.
+func (r *gormRepository[T]) authorizeStored(tx *gorm.DB, op Operation, id string) error {
+	var stored T
+	dest := interface{}(&stored)
+	if t := reflect.TypeOf(stored); t != nil && t.Kind() == reflect.Pointer {
+		stored = reflect.New(t.Elem()).Interface().(T)
+		dest = stored
+	}
+
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(dest, "id = ?", id).
+		Error; err != nil {
+		return err
+	}
+
+	return r.authorize(op, stored)
+}
+
+// resetEntity zeroes the entity the pointer points to, e.g. an entity loaded for a caller who is denied.
+func resetEntity(entity interface{}) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
+
+// checkRowQuota checks that the entities fit in the row quota of the tenant of the context, and in its storage quota
+// when the entities are files, if the repository counts the rows.
+func (r *gormRepository[T]) checkRowQuota(entities ...T) error {
+	if r.quota == nil {
+		return nil
+	}
+
+	if _, ok := TenantFromContext(r.context()); !ok {
+		return nil
+	}
+
+	if err := r.quota.CheckQuotaFor(r.context(), QuotaRows, int64(len(entities))); err != nil {
+		return err
+	}
+
+	if bytes, ok := fileBytes(entities); ok {
+		return r.quota.CheckQuotaFor(r.context(), QuotaStorageBytes, bytes)
+	}
+
 	return nil
 }
+
+// addRowUsage adds the entities, and the bytes of their files, to the usage of the tenant of the context, if the
+// repository counts the rows. A negative sign removes them. Inside WithTransaction the usage is added once the
+// transaction commits, so a rollback leaves the counters as they are.
+func (r *gormRepository[T]) addRowUsage(sign int64, entities ...T) error {
+	if r.quota == nil {
+		return nil
+	}
+
+	ctx := r.context()
+	if _, ok := TenantFromContext(ctx); !ok {
+		return nil
+	}
+
+	add := func() error {
+		if _, err := r.quota.AddUsage(ctx, QuotaRows, sign*int64(len(entities))); err != nil {
+			return err
+		}
+
+		if bytes, ok := fileBytes(entities); ok {
+			if _, err := r.quota.AddUsage(ctx, QuotaStorageBytes, sign*bytes); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if pending, ok := ctx.Value(pendingEventsKey{}).(*pendingEvents); ok {
+		pending.afterCommit(add)
+		return nil
+	}
+
+	return add()
+}
+
+// fileBytes returns the total size of the files of the entities, and whether they are files.
+func fileBytes[T Entity](entities []T) (int64, bool) {
+	var bytes int64
+	for _, entity := range entities {
+		file, ok := any(entity).(interface{ FileMetadata() *FileEntity })
+		if !ok {
+			return 0, false
+		}
+		bytes += file.FileMetadata().Size
+	}
+
+	return bytes, len(entities) > 0
+}
+
+// write runs the write of the entity with the given id. When the repository is system-versioned or syncs the search
+// index through the outbox, the prior version of the row is archived and the outbox is written in the same transaction.
+// When the repository has an authorizer, the stored row of an update or a delete is authorized in the same transaction.
+func (r *gormRepository[T]) write(op Operation, id string, entity T, fn func(db *gorm.DB) error) error {
+	outbox := r.indexer != nil && r.searchSyncMode == SearchSyncOutbox
+	guarded := r.authorizer != nil && op != OperationCreate
+	if !r.history && !outbox && !guarded {
+		return fn(r.db)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if guarded {
+			if err := r.authorizeStored(tx, op, id); err != nil {
+				return err
+			}
+		}
+
+		if r.history && op != OperationCreate {
+			if err := archiveVersion(tx, entity, id); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if outbox {
+			return r.syncSearchIndex(tx, op, entity)
+		}
+
+		return nil
+	})
+}
+
+// upsert writes the entities with the clause ON CONFLICT built from the columns, and reads the stored rows back. When
+// the repository has an authorizer, the stored rows the entities conflict with are authorized for the update in the
+// same transaction, and only the entities which conflict with none of them count against the row quota.
+func (r *gormRepository[T]) upsert(entities []T, batchSize int, conflictColumns []string, updateColumns []string) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	for _, entity := range entities {
+		if err := r.authorize(OperationCreate, entity); err != nil {
+			return err
+		}
+		if err := r.authorize(OperationUpdate, entity); err != nil {
+			return err
+		}
+	}
+
+	onConflict := clause.OnConflict{UpdateAll: len(updateColumns) == 0}
+	for _, column := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+	if len(updateColumns) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(updateColumns)
+	}
+
+	// the columns are listed, gorm fills the entities of a batch back in place only with an explicit RETURNING
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(entities[0]); err != nil {
+		return err
+	}
+	returning := clause.Returning{}
+	for _, name := range stmt.Schema.DBNames {
+		returning.Columns = append(returning.Columns, clause.Column{Name: name})
+	}
+
+	inserted := entities
+	if err := r.writeMany(OperationUpdate, entities, func(db *gorm.DB) error {
+		// the stored rows the entities conflict with are updated, the others are created
+		if r.history || r.quota != nil || r.authorizer != nil {
+			var stored []T
+			var err error
+			if stored, inserted, err = conflictingRows(db, entities, conflictColumns); err != nil {
+				return err
+			}
+
+			ids := make([]string, len(stored))
+			for i, row := range stored {
+				if err := r.authorize(OperationUpdate, row); err != nil {
+					return err
+				}
+				ids[i] = entityID(row)
+			}
+
+			if err := r.checkRowQuota(inserted...); err != nil {
+				return err
+			}
+
+			if r.history && len(ids) > 0 {
+				if err := archiveVersion(db, entities[0], ids...); err != nil {
+					return err
+				}
+			}
+		}
+
+		return db.
+			Clauses(onConflict, returning).
+			CreateInBatches(entities, batchSize).
+			Error
+	}); err != nil {
+		return err
+	}
+
+	if err := r.addRowUsage(1, inserted...); err != nil {
+		return err
+	}
+
+	for _, entity := range entities {
+		r.syncSearchIndexInline(OperationUpdate, entity)
+		r.emitEvents(entity)
+	}
+
+	return nil
+}
+
+// writeMany runs the write of the entities. When the repository is system-versioned, counts the rows, syncs the
+// search index through the outbox, or authorizes the stored rows of an update, fn runs in a transaction and the outbox
+// is written in the same transaction.
+func (r *gormRepository[T]) writeMany(op Operation, entities []T, fn func(db *gorm.DB) error) error {
+	outbox := r.indexer != nil && r.searchSyncMode == SearchSyncOutbox
+	guarded := r.authorizer != nil && op != OperationCreate
+	if !r.history && r.quota == nil && !outbox && !guarded {
+		return fn(r.db)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if !outbox {
+			return nil
+		}
+
+		for _, entity := range entities {
+			if err := r.syncSearchIndex(tx, op, entity); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// conflictingRows returns the stored rows the entities conflict with on the columns, the primary key if empty, and the
+// entities which conflict with none of them. The rows are locked until the end of the transaction of db.
+func conflictingRows[T Entity](db *gorm.DB, entities []T, columns []string) (stored []T, inserted []T, err error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entities[0]); err != nil {
+		return nil, nil, err
+	}
+	if len(columns) == 0 {
+		columns = stmt.Schema.PrimaryFieldDBNames
+	}
+
+	fields := make([]*schema.Field, len(columns))
+	vars := make([]interface{}, 0, len(columns)+1)
+	for i, column := range columns {
+		if fields[i] = stmt.Schema.LookUpField(column); fields[i] == nil {
+			return nil, nil, fmt.Errorf("unknown conflict column %s of %s", column, stmt.Schema.Name)
+		}
+		vars = append(vars, clause.Column{Name: fields[i].DBName})
+	}
+
+	keyOf := func(entity T) []interface{} {
+		key := make([]interface{}, len(fields))
+		for i, field := range fields {
+			key[i], _ = field.ValueOf(db.Statement.Context, reflect.Indirect(reflect.ValueOf(entity)))
+			if v := reflect.ValueOf(key[i]); v.Kind() == reflect.Pointer && !v.IsNil() {
+				key[i] = v.Elem().Interface()
+			}
+		}
+		return key
+	}
+
+	keys := make([][]interface{}, len(entities))
+	for i, entity := range entities {
+		keys[i] = keyOf(entity)
+	}
+	vars = append(vars, keys)
+
+	if err := db.
+		Model(entities[0]).
+		Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(clause.Expr{SQL: "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ") + ") IN ?", Vars: vars}).
+		Find(&stored).
+		Error; err != nil {
+		return nil, nil, err
+	}
+
+	conflicting := make(map[string]bool, len(stored))
+	for _, row := range stored {
+		conflicting[fmt.Sprintf("%#v", keyOf(row))] = true
+	}
+	for i, entity := range entities {
+		if !conflicting[fmt.Sprintf("%#v", keys[i])] {
+			inserted = append(inserted, entity)
+		}
+	}
+
+	return stored, inserted, nil
+}
+
+// syncSearchIndexInline indexes the entity after the write when the repository syncs the search index inline. The
+// write is already committed, so the error is reported to the search error handler rather than returned.
+func (r *gormRepository[T]) syncSearchIndexInline(op Operation, entity T) {
+	if r.indexer == nil || r.searchSyncMode != SearchSyncInline {
+		return
+	}
+
+	err := r.syncSearchIndex(r.db, op, entity)
+	if err == nil {
+		return
+	}
+
+	if r.onSearchError != nil {
+		r.onSearchError(op, entity, err)
+		return
+	}
+	otel.Handle(err)
+}
+
+// context returns the context bound to the GORM database connection.
+func (r *gormRepository[T]) context() context.Context {
+	if r.db.Statement.Context == nil {
+		return context.Background()
+	}
+
+	return r.db.Statement.Context
+}
@@ -0,0 +1,249 @@
+package repositorysdk
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type upsertItem struct {
+	Base
+	TenantID string
+	Slug     string `gorm:"uniqueIndex"`
+}
+
+func (*upsertItem) TableName() string { return "upsert_items" }
+
+// tenantAuthorizer allows the operations on the entities of the tenant of the context only.
+var tenantAuthorizer = AuthorizerFunc(func(ctx context.Context, op Operation, entityType string, entity interface{}) error {
+	tenant, _ := TenantFromContext(ctx)
+	if item, ok := entity.(*upsertItem); ok && item.TenantID != tenant {
+		return ErrForbidden
+	}
+	return nil
+})
+
+func TestUpsertRejectsTheStoredRowOfAnotherTenant(t *testing.T) {
+	db := newStubDB(t, func(query string) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "FOR UPDATE") {
+			return []string{"id", "tenant_id", "slug"}, [][]driver.Value{
+				{"9b2f1d0e-6c1a-4a57-8f1e-2d0f5b6c7a81", "tenant-b", "shared"},
+			}
+		}
+		return nil, nil
+	})
+
+	ctx := WithTenant(context.Background(), "tenant-a")
+	repo := NewGormRepository[*upsertItem](db.db.WithContext(ctx), WithAuthorizer(tenantAuthorizer))
+
+	err := repo.Upsert(&upsertItem{TenantID: "tenant-a", Slug: "shared"}, []string{"slug"}, nil)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("upsert over the row of another tenant: got %v, want ErrForbidden", err)
+	}
+	if db.executed("INSERT") {
+		t.Errorf("the row of another tenant was overwritten: %v", db.queries())
+	}
+	if !db.executed("ROLLBACK") {
+		t.Errorf("the transaction was not rolled back: %v", db.queries())
+	}
+}
+
+func TestUpsertChecksTheQuotaOfTheInsertedRowsOnly(t *testing.T) {
+	db := newStubDB(t, func(query string) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "FOR UPDATE") {
+			return []string{"id", "tenant_id", "slug"}, [][]driver.Value{
+				{"9b2f1d0e-6c1a-4a57-8f1e-2d0f5b6c7a81", "tenant-a", "stored"},
+			}
+		}
+		return nil, nil
+	})
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	quota := NewQuotaManager(client, map[QuotaResource]int64{QuotaRows: 1})
+	ctx := WithTenant(context.Background(), "tenant-a")
+	if _, err := quota.AddUsage(ctx, QuotaRows, 1); err != nil {
+		t.Fatalf("add usage: %v", err)
+	}
+
+	repo := NewGormRepository[*upsertItem](db.db.WithContext(ctx), WithQuota(quota))
+
+	// the stored row is updated, the re-ingest needs no room in the quota
+	if err := repo.Upsert(&upsertItem{TenantID: "tenant-a", Slug: "stored"}, []string{"slug"}, nil); err != nil {
+		t.Fatalf("upsert of a stored row at the quota limit: %v", err)
+	}
+	if usage, _ := quota.GetUsage(ctx, QuotaRows); usage != 1 {
+		t.Errorf("usage after the update = %d, want 1", usage)
+	}
+
+	// a new row does not fit
+	err := repo.UpsertMany([]*upsertItem{
+		{TenantID: "tenant-a", Slug: "stored"},
+		{TenantID: "tenant-a", Slug: "new"},
+	}, 0, []string{"slug"}, nil)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("upsert of a new row at the quota limit: got %v, want ErrQuotaExceeded", err)
+	}
+}
+
+// stubDB is a gorm database over a stub SQL driver, which records the statements and answers the queries with the
+// rows of its result function.
+type stubDB struct {
+	db     *gorm.DB
+	mu     sync.Mutex
+	log    []string
+	result func(query string) ([]string, [][]driver.Value)
+}
+
+var (
+	stubOnce sync.Once
+	stubDBs  sync.Map
+	stubSeq  atomic.Int64
+)
+
+func newStubDB(t *testing.T, result func(query string) ([]string, [][]driver.Value)) *stubDB {
+	t.Helper()
+	stubOnce.Do(func() {
+		sql.Register("repositorysdk-stub", stubDriver{})
+	})
+
+	s := &stubDB{result: result}
+	name := fmt.Sprintf("%s#%d", t.Name(), stubSeq.Add(1))
+	stubDBs.Store(name, s)
+	t.Cleanup(func() {
+		stubDBs.Delete(name)
+	})
+
+	sqlDB, err := sql.Open("repositorysdk-stub", name)
+	if err != nil {
+		t.Fatalf("open stub database: %v", err)
+	}
+	if s.db, err = gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	}); err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+
+	return s
+}
+
+func (s *stubDB) record(query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, strings.TrimSpace(query))
+}
+
+func (s *stubDB) queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.log...)
+}
+
+// executed reports whether a statement starting with the prefix was run.
+func (s *stubDB) executed(prefix string) bool {
+	for _, query := range s.queries() {
+		if strings.HasPrefix(query, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type stubDriver struct{}
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	s, ok := stubDBs.Load(name)
+	if !ok {
+		return nil, errors.New("unknown stub database " + name)
+	}
+	return &stubConn{db: s.(*stubDB)}, nil
+}
+
+type stubConn struct {
+	db *stubDB
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{db: c.db, query: query}, nil
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return &stubTx{db: c.db}, nil
+}
+
+type stubTx struct {
+	db *stubDB
+}
+
+func (t *stubTx) Commit() error {
+	t.db.record("COMMIT")
+	return nil
+}
+
+func (t *stubTx) Rollback() error {
+	t.db.record("ROLLBACK")
+	return nil
+}
+
+type stubStmt struct {
+	db    *stubDB
+	query string
+}
+
+func (s *stubStmt) Close() error {
+	return nil
+}
+
+func (s *stubStmt) NumInput() int {
+	return -1
+}
+
+func (s *stubStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stubStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	s.db.record(s.query)
+	columns, rows := s.db.result(s.query)
+	return &stubRows{columns: columns, rows: rows}, nil
+}
+
+type stubRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string {
+	return r.columns
+}
+
+func (r *stubRows) Close() error {
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
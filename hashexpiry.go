@@ -0,0 +1,36 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// WithHashFieldExpiry makes SaveHashCache and SaveAllHashCache set the ttl on the saved fields by using the command
+// `HEXPIRE` of redis 7.4+, instead of on the whole hash. The other fields of the hash keep their own expiration time,
+// e.g. a hash of sessions whose fields expire one by one. Against an older redis these methods fail.
+func WithHashFieldExpiry() RedisOption {
+	return func(o *redisOptions) {
+		o.hashFieldExpiry = true
+	}
+}
+
+// expireHash queues the expiration of the hash, or of its fields with WithHashFieldExpiry, a ttl of 0 or less sets
+// no expiration time.
+func (r *redisRepository) expireHash(ctx context.Context, pipe redis.Pipeliner, key string, ttl int, fields ...string) {
+	if ttl <= 0 {
+		return
+	}
+
+	if !r.hashFieldExpiry {
+		pipe.Expire(ctx, key, time.Duration(ttl)*time.Second)
+		return
+	}
+
+	args := make([]interface{}, 0, len(fields)+5)
+	args = append(args, "HEXPIRE", key, ttl, "FIELDS", len(fields))
+	for _, field := range fields {
+		args = append(args, field)
+	}
+	pipe.Do(ctx, args...)
+}
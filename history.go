@@ -0,0 +1,118 @@
+package repositorysdk
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// WithHistory enables the system-versioned mode, every update or delete made by the repository archives the prior
+// version of the row into the history table of the entity. The history table is created by MigrateHistory.
+func WithHistory() GormOption {
+	return func(o *gormOptions) {
+		o.history = true
+	}
+}
+
+// MigrateHistory creates the history table `<table>_history` of each entity, it holds the columns of the entity plus
+// `valid_from` and `valid_to`.
+//
+// Parameters:
+// - db: the GORM database connection.
+// - entities: the system-versioned entities.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func MigrateHistory(db *gorm.DB, entities ...Entity) error {
+	for _, entity := range entities {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(entity); err != nil {
+			return err
+		}
+
+		table := tableName(stmt)
+		history := stmt.Quote(historyTable(table))
+
+		if err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE %s INCLUDING DEFAULTS)", history, stmt.Quote(table))).Error; err != nil {
+			return err
+		}
+
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS valid_from timestamp, ADD COLUMN IF NOT EXISTS valid_to timestamp", history)).Error; err != nil {
+			return err
+		}
+
+		if err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (id, valid_from, valid_to)", stmt.Quote("idx_"+historyTable(table)+"_validity"), history)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindAsOf finds the version of the entity with the given id which was valid at the given time.
+// It returns gorm.ErrRecordNotFound if the entity did not exist at that time.
+func (r *gormRepository[T]) FindAsOf(id string, at time.Time, entity T) (err error) {
+	defer wrapError(&err, "FindAsOf", entityTypeName[T](), id, time.Now())
+
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(entity); err != nil {
+		return err
+	}
+
+	err = r.db.
+		Table(historyTable(tableName(stmt))).
+		Where("id = ? AND valid_from <= ? AND valid_to > ?", id, at, at).
+		Order("valid_from DESC").
+		Take(entity).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.db.
+			Where("id = ? AND updated_at <= ?", id, at).
+			Take(entity).
+			Error
+	}
+	if err != nil {
+		return err
+	}
+
+	// the version is authorized once loaded, as FindOne does
+	if err := r.authorize(OperationFindOne, entity); err != nil {
+		resetEntity(entity)
+		return err
+	}
+
+	return nil
+}
+
+// archiveVersion copies the current version of the rows with the given ids into the history table, the version is
+// valid from its last update until now. The rows are locked until the end of the transaction, so a concurrent update
+// waits and archives the version written by this one rather than the same version twice.
+func archiveVersion(db *gorm.DB, entity interface{}, ids ...string) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entity); err != nil {
+		return err
+	}
+
+	columns := make([]string, 0, len(stmt.Schema.DBNames))
+	for _, name := range stmt.Schema.DBNames {
+		columns = append(columns, stmt.Quote(name))
+	}
+	list := strings.Join(columns, ", ")
+
+	validFrom := "NULL"
+	if _, ok := stmt.Schema.FieldsByDBName["updated_at"]; ok {
+		validFrom = stmt.Quote("updated_at")
+	}
+
+	return db.Exec(
+		fmt.Sprintf("INSERT INTO %s (%s, valid_from, valid_to) SELECT %s, %s, ? FROM %s WHERE id IN ? FOR UPDATE",
+			stmt.Quote(historyTable(tableName(stmt))), list, list, validFrom, stmt.Quote(tableName(stmt))),
+		time.Now(), ids,
+	).Error
+}
+
+func historyTable(table string) string {
+	return table + "_history"
+}
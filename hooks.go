@@ -0,0 +1,93 @@
+package repositorysdk
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// HookEvent is the point of the lifecycle of an entity at which a hook runs.
+type HookEvent string
+
+const (
+	HookBeforeCreate HookEvent = "before_create"
+	HookAfterCreate  HookEvent = "after_create"
+	HookBeforeUpdate HookEvent = "before_update"
+	HookAfterUpdate  HookEvent = "after_update"
+	HookBeforeDelete HookEvent = "before_delete"
+	HookAfterDelete  HookEvent = "after_delete"
+)
+
+// HookRegistry holds the lifecycle hooks of the entities by entity type, the hooks are run by the gorm repositories
+// created with WithHooks.
+type HookRegistry struct {
+	mu    sync.RWMutex
+	hooks map[hookKey][]func(ctx context.Context, entity interface{}) error
+}
+
+// hookKey is keyed by the type of the entity rather than by its name, the entities of different packages may share a
+// name.
+type hookKey struct {
+	entity reflect.Type
+	event  HookEvent
+}
+
+// NewHookRegistry function that create a new instance of HookRegistry
+func NewHookRegistry() *HookRegistry {
+	return &HookRegistry{
+		hooks: map[hookKey][]func(ctx context.Context, entity interface{}) error{},
+	}
+}
+
+// RegisterHook attaches the hook to the event of the entity type T, the hooks of an event run in the order of
+// registration. A non-nil error of a before hook aborts the write, the error of an after hook is returned by the
+// repository once the entity is written.
+//
+// Parameters:
+// - registry: the hook registry.
+// - event: the lifecycle event.
+// - hook: the hook, it receives the context of the repository and the entity.
+func RegisterHook[T Entity](registry *HookRegistry, event HookEvent, hook func(ctx context.Context, entity T) error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	key := hookKey{entity: reflect.TypeOf((*T)(nil)).Elem(), event: event}
+	registry.hooks[key] = append(registry.hooks[key], func(ctx context.Context, entity interface{}) error {
+		v, ok := entity.(T)
+		if !ok {
+			return nil
+		}
+		return hook(ctx, v)
+	})
+}
+
+// WithHooks enables running the lifecycle hooks of the registry on Create, Update, and Delete.
+func WithHooks(registry *HookRegistry) GormOption {
+	return func(o *gormOptions) {
+		o.hooks = registry
+	}
+}
+
+// run runs the hooks of the event of the entity type in order, it stops at the first error.
+func (h *HookRegistry) run(ctx context.Context, entity reflect.Type, event HookEvent, value interface{}) error {
+	h.mu.RLock()
+	hooks := h.hooks[hookKey{entity: entity, event: event}]
+	h.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runHooks runs the hooks of the event on the entity, if the repository has a hook registry.
+func (r *gormRepository[T]) runHooks(event HookEvent, entity T) error {
+	if r.hooks == nil {
+		return nil
+	}
+
+	return r.hooks.run(r.context(), reflect.TypeOf((*T)(nil)).Elem(), event, entity)
+}
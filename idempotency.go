@@ -0,0 +1,139 @@
+package repositorysdk
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// The record of an idempotency key is the state of its request followed by the response once it is completed.
+const (
+	idempotencyPending   = "p"
+	idempotencyCompleted = "c"
+)
+
+// abortIdempotencyScript removes the record KEYS[1] only if its request is still pending, so a completed response is
+// kept.
+var abortIdempotencyScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+
+return 0
+`)
+
+// IdempotencyConfig is a struct that holds the settings of an idempotency store.
+type IdempotencyConfig struct {
+	// PendingTTL is the time a request is considered in progress before it can be started again, e.g. when its
+	// instance crashed, 0 means 1 minute.
+	PendingTTL time.Duration
+}
+
+type IdempotencyStore interface {
+	Begin(key string) (bool, []byte, error)
+	Complete(key string, response []byte, ttl time.Duration) error
+	Abort(key string) error
+}
+
+type idempotencyStore struct {
+	repo RedisRepository
+	conf IdempotencyConfig
+}
+
+// NewIdempotencyStore function that create a new instance of IdempotencyStore which runs a request once per
+// idempotency key, e.g. for the payments and the webhooks, and gives back the response of the first request to its
+// retries.
+func NewIdempotencyStore(repo RedisRepository, conf IdempotencyConfig) IdempotencyStore {
+	if conf.PendingTTL <= 0 {
+		conf.PendingTTL = time.Minute
+	}
+
+	return &idempotencyStore{repo: repo, conf: conf}
+}
+
+func (s *idempotencyStore) key(key string) string {
+	return redisKey(s.repo, IdempotencyKeyPrefix+key)
+}
+
+// Begin starts the request of an idempotency key by using the command `SET` with the option `NX`, the request must
+// then be completed with its response, or aborted when it fails so it can be retried.
+//
+// Parameters:
+// - key: the idempotency key, e.g. the Idempotency-Key header.
+//
+// Returns:
+// - bool: true if the request is the first one of the key and must be processed.
+// - []byte: the response of the first request if it is completed.
+// - error: ErrRequestInProgress if the first request is still being processed, otherwise an error if something goes
+// wrong.
+func (s *idempotencyStore) Begin(key string) (firstTime bool, cachedResponse []byte, err error) {
+	defer wrapError(&err, "Begin", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := s.repo.GetUniversalClient()
+	for {
+		started, err := client.SetNX(ctx, s.key(key), idempotencyPending, s.conf.PendingTTL).Result()
+		if err != nil {
+			return false, nil, err
+		}
+		if started {
+			return true, nil, nil
+		}
+
+		record, err := client.Get(ctx, s.key(key)).Bytes()
+		if err == redis.Nil {
+			// expired in between, start again
+			continue
+		}
+		if err != nil {
+			return false, nil, err
+		}
+
+		if bytes.HasPrefix(record, []byte(idempotencyCompleted)) {
+			return false, record[len(idempotencyCompleted):], nil
+		}
+
+		return false, nil, ErrRequestInProgress
+	}
+}
+
+// Complete saves the response of the request of an idempotency key, it is given back to the later requests of the key
+// until it expires.
+//
+// Parameters:
+// - key: the idempotency key.
+// - response: the response of the request.
+// - ttl: the time the response is kept, 0 means no expiration time.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (s *idempotencyStore) Complete(key string, response []byte, ttl time.Duration) (err error) {
+	defer wrapError(&err, "Complete", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	record := append([]byte(idempotencyCompleted), response...)
+
+	return s.repo.GetUniversalClient().Set(ctx, s.key(key), record, ttl).Err()
+}
+
+// Abort releases the request of an idempotency key which failed, so it can be retried right away. A completed request
+// is left as it is.
+//
+// Parameters:
+// - key: the idempotency key.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (s *idempotencyStore) Abort(key string) (err error) {
+	defer wrapError(&err, "Abort", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return abortIdempotencyScript.Run(ctx, s.repo.GetUniversalClient(), []string{s.key(key)}, idempotencyPending).Err()
+}
@@ -0,0 +1,175 @@
+package repositorysdk
+
+import (
+	"container/heap"
+	"context"
+	"github.com/go-redis/redis/v8"
+	"sort"
+	"time"
+)
+
+// KeyMemory is a struct that holds the memory usage of a key.
+type KeyMemory struct {
+	Key   string
+	Bytes int64
+}
+
+// KeyMemoryUsage retrieves the memory used by a key and its value by using the command `MEMORY USAGE`, the size of the
+// aggregate values is estimated from a sample of their elements.
+//
+// Parameters:
+// - key: the key.
+//
+// Returns:
+// - int64: the memory usage in bytes.
+// - error: ErrKeyNotFound if the key does not exist, otherwise an error if something goes wrong.
+func (r *redisRepository) KeyMemoryUsage(key string) (bytes int64, err error) {
+	defer r.observe(&err, "KeyMemoryUsage", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.MemoryUsage(ctx, key).Result()
+}
+
+// KeyType retrieves the type of the value of a key by using the command `TYPE`, e.g. "string", "hash", or "zset".
+//
+// Parameters:
+// - key: the key.
+//
+// Returns:
+// - string: the type of the value.
+// - error: ErrKeyNotFound if the key does not exist, otherwise an error if something goes wrong.
+func (r *redisRepository) KeyType(key string) (typ string, err error) {
+	defer r.observe(&err, "KeyType", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	typ, err = r.client.Type(ctx, key).Result()
+	if err == nil && typ == "none" {
+		return "", redis.Nil
+	}
+
+	return typ, err
+}
+
+// ObjectIdleTime retrieves the time since a key was last read or written by using the command `OBJECT IDLETIME`, e.g.
+// to find the caches which are never read. It fails when the eviction policy of redis is an LFU policy.
+//
+// Parameters:
+// - key: the key.
+//
+// Returns:
+// - time.Duration: the idle time, in seconds.
+// - error: ErrKeyNotFound if the key does not exist, otherwise an error if something goes wrong.
+func (r *redisRepository) ObjectIdleTime(key string) (idle time.Duration, err error) {
+	defer r.observe(&err, "ObjectIdleTime", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.ObjectIdleTime(ctx, key).Result()
+}
+
+// TopKeysByMemory finds the n keys matching the pattern which use the most memory by using the commands `SCAN` and
+// `MEMORY USAGE`, e.g. to find the bloated caches. Every matching key is measured, in pipelines of ScanBatchSize
+// keys, so the report lasts as long as the keyspace is large and is not bounded by a timeout. On a cluster the keys of
+// every master are measured.
+//
+// Parameters:
+// - pattern: the glob-style pattern of the keys, e.g. "user:*".
+// - n: the number of keys reported.
+//
+// Returns:
+// - []KeyMemory: the keys and their memory usage, the largest first.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) TopKeysByMemory(pattern string, n int) (top []KeyMemory, err error) {
+	defer r.observe(&err, "TopKeysByMemory", "", pattern, time.Now())
+
+	if n <= 0 {
+		return []KeyMemory{}, nil
+	}
+
+	largest := &keyMemoryHeap{}
+	measure := func(ctx context.Context, node redis.UniversalClient, keys []string) error {
+		if len(keys) == 0 {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		cmds := make([]*redis.IntCmd, len(keys))
+		if _, err := node.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.MemoryUsage(ctx, key)
+			}
+			return nil
+		}); err != nil && err != redis.Nil {
+			return err
+		}
+
+		for i, cmd := range cmds {
+			// the keys removed since the scan are skipped
+			if cmd.Err() != nil {
+				continue
+			}
+
+			heap.Push(largest, KeyMemory{Key: keys[i], Bytes: cmd.Val()})
+			if largest.Len() > n {
+				heap.Pop(largest)
+			}
+		}
+		return nil
+	}
+
+	if err := forEachRedisNode(r.context(), r.client, func(ctx context.Context, node redis.UniversalClient) error {
+		batch := make([]string, 0, ScanBatchSize)
+
+		iter := node.Scan(ctx, 0, pattern, ScanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) < ScanBatchSize {
+				continue
+			}
+
+			if err := measure(ctx, node, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+
+		return measure(ctx, node, batch)
+	}); err != nil {
+		return nil, err
+	}
+
+	top = append([]KeyMemory{}, *largest...)
+	sort.Slice(top, func(i, j int) bool {
+		return top[i].Bytes > top[j].Bytes
+	})
+
+	return top, nil
+}
+
+// keyMemoryHeap is a min-heap of the memory usages, it holds the largest keys seen so far.
+type keyMemoryHeap []KeyMemory
+
+func (h keyMemoryHeap) Len() int           { return len(h) }
+func (h keyMemoryHeap) Less(i, j int) bool { return h[i].Bytes < h[j].Bytes }
+func (h keyMemoryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *keyMemoryHeap) Push(x interface{}) {
+	*h = append(*h, x.(KeyMemory))
+}
+
+func (h *keyMemoryHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,60 @@
+package repositorysdk
+
+import (
+	"math"
+	"math/rand"
+)
+
+// WithTTLJitter spreads the expiration times of the keys written together, so the caches filled at once, e.g. after a
+// deploy or a flush, do not expire at once and stampede the database. Every ttl written by SaveCache, SaveCacheNX,
+// SaveCacheXX, SaveHashCache, SaveAllHashCache, SaveMultiCache, GetOrSetCache, AddSetMember, AddUnique, SetBit,
+// SaveVersionedCache, and PushList is moved by a random amount of up to ±fraction of it, e.g. 0.1 turns a ttl of 600
+// seconds into a ttl between 540 and 660 seconds. The ttl given to SetExpire, the windows of the counters, and the
+// keys kept without expiration are left as they are.
+//
+// Parameters:
+// - fraction: the maximum share of the ttl added or removed, between 0 and 1, 0 disables the jitter.
+func WithTTLJitter(fraction float64) RedisOption {
+	return func(o *redisOptions) {
+		o.ttlJitter = math.Min(math.Max(fraction, 0), 1)
+	}
+}
+
+// jitterTTL returns the ttl in seconds moved by the jitter of the repository, it is never below 1 second. A ttl of 0
+// or less, e.g. RedisKeepTTL, is returned as it is.
+func (r *redisRepository) jitterTTL(ttl int) int {
+	if r.ttlJitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+
+	jittered := ttl + int(math.Round(float64(ttl)*r.ttlJitter*(2*rand.Float64()-1)))
+	if jittered < 1 {
+		return 1
+	}
+
+	return jittered
+}
+
+// maxTTLJitter returns the maximum share of a ttl added or removed by the jitter.
+func (r *redisRepository) maxTTLJitter() float64 {
+	return r.ttlJitter
+}
+
+// ttlJitterOf returns the ttl jitter of the repository, the jitter of the wrapped repository for the wrappers.
+func ttlJitterOf(repo RedisRepository) float64 {
+	if j, ok := repo.(interface{ maxTTLJitter() float64 }); ok {
+		return j.maxTTLJitter()
+	}
+
+	return 0
+}
+
+// maxJitteredTTL returns the longest ttl in seconds the jitter of the repository can give to the ttl, e.g. for the
+// keys which must outlive the caches they track.
+func maxJitteredTTL(repo RedisRepository, ttl int) int {
+	if ttl <= 0 {
+		return ttl
+	}
+
+	return ttl + int(math.Ceil(float64(ttl)*ttlJitterOf(repo)))
+}
@@ -0,0 +1,160 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type JSONRepository interface {
+	JSONSet(key string, path string, value interface{}, ttl int) error
+	JSONGet(key string, path string, dest interface{}) error
+	JSONIncrementNumber(key string, path string, by float64) (float64, error)
+	JSONDelete(key string, path string) (int64, error)
+}
+
+type jsonRepository struct {
+	repo RedisRepository
+}
+
+// NewJSONRepository function that create a new instance of JSONRepository backed by the module RedisJSON, which reads
+// and updates a single field of a cached document without loading the whole document. The module is looked up once,
+// so the service can fall back to SaveCache and GetCache when it is not loaded.
+//
+// The documents are always encoded in json, whatever the codec of repo, and the paths follow the JSONPath syntax of
+// RedisJSON, e.g. `$` for the whole document and `$.address.city` for a field.
+//
+// Parameters:
+// - repo: the redis repository, its key prefix applies to the documents.
+//
+// Returns:
+// - JSONRepository: the json documents.
+// - error: ErrModuleUnavailable if RedisJSON is not loaded, otherwise an error if something goes wrong.
+func NewJSONRepository(repo RedisRepository) (JSONRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := requireRedisCommand(ctx, repo, "JSON.SET"); err != nil {
+		return nil, err
+	}
+
+	return &jsonRepository{repo: repo}, nil
+}
+
+// JSONSet sets the value at the path of a document by using the command `JSON.SET`, the document must exist unless
+// the path is the root `$`.
+//
+// Parameters:
+// - key: the document key.
+// - path: the path of the value.
+// - value: the value, encoded in json.
+// - ttl: the expiration time for the document in seconds, 0 keeps the current expiration time.
+//
+// Returns:
+// - error: ErrKeyNotFound if the parent of the path does not exist, otherwise an error if something goes wrong.
+func (r *jsonRepository) JSONSet(key string, path string, value interface{}, ttl int) (err error) {
+	defer wrapError(&err, "JSONSet", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	k := redisKey(r.repo, key)
+	if err := moduleError(r.repo.GetUniversalClient().Do(ctx, "JSON.SET", k, path, string(v)).Err()); err != nil {
+		return err
+	}
+
+	if ttl > 0 {
+		return r.repo.GetUniversalClient().Expire(ctx, k, time.Duration(ttl)*time.Second).Err()
+	}
+
+	return nil
+}
+
+// JSONGet retrieves the value at the path of a document by using the command `JSON.GET`. A JSONPath starting with `$`
+// matches a list of values, so dest must be a slice, e.g. *[]string for `$.tags[*]`.
+//
+// Parameters:
+// - key: the document key.
+// - path: the path of the value.
+// - dest: a pointer to the destination of the value.
+//
+// Returns:
+// - error: ErrKeyNotFound if the document does not exist, otherwise an error if something goes wrong.
+func (r *jsonRepository) JSONGet(key string, path string, dest interface{}) (err error) {
+	defer wrapError(&err, "JSONGet", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	v, err := r.repo.GetUniversalClient().Do(ctx, "JSON.GET", redisKey(r.repo, key), path).Text()
+	if err != nil {
+		return moduleError(err)
+	}
+
+	return json.Unmarshal([]byte(v), dest)
+}
+
+// JSONIncrementNumber atomically increments the number at the path of a document by using the command
+// `JSON.NUMINCRBY`.
+//
+// Parameters:
+// - key: the document key.
+// - path: the path of the number, it must match a single value.
+// - by: the increment, negative to decrement.
+//
+// Returns:
+// - float64: the value of the number after the increment.
+// - error: ErrKeyNotFound if the path matches no number, otherwise an error if something goes wrong.
+func (r *jsonRepository) JSONIncrementNumber(key string, path string, by float64) (value float64, err error) {
+	defer wrapError(&err, "JSONIncrementNumber", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := r.repo.GetUniversalClient().Do(ctx, "JSON.NUMINCRBY", redisKey(r.repo, key), path, by).Text()
+	if err != nil {
+		return 0, moduleError(err)
+	}
+
+	// a JSONPath replies a list with null for the values which are not numbers
+	if strings.HasPrefix(res, "[") {
+		var values []*float64
+		if err := json.Unmarshal([]byte(res), &values); err != nil {
+			return 0, err
+		}
+		if len(values) == 0 || values[0] == nil {
+			return 0, redis.Nil
+		}
+		return *values[0], nil
+	}
+
+	return strconv.ParseFloat(res, 64)
+}
+
+// JSONDelete removes the values at the path of a document by using the command `JSON.DEL`, the root path `$`
+// removes the document.
+//
+// Parameters:
+// - key: the document key.
+// - path: the path of the values.
+//
+// Returns:
+// - int64: the number of values removed.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *jsonRepository) JSONDelete(key string, path string) (removed int64, err error) {
+	defer wrapError(&err, "JSONDelete", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	removed, err = r.repo.GetUniversalClient().Do(ctx, "JSON.DEL", redisKey(r.repo, key), path).Int64()
+	return removed, moduleError(err)
+}
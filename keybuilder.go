@@ -0,0 +1,138 @@
+package repositorysdk
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeyBuilderConfig is a struct that holds the settings of a key builder.
+type KeyBuilderConfig struct {
+	// Prefix is the first part of every key, e.g. the name of the service. An empty prefix adds no part.
+	Prefix string
+	// Separator is the separator of the parts, an empty separator means ":".
+	Separator string
+	// MaxLength is the length above which the end of a key is replaced by its hash, so the key keeps its readable head
+	// for the patterns of SCAN. 0 means the keys are never hashed, otherwise it must exceed 41 plus the separator.
+	MaxLength int
+}
+
+type KeyBuilder interface {
+	Key(parts ...interface{}) (string, error)
+	MustKey(parts ...interface{}) string
+}
+
+type keyBuilder struct {
+	conf KeyBuilderConfig
+}
+
+// NewKeyBuilder function that create a new instance of KeyBuilder which joins the parts of the cache keys in a single
+// format shared by the services, e.g. Key("user", 123, "profile") is "user:123:profile".
+func NewKeyBuilder(conf KeyBuilderConfig) KeyBuilder {
+	if conf.Separator == "" {
+		conf.Separator = ":"
+	}
+
+	return &keyBuilder{conf: conf}
+}
+
+// Key joins the parts into a key. A part is a string, an integer, or a fmt.Stringer such as uuid.UUID, and it must not
+// be empty nor contain a space, a control character, or the separator, so two keys built from different parts never
+// collide.
+//
+// Parameters:
+// - parts: the parts of the key.
+//
+// Returns:
+// - string: the key, hashed if it is longer than MaxLength.
+// - error: ErrInvalidKey if a part is not valid, otherwise nil.
+func (b *keyBuilder) Key(parts ...interface{}) (string, error) {
+	if len(parts) == 0 {
+		return "", fmt.Errorf("%w: no part", ErrInvalidKey)
+	}
+
+	formatted := make([]string, 0, len(parts)+1)
+	if b.conf.Prefix != "" {
+		formatted = append(formatted, b.conf.Prefix)
+	}
+
+	for i, part := range parts {
+		s, err := b.part(part)
+		if err != nil {
+			return "", fmt.Errorf("%w: part %d: %s", ErrInvalidKey, i, err.Error())
+		}
+		formatted = append(formatted, s)
+	}
+
+	return b.shorten(strings.Join(formatted, b.conf.Separator)), nil
+}
+
+// MustKey is Key for the parts which are known to be valid, e.g. constants, it panics if a part is not valid.
+func (b *keyBuilder) MustKey(parts ...interface{}) string {
+	key, err := b.Key(parts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
+
+func (b *keyBuilder) part(part interface{}) (string, error) {
+	var s string
+	switch v := part.(type) {
+	case string:
+		s = v
+	case int:
+		s = strconv.Itoa(v)
+	case int32:
+		s = strconv.FormatInt(int64(v), 10)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case uint:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		s = strconv.FormatUint(v, 10)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return "", fmt.Errorf("unsupported type %T", part)
+	}
+
+	if s == "" {
+		return "", fmt.Errorf("empty")
+	}
+	if strings.Contains(s, b.conf.Separator) {
+		return "", fmt.Errorf("%q contains the separator", s)
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return "", fmt.Errorf("%q contains a space or a control character", s)
+	}
+
+	return s, nil
+}
+
+// shorten replaces the end of a key longer than MaxLength by the sha1 of the whole key.
+func (b *keyBuilder) shorten(key string) string {
+	if b.conf.MaxLength <= 0 || len(key) <= b.conf.MaxLength {
+		return key
+	}
+
+	sum := sha1.Sum([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+
+	head := b.conf.MaxLength - len(b.conf.Separator) - len(hash)
+	if head < 0 {
+		head = 0
+	}
+	for head > 0 && !utf8.RuneStart(key[head]) {
+		head--
+	}
+
+	return key[:head] + b.conf.Separator + hash
+}
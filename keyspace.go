@@ -0,0 +1,88 @@
+package repositorysdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OnKeyExpired calls the handler with every key matching the pattern which expires, until ctx is done. It enables the
+// keyspace notifications of the expirations, by using the command `CONFIG SET notify-keyspace-events`, and subscribes
+// to them by using the command `PSUBSCRIBE`, e.g. to clean up the data of a session when its key expires.
+//
+// The notification is sent when redis removes the key, which may be some time after its ttl lapsed, and it is lost if
+// no subscriber is connected. Every instance of a service receives it, so the handler must be idempotent or guarded by
+// a lock. On the servers where `CONFIG` is disabled, the notifications must be enabled in the server config with the
+// flags `Kx`. On a cluster the notifications are only published by the node of the key, so a single node is heard.
+//
+// Parameters:
+// - ctx: the context which stops the subscription.
+// - repo: the redis repository, the pattern and the keys are relative to its key prefix.
+// - pattern: the glob-style pattern of the keys, e.g. "session:*".
+// - handler: the function which handles the expired key.
+//
+// Returns:
+// - error: the error of ctx when it is done, or an error if the subscription fails.
+func OnKeyExpired(ctx context.Context, repo RedisRepository, pattern string, handler func(key string)) (err error) {
+	defer wrapError(&err, "OnKeyExpired", "", pattern, time.Now())
+
+	client := repo.GetUniversalClient()
+	if err := enableKeyspaceEvents(ctx, repo, "Kx"); err != nil {
+		return err
+	}
+
+	prefix := redisKey(repo, "")
+	channel := fmt.Sprintf("__keyspace@%d__:", redisDB(client))
+
+	pubsub := client.PSubscribe(ctx, channel+escapePattern(prefix)+pattern)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			if message.Payload == "expired" {
+				handler(strings.TrimPrefix(strings.TrimPrefix(message.Channel, channel), prefix))
+			}
+		}
+	}
+}
+
+// enableKeyspaceEvents adds the flags to the keyspace notifications of the server, keeping the flags already set. It
+// does nothing if the server does not allow `CONFIG`.
+func enableKeyspaceEvents(ctx context.Context, repo RedisRepository, flags string) error {
+	client := repo.GetUniversalClient()
+
+	cfg, err := client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil || len(cfg) < 2 {
+		// e.g. the managed servers, where the notifications are set in the server config
+		return nil
+	}
+
+	current, _ := cfg[1].(string)
+	enabled := current
+	for _, flag := range flags {
+		// A is the alias of all the event types, which include x
+		if strings.ContainsRune(enabled, flag) || flag != 'K' && flag != 'E' && strings.ContainsRune(enabled, 'A') {
+			continue
+		}
+		enabled += string(flag)
+	}
+
+	if enabled == current {
+		return nil
+	}
+
+	return client.ConfigSet(ctx, "notify-keyspace-events", enabled).Err()
+}
@@ -0,0 +1,254 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// LeaderboardConfig is a struct that holds the settings of a leaderboard.
+type LeaderboardConfig struct {
+	// Period is the period after which the leaderboard starts over empty, e.g. 24 hours for a daily leaderboard. The
+	// periods are aligned on the UTC time, a day starts at midnight and a week on monday. 0 means the leaderboard is
+	// never reset.
+	Period time.Duration
+	// KeepBest keeps the best score submitted by a member, otherwise a submitted score replaces the previous one.
+	KeepBest bool
+}
+
+// LeaderboardEntry is a struct that holds the score and the rank of a member of a leaderboard.
+type LeaderboardEntry struct {
+	Member string
+	Score  float64
+	// Rank is the rank of the member, 1 being the highest score.
+	Rank int64
+}
+
+type Leaderboard interface {
+	Submit(member string, score float64) error
+	Increment(member string, by float64) (float64, error)
+	Top(n int64) ([]LeaderboardEntry, error)
+	Rank(member string) (LeaderboardEntry, error)
+	Around(member string, radius int64) ([]LeaderboardEntry, error)
+	Len() (int64, error)
+	Reset() error
+}
+
+type leaderboard struct {
+	repo RedisRepository
+	key  string
+	conf LeaderboardConfig
+}
+
+// NewLeaderboard function that create a new instance of Leaderboard which ranks the members by their score, the
+// highest first, backed by a sorted set of redis. With a period, the scores are held in a sorted set per period which
+// expires at the end of the period, so the leaderboard is reset without a scheduled job.
+func NewLeaderboard(repo RedisRepository, name string, conf LeaderboardConfig) Leaderboard {
+	return &leaderboard{
+		repo: repo,
+		key:  LeaderboardKeyPrefix + "{" + name + "}",
+		conf: conf,
+	}
+}
+
+// current returns the key of the sorted set of the current period, and the end of the period, zero without period.
+func (l *leaderboard) current() (string, time.Time) {
+	key := redisKey(l.repo, l.key)
+	if l.conf.Period <= 0 {
+		return key, time.Time{}
+	}
+
+	start := time.Now().UTC().Truncate(l.conf.Period)
+
+	return key + ":" + strconv.FormatInt(start.Unix(), 10), start.Add(l.conf.Period)
+}
+
+// Submit saves the score of a member by using the command `ZADD`, with the option `GT` of redis 6.2+ when the best
+// score is kept.
+//
+// Parameters:
+// - member: the member, e.g. the id of a player.
+// - score: the score of the member.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (l *leaderboard) Submit(member string, score float64) (err error) {
+	defer wrapError(&err, "Submit", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, end := l.current()
+	_, err = l.repo.GetUniversalClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAddArgs(ctx, key, redis.ZAddArgs{GT: l.conf.KeepBest, Members: []redis.Z{{Score: score, Member: member}}})
+		if !end.IsZero() {
+			pipe.ExpireAt(ctx, key, end)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Increment adds to the score of a member by using the command `ZINCRBY`, a missing member starts from 0.
+//
+// Parameters:
+// - member: the member.
+// - by: the increment, negative to decrement.
+//
+// Returns:
+// - float64: the score of the member after the increment.
+// - error: an error if something goes wrong, otherwise nil.
+func (l *leaderboard) Increment(member string, by float64) (score float64, err error) {
+	defer wrapError(&err, "Increment", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, end := l.current()
+
+	var incr *redis.FloatCmd
+	if _, err := l.repo.GetUniversalClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.ZIncrBy(ctx, key, by, member)
+		if !end.IsZero() {
+			pipe.ExpireAt(ctx, key, end)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// Top retrieves the n members with the highest scores by using the command `ZREVRANGE`, the members of the same score
+// are ranked in reverse lexicographical order.
+//
+// Parameters:
+// - n: the number of members.
+//
+// Returns:
+// - []LeaderboardEntry: the members, the highest score first.
+// - error: an error if something goes wrong, otherwise nil.
+func (l *leaderboard) Top(n int64) (entries []LeaderboardEntry, err error) {
+	defer wrapError(&err, "Top", "", l.key, time.Now())
+
+	if n <= 0 {
+		return []LeaderboardEntry{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, _ := l.current()
+
+	return l.rangeEntries(ctx, key, 0, n-1)
+}
+
+// Rank retrieves the score and the rank of a member by using the commands `ZREVRANK` and `ZSCORE`.
+//
+// Parameters:
+// - member: the member.
+//
+// Returns:
+// - LeaderboardEntry: the score and the rank of the member.
+// - error: ErrKeyNotFound if the member has no score, otherwise an error if something goes wrong.
+func (l *leaderboard) Rank(member string) (entry LeaderboardEntry, err error) {
+	defer wrapError(&err, "Rank", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, _ := l.current()
+
+	var rank *redis.IntCmd
+	var score *redis.FloatCmd
+	if _, err := l.repo.GetUniversalClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		rank = pipe.ZRevRank(ctx, key, member)
+		score = pipe.ZScore(ctx, key, member)
+		return nil
+	}); err != nil {
+		return LeaderboardEntry{}, err
+	}
+
+	return LeaderboardEntry{Member: member, Score: score.Val(), Rank: rank.Val() + 1}, nil
+}
+
+// Around retrieves the members ranked around a member by using the commands `ZREVRANK` and `ZREVRANGE`, e.g. to show
+// a player the players just above and below.
+//
+// Parameters:
+// - member: the member.
+// - radius: the number of members retrieved above and below the member.
+//
+// Returns:
+// - []LeaderboardEntry: the member and the members around it, the highest score first.
+// - error: ErrKeyNotFound if the member has no score, otherwise an error if something goes wrong.
+func (l *leaderboard) Around(member string, radius int64) (entries []LeaderboardEntry, err error) {
+	defer wrapError(&err, "Around", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, _ := l.current()
+
+	rank, err := l.repo.GetUniversalClient().ZRevRank(ctx, key, member).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	start := rank - radius
+	if start < 0 {
+		start = 0
+	}
+
+	return l.rangeEntries(ctx, key, start, rank+radius)
+}
+
+// Len returns the number of members of the leaderboard by using the command `ZCARD`.
+//
+// Returns:
+// - int64: the number of members.
+// - error: an error if something goes wrong, otherwise nil.
+func (l *leaderboard) Len() (n int64, err error) {
+	defer wrapError(&err, "Len", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, _ := l.current()
+
+	return l.repo.GetUniversalClient().ZCard(ctx, key).Result()
+}
+
+// Reset removes the scores of the current period by using the command `DEL`.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (l *leaderboard) Reset() (err error) {
+	defer wrapError(&err, "Reset", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, _ := l.current()
+
+	return l.repo.GetUniversalClient().Del(ctx, key).Err()
+}
+
+// rangeEntries retrieves the members ranked from start to stop, zero-based and inclusive.
+func (l *leaderboard) rangeEntries(ctx context.Context, key string, start int64, stop int64) ([]LeaderboardEntry, error) {
+	res, err := l.repo.GetUniversalClient().ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LeaderboardEntry, len(res))
+	for i, z := range res {
+		member, _ := z.Member.(string)
+		entries[i] = LeaderboardEntry{Member: member, Score: z.Score, Rank: start + int64(i) + 1}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,161 @@
+package repositorysdk
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type Loader[T Entity] interface {
+	Load(id string) (T, error)
+	LoadMany(ids []string) ([]T, []error)
+}
+
+type loader[T Entity] struct {
+	db       *gorm.DB
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	batch *loaderBatch[T]
+}
+
+// loaderBatch holds the ids collected during a window and the result of their query.
+type loaderBatch[T Entity] struct {
+	ids      []string
+	seen     map[string]struct{}
+	entities map[string]T
+	err      error
+	once     sync.Once
+	done     chan struct{}
+}
+
+// NewLoader function that create a new instance of Loader[T] which batches the concurrent loads of the entities
+// received within the wait window into a single `WHERE id IN (...)` query, the duplicated ids are queried once.
+// A batch is dispatched early when it reaches maxBatch ids, 0 means MaximumQueryEntities.
+func NewLoader[T Entity](db *gorm.DB, wait time.Duration, maxBatch int) Loader[T] {
+	if maxBatch <= 0 {
+		maxBatch = MaximumQueryEntities
+	}
+
+	return &loader[T]{
+		db:       db,
+		wait:     wait,
+		maxBatch: maxBatch,
+	}
+}
+
+// Load finds the entity with the given id, together with the other ids loaded within the same window.
+//
+// Parameters:
+// - id: the id of the entity.
+//
+// Returns:
+// - T: the entity.
+// - error: gorm.ErrRecordNotFound if no entity with the given id is found, otherwise an error if the query fails.
+func (l *loader[T]) Load(id string) (entity T, err error) {
+	defer wrapError(&err, "Load", entityTypeName[T](), id, time.Now())
+
+	batch := l.enqueue(id)
+	<-batch.done
+
+	var zero T
+	if batch.err != nil {
+		return zero, batch.err
+	}
+
+	entity, ok := batch.entities[id]
+	if !ok {
+		return zero, gorm.ErrRecordNotFound
+	}
+
+	return entity, nil
+}
+
+// LoadMany finds the entities with the given ids, the result and the error of each id are at the same index as the id.
+func (l *loader[T]) LoadMany(ids []string) ([]T, []error) {
+	entities := make([]T, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			entities[i], errs[i] = l.Load(id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	return entities, errs
+}
+
+// enqueue adds the id to the current batch, a new batch is started and scheduled if there is none.
+func (l *loader[T]) enqueue(id string) *loaderBatch[T] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.batch == nil {
+		l.batch = &loaderBatch[T]{
+			seen: map[string]struct{}{},
+			done: make(chan struct{}),
+		}
+
+		batch := l.batch
+		time.AfterFunc(l.wait, func() { l.dispatch(batch) })
+	}
+
+	batch := l.batch
+	if _, ok := batch.seen[id]; !ok {
+		batch.seen[id] = struct{}{}
+		batch.ids = append(batch.ids, id)
+	}
+
+	if len(batch.ids) >= l.maxBatch {
+		l.batch = nil
+		go l.dispatch(batch)
+	}
+
+	return batch
+}
+
+// dispatch runs the query of the batch once, whichever of the timer and the size limit comes first.
+func (l *loader[T]) dispatch(batch *loaderBatch[T]) {
+	l.mu.Lock()
+	if l.batch == batch {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	batch.once.Do(func() {
+		defer close(batch.done)
+
+		var entities []T
+		if err := l.db.Where("id IN ?", batch.ids).Find(&entities).Error; err != nil {
+			batch.err = err
+			return
+		}
+
+		batch.entities = make(map[string]T, len(entities))
+		for _, entity := range entities {
+			batch.entities[entityID(entity)] = entity
+		}
+	})
+}
+
+// entityID returns the value of the ID field of the entity as string.
+func entityID(entity interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	id := reflect.Indirect(v.FieldByName("ID"))
+	if !id.IsValid() {
+		return ""
+	}
+
+	return fmt.Sprint(id.Interface())
+}
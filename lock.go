@@ -0,0 +1,121 @@
+package repositorysdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+type Lock interface {
+	Key() string
+	Release() error
+	Extend(ttl time.Duration) error
+}
+
+// releaseLockScript deletes the lock only if it is still held with the token ARGV[1].
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// extendLockScript sets the expiration time of the lock to ARGV[2] milliseconds only if it is still held with the
+// token ARGV[1].
+var extendLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type redisLock struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+}
+
+// AcquireLock acquires the lock of the key by using the command `SET NX` with a random token, the lock is released
+// automatically once the ttl elapses. The lock is released and extended only by its holder.
+//
+// Parameters:
+// - key: the lock key.
+// - ttl: the expiration time of the lock.
+//
+// Returns:
+// - Lock: the acquired lock.
+// - error: ErrLockNotAcquired if the lock is held by someone else, otherwise an error if something goes wrong.
+func (r *redisRepository) AcquireLock(key string, ttl time.Duration) (lock Lock, err error) {
+	defer wrapError(&err, "AcquireLock", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+
+	ok, err := r.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockNotAcquired
+	}
+
+	return &redisLock{client: r.client, key: key, token: token}, nil
+}
+
+// Key returns the key of the lock.
+func (l *redisLock) Key() string {
+	return l.key
+}
+
+// Release releases the lock if it is still held.
+//
+// Returns:
+// - error: ErrLockNotHeld if the lock has expired or is held by someone else, otherwise an error if something goes wrong.
+func (l *redisLock) Release() (err error) {
+	defer wrapError(&err, "Release", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := releaseLockScript.Run(ctx, l.client, []string{l.key}, l.token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Extend resets the expiration time of the lock to ttl if it is still held.
+//
+// Parameters:
+// - ttl: the new expiration time of the lock.
+//
+// Returns:
+// - error: ErrLockNotHeld if the lock has expired or is held by someone else, otherwise an error if something goes wrong.
+func (l *redisLock) Extend(ttl time.Duration) (err error) {
+	defer wrapError(&err, "Extend", "", l.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := extendLockScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
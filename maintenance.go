@@ -0,0 +1,295 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"reflect"
+)
+
+// MaintenanceConfig is a struct that holds the connections of a service used by the maintenance commands, a nil
+// connection is skipped by the commands which do not need it.
+type MaintenanceConfig struct {
+	Database   *PostgresDatabaseConfig `mapstructure:"database" json:"database"`
+	Redis      *RedisConfig            `mapstructure:"redis" json:"redis"`
+	OpenSearch *OpenSearchConfig       `mapstructure:"opensearch" json:"opensearch"`
+}
+
+// LoadMaintenanceConfig reads the connections of a service from a json file, the keys of the connections are the
+// names of the fields of their config, e.g. `{"database": {"Host": "localhost", "Port": 5432}}`.
+//
+// Parameters:
+// - path: the path of the json file.
+//
+// Returns:
+// - *MaintenanceConfig: the connections of the service.
+// - error: an error if the file cannot be read or parsed, otherwise nil.
+func LoadMaintenanceConfig(path string) (*MaintenanceConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &MaintenanceConfig{}
+	if err := json.Unmarshal(b, conf); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return conf, nil
+}
+
+// MaintenanceTool runs the operational tasks of a service with the same code paths as the service. A service builds
+// its own command by filling the entities and the seeders, see cmd/repoctl.
+type MaintenanceTool struct {
+	Config MaintenanceConfig
+	// Entities are migrated by migrate and indexed by reindex-opensearch.
+	Entities []Entity
+	// Seeders are run in order in a single transaction by seed.
+	Seeders []func(tx *gorm.DB) error
+	// Out receives the output of the commands, nil means os.Stdout.
+	Out io.Writer
+}
+
+// MaintenanceUsage is the usage of the commands of MaintenanceTool.Run.
+const MaintenanceUsage = `commands:
+  migrate                            migrate the tables of the entities
+  seed                               run the seeders in a single transaction
+  cache-flush -pattern <pattern>     remove the caches matching the pattern
+  reindex-opensearch [-batch <size>] index the rows of the entities in OpenSearch
+  health                             check the connections`
+
+// Run runs the command named by args[0] with the flags of args[1:].
+//
+// Parameters:
+// - ctx: the context of the command.
+// - args: the command and its flags.
+//
+// Returns:
+// - error: an error if the command is unknown or fails, otherwise nil.
+func (t *MaintenanceTool) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", MaintenanceUsage)
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(t.out())
+	pattern := fs.String("pattern", "", "the glob-style pattern of the caches")
+	batch := fs.Int("batch", MaximumQueryEntities, "the number of rows read per query")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "migrate":
+		return t.Migrate(ctx)
+	case "seed":
+		return t.Seed(ctx)
+	case "cache-flush":
+		if *pattern == "" {
+			return errors.New("cache-flush: missing -pattern")
+		}
+		return t.FlushCache(*pattern)
+	case "reindex-opensearch":
+		return t.ReindexOpenSearch(ctx, *batch)
+	case "health":
+		return t.Health(ctx)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], MaintenanceUsage)
+	}
+}
+
+// Migrate migrates the tables of the entities with AutoMigrate.
+func (t *MaintenanceTool) Migrate(ctx context.Context) error {
+	db, err := t.database()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	if err := AutoMigrate(db.WithContext(ctx), t.Entities...); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(t.out(), "migrated %d entities\n", len(t.Entities))
+	return nil
+}
+
+// Seed runs the seeders in order in a single transaction, the transaction is rolled back if a seeder fails.
+func (t *MaintenanceTool) Seed(ctx context.Context) error {
+	db, err := t.database()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	if err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, seeder := range t.Seeders {
+			if err := seeder(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(t.out(), "ran %d seeders\n", len(t.Seeders))
+	return nil
+}
+
+// FlushCache removes the caches matching the pattern with RemoveCacheByPattern.
+func (t *MaintenanceTool) FlushCache(pattern string) error {
+	if t.Config.Redis == nil {
+		return errors.New("missing redis config")
+	}
+
+	client, err := InitRedisConnect(t.Config.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	removed, err := NewRedisRepository(client).RemoveCacheByPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(t.out(), "removed %d caches\n", removed)
+	return nil
+}
+
+// ReindexOpenSearch indexes the existing rows of the entities with the fields tagged by `searchindex:"true"`, as
+// BackfillSearchIndex does.
+func (t *MaintenanceTool) ReindexOpenSearch(ctx context.Context, batchSize int) error {
+	if t.Config.OpenSearch == nil {
+		return errors.New("missing opensearch config")
+	}
+
+	db, err := t.database()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	client, err := InitOpenSearchClient(t.Config.OpenSearch)
+	if err != nil {
+		return err
+	}
+	indexer := NewOpenSearchIndexer(client)
+
+	for _, entity := range t.Entities {
+		if _, ok := searchDocument(entity); !ok {
+			continue
+		}
+
+		entities := reflect.New(reflect.SliceOf(reflect.TypeOf(entity))).Interface()
+		indexed, err := backfillSearchIndex(db.WithContext(ctx).Model(entity), indexer, entities, batchSize)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(t.out(), "indexed %d documents into %s\n", indexed, entity.TableName())
+	}
+
+	return nil
+}
+
+// Health checks the configured connections and reports each of them.
+//
+// Returns:
+// - error: the errors of the connections which failed, otherwise nil.
+func (t *MaintenanceTool) Health(ctx context.Context) error {
+	var errs []error
+	report := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			fmt.Fprintf(t.out(), "%s: %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(t.out(), "%s: ok\n", name)
+	}
+
+	if t.Config.Database != nil {
+		report("postgres", t.pingDatabase(ctx))
+	}
+	if t.Config.Redis != nil {
+		report("redis", t.pingRedis(ctx))
+	}
+	if t.Config.OpenSearch != nil {
+		report("opensearch", t.pingOpenSearch(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t *MaintenanceTool) pingDatabase(ctx context.Context) error {
+	db, err := t.database()
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	return sqlDB.PingContext(ctx)
+}
+
+func (t *MaintenanceTool) pingRedis(ctx context.Context) error {
+	client, err := InitRedisConnect(t.Config.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return NewRedisRepository(client).HealthCheck(ctx)
+}
+
+func (t *MaintenanceTool) pingOpenSearch(ctx context.Context) error {
+	client, err := InitOpenSearchClient(t.Config.OpenSearch)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Ping(client.Ping.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("ping: %s", res.String())
+	}
+
+	return nil
+}
+
+func (t *MaintenanceTool) database() (*gorm.DB, error) {
+	if t.Config.Database == nil {
+		return nil, errors.New("missing database config")
+	}
+
+	return InitPostgresDatabase(t.Config.Database, false)
+}
+
+// closeDatabase closes the pool of the connections opened by database.
+func closeDatabase(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
+func (t *MaintenanceTool) out() io.Writer {
+	if t.Out == nil {
+		return os.Stdout
+	}
+
+	return t.Out
+}
@@ -0,0 +1,78 @@
+package repositorysdk
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// RedisCallResult is the outcome of a call of a redis repository.
+type RedisCallResult string
+
+const (
+	// RedisCallHit is a read which found its cache.
+	RedisCallHit RedisCallResult = "hit"
+	// RedisCallMiss is a read which did not find its cache, for GetOrSetCache a call of the loader.
+	RedisCallMiss RedisCallResult = "miss"
+	// RedisCallOK is a call which is not a read and succeeded.
+	RedisCallOK RedisCallResult = "ok"
+	// RedisCallError is a call which failed.
+	RedisCallError RedisCallResult = "error"
+	// RedisCallDegraded is a call served by the fallback of a circuit breaker, see RedisCircuitBreakerConfig.
+	RedisCallDegraded RedisCallResult = "degraded"
+)
+
+// RedisMetrics records the calls of a redis repository, e.g. into Prometheus collectors. It is called synchronously
+// after every call, so it must be fast and safe for concurrent use.
+type RedisMetrics interface {
+	ObserveRedisCall(method string, result RedisCallResult, duration time.Duration)
+}
+
+// redisReadMethods are the methods whose calls are recorded as hits or misses.
+var redisReadMethods = map[string]bool{
+	"GetCache":          true,
+	"GetDelCache":       true,
+	"GetHashCache":      true,
+	"GetVersionedCache": true,
+	"GetOrSetCache":     true,
+	"PopList":           true,
+}
+
+// WithMetrics records every call of the repository with the method, the result, and the duration, the hit ratio of a
+// cache is the number of hits over the number of hits and misses of its reads.
+func WithMetrics(metrics RedisMetrics) RedisOption {
+	return func(o *redisOptions) {
+		o.metrics = metrics
+	}
+}
+
+// observe wraps the error of a call as wrapError does, and records the call.
+func (r *redisRepository) observe(err *error, op string, entity string, key string, start time.Time) {
+	wrapError(err, op, entity, key, start)
+	r.record(op, key, start, *err, false)
+}
+
+// record records a call with the metrics and the tracer of the repository, missed tells that a read found no cache
+// even though it succeeded.
+func (r *redisRepository) record(method string, key string, start time.Time, err error, missed bool) {
+	if r.metrics == nil && r.tracer == nil {
+		return
+	}
+
+	result := RedisCallOK
+	switch {
+	case errors.Is(err, redis.Nil), err == nil && missed:
+		result = RedisCallMiss
+	case err != nil:
+		result = RedisCallError
+	case redisReadMethods[method]:
+		result = RedisCallHit
+	}
+
+	if r.metrics != nil {
+		r.metrics.ObserveRedisCall(method, result, time.Since(start))
+	}
+	if r.tracer != nil {
+		r.traceCall(method, key, result, start, err)
+	}
+}
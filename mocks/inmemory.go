@@ -0,0 +1,164 @@
+package mocks
+
+import (
+	"github.com/PromptSnapshot/repositorysdk"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/go-redis/redis/v8"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// NewInMemoryRedisRepository function that create a new instance of RedisRepository backed by an in-memory redis
+// server (miniredis), so the unit tests need no redis or docker. The server is closed when the test ends.
+//
+// The caches, the hashes, the sets, the lists, the counters, the scripts, and the pub/sub, sharded or not, behave as
+// with redis. The expiration times only elapse when the clock of the server is moved by FastForward, ObjectIdleTime
+// always reports 0, and the modules, the keyspace notifications, and the memory usage of NamespaceStats are not
+// supported.
+//
+// Parameters:
+// - tb: the test, it fails if the server cannot be started.
+// - opts: the options of the repository, e.g. WithCodec.
+//
+// Returns:
+// - RedisRepository: the repository.
+// - *miniredis.Miniredis: the server, e.g. to call FastForward or to inspect the keys.
+func NewInMemoryRedisRepository(tb testing.TB, opts ...repositorysdk.RedisOption) (repositorysdk.RedisRepository, *miniredis.Miniredis) {
+	tb.Helper()
+
+	s := miniredis.RunT(tb)
+	registerMissingCommands(s)
+
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	tb.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	return repositorysdk.NewRedisRepository(client, opts...), s
+}
+
+// registerMissingCommands adds the commands used by RedisRepository which miniredis does not implement.
+func registerMissingCommands(s *miniredis.Miniredis) {
+	_ = s.Server().Register("HRANDFIELD", func(c *server.Peer, cmd string, args []string) {
+		if len(args) != 2 {
+			c.WriteError("ERR wrong number of arguments for '" + cmd + "' command")
+			return
+		}
+
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			c.WriteError("ERR value is not an integer or out of range")
+			return
+		}
+
+		fields, err := s.HKeys(args[0])
+		if err != nil && err != miniredis.ErrKeyNotFound {
+			c.WriteError(err.Error())
+			return
+		}
+
+		c.WriteStrings(randomFields(fields, n))
+	})
+
+	// SSUBSCRIBE and SPUBLISH keep their own subscribers, the sharded messages never reach the classic subscribers
+	var mu sync.Mutex
+	sharded := map[string]map[*server.Peer]struct{}{}
+	_ = s.Server().Register("SSUBSCRIBE", func(c *server.Peer, cmd string, args []string) {
+		if len(args) == 0 {
+			c.WriteError("ERR wrong number of arguments for '" + cmd + "' command")
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i, channel := range args {
+			if sharded[channel] == nil {
+				sharded[channel] = map[*server.Peer]struct{}{}
+			}
+			sharded[channel][c] = struct{}{}
+
+			c.Block(func(w *server.Writer) {
+				w.WritePushLen(3)
+				w.WriteBulk("ssubscribe")
+				w.WriteBulk(channel)
+				w.WriteInt(i + 1)
+			})
+		}
+
+		c.OnDisconnect(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, channel := range args {
+				delete(sharded[channel], c)
+			}
+		})
+	})
+
+	_ = s.Server().Register("SPUBLISH", func(c *server.Peer, cmd string, args []string) {
+		if len(args) != 2 {
+			c.WriteError("ERR wrong number of arguments for '" + cmd + "' command")
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for peer := range sharded[args[0]] {
+			peer.Block(func(w *server.Writer) {
+				w.WritePushLen(3)
+				w.WriteBulk("smessage")
+				w.WriteBulk(args[0])
+				w.WriteBulk(args[1])
+				w.Flush()
+			})
+		}
+
+		c.WriteInt(len(sharded[args[0]]))
+	})
+
+	// OBJECT IDLETIME reports every key as just accessed, miniredis does not track the accesses
+	_ = s.Server().Register("OBJECT", func(c *server.Peer, cmd string, args []string) {
+		if len(args) != 2 || !strings.EqualFold(args[0], "IDLETIME") {
+			c.WriteError("ERR unknown subcommand or wrong number of arguments for '" + cmd + "' command")
+			return
+		}
+
+		if !s.Exists(args[1]) {
+			c.WriteNull()
+			return
+		}
+		c.WriteInt(0)
+	})
+
+	// MEMORY USAGE reports no size, NamespaceStats then only counts the keys
+	_ = s.Server().Register("MEMORY", func(c *server.Peer, cmd string, args []string) {
+		c.WriteNull()
+	})
+}
+
+// randomFields picks n distinct fields, or -n fields which may repeat, as HRANDFIELD does.
+func randomFields(fields []string, n int) []string {
+	if len(fields) == 0 {
+		return []string{}
+	}
+
+	if n < 0 {
+		picked := make([]string, -n)
+		for i := range picked {
+			picked[i] = fields[rand.Intn(len(fields))]
+		}
+		return picked
+	}
+
+	rand.Shuffle(len(fields), func(i, j int) {
+		fields[i], fields[j] = fields[j], fields[i]
+	})
+	if n < len(fields) {
+		fields = fields[:n]
+	}
+
+	return fields
+}
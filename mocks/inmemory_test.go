@@ -0,0 +1,111 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"github.com/PromptSnapshot/repositorysdk"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRedisRepositoryExpiresTheCaches(t *testing.T) {
+	repo, s := NewInMemoryRedisRepository(t)
+
+	if err := repo.SaveCache("session", "value", 10); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := repo.SaveAllHashCache("user", map[string]string{"name": "ada", "role": "admin"}, 10); err != nil {
+		t.Fatalf("save hash: %v", err)
+	}
+
+	fields, err := repo.RandomHashFields("user", 1)
+	if err != nil || len(fields) != 1 {
+		t.Fatalf("random hash fields: got %v, %v", fields, err)
+	}
+	if idle, err := repo.ObjectIdleTime("session"); err != nil || idle != 0 {
+		t.Fatalf("object idle time: got %s, %v", idle, err)
+	}
+
+	s.FastForward(11 * time.Second)
+
+	var value string
+	if err := repo.GetCache("session", &value); !errors.Is(err, repositorysdk.ErrKeyNotFound) {
+		t.Errorf("get of an expired cache: got %v, want ErrKeyNotFound", err)
+	}
+	if _, err := repo.GetHashCache("user", "name"); !errors.Is(err, repositorysdk.ErrKeyNotFound) {
+		t.Errorf("get of an expired hash: got %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestInMemoryShardedPublishSkipsTheClassicSubscribers(t *testing.T) {
+	repo, _ := NewInMemoryRedisRepository(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var classic, sharded []string
+	received := func(messages *[]string, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*messages = append(*messages, string(payload))
+		return nil
+	}
+	// publish publishes until the payload is received, the subscriptions are made in the background
+	publish := func(publish func(channel string, payload interface{}) error, messages *[]string, payload string) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); {
+			if err := publish("events", payload); err != nil {
+				t.Fatalf("publish: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			n := len(*messages)
+			mu.Unlock()
+			if n > 0 {
+				return
+			}
+		}
+		t.Fatalf("%s was not received", payload)
+	}
+
+	go func() {
+		_ = repo.Subscribe(ctx, "events", func(payload []byte) error { return received(&classic, payload) })
+	}()
+	publish(repo.Publish, &classic, "ready")
+
+	go func() {
+		_ = repo.ShardedSubscribe(ctx, "events", func(payload []byte) error { return received(&sharded, payload) })
+	}()
+	publish(repo.ShardedPublish, &sharded, "sharded")
+
+	// the messages of a connection are delivered in order, so a sharded message sent to the classic subscriber
+	// would be received before this one
+	mu.Lock()
+	before := len(classic)
+	mu.Unlock()
+	if err := repo.Publish("events", "end"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		mu.Lock()
+		n := len(classic)
+		mu.Unlock()
+		if n > before {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("end was not received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, payload := range classic {
+		if payload == `"sharded"` {
+			t.Fatalf("a classic subscriber received a sharded message: %v", classic)
+		}
+	}
+}
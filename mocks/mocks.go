@@ -0,0 +1,12 @@
+// Package mocks holds a mock of every exported interface of repositorysdk, e.g. MockRedisRepository and
+// MockGormRepository, generated by cmd/mockgen. A mock holds a function field per method, and a method panics if its
+// function is not set.
+//
+//	repo := &mocks.MockRedisRepository{
+//		GetCacheFunc: func(key string, value interface{}) error {
+//			return repositorysdk.ErrCacheMiss
+//		},
+//	}
+package mocks
+
+//go:generate go run ../cmd/mockgen -source .. -output mocks_gen.go
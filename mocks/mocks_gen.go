@@ -0,0 +1,1946 @@
+// Code generated by mockgen; DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/PromptSnapshot/repositorysdk"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MockAuthorizer is a mock of repositorysdk.Authorizer, a method panics if its function is not set.
+type MockAuthorizer struct {
+	AuthorizeFunc func(ctx context.Context, op repositorysdk.Operation, entityType string, entity interface{}) error
+}
+
+var _ repositorysdk.Authorizer = (*MockAuthorizer)(nil)
+
+func (m *MockAuthorizer) Authorize(p0 context.Context, p1 repositorysdk.Operation, p2 string, p3 interface{}) error {
+	if m.AuthorizeFunc == nil {
+		panic("MockAuthorizer.Authorize is not set")
+	}
+	return m.AuthorizeFunc(p0, p1, p2, p3)
+}
+
+// MockBloomRepository is a mock of repositorysdk.BloomRepository, a method panics if its function is not set.
+type MockBloomRepository struct {
+	BloomReserveFunc func(key string, errorRate float64, capacity int64) error
+	BloomAddFunc     func(key string, item interface{}) (bool, error)
+	BloomExistsFunc  func(key string, item interface{}) (bool, error)
+}
+
+var _ repositorysdk.BloomRepository = (*MockBloomRepository)(nil)
+
+func (m *MockBloomRepository) BloomReserve(p0 string, p1 float64, p2 int64) error {
+	if m.BloomReserveFunc == nil {
+		panic("MockBloomRepository.BloomReserve is not set")
+	}
+	return m.BloomReserveFunc(p0, p1, p2)
+}
+
+func (m *MockBloomRepository) BloomAdd(p0 string, p1 interface{}) (bool, error) {
+	if m.BloomAddFunc == nil {
+		panic("MockBloomRepository.BloomAdd is not set")
+	}
+	return m.BloomAddFunc(p0, p1)
+}
+
+func (m *MockBloomRepository) BloomExists(p0 string, p1 interface{}) (bool, error) {
+	if m.BloomExistsFunc == nil {
+		panic("MockBloomRepository.BloomExists is not set")
+	}
+	return m.BloomExistsFunc(p0, p1)
+}
+
+// MockCoalescingWriter is a mock of repositorysdk.CoalescingWriter, a method panics if its function is not set.
+type MockCoalescingWriter struct {
+	SaveCacheFunc func(key string, value interface{}, ttl int) error
+	FlushFunc     func() error
+	CloseFunc     func() error
+}
+
+var _ repositorysdk.CoalescingWriter = (*MockCoalescingWriter)(nil)
+
+func (m *MockCoalescingWriter) SaveCache(p0 string, p1 interface{}, p2 int) error {
+	if m.SaveCacheFunc == nil {
+		panic("MockCoalescingWriter.SaveCache is not set")
+	}
+	return m.SaveCacheFunc(p0, p1, p2)
+}
+
+func (m *MockCoalescingWriter) Flush() error {
+	if m.FlushFunc == nil {
+		panic("MockCoalescingWriter.Flush is not set")
+	}
+	return m.FlushFunc()
+}
+
+func (m *MockCoalescingWriter) Close() error {
+	if m.CloseFunc == nil {
+		panic("MockCoalescingWriter.Close is not set")
+	}
+	return m.CloseFunc()
+}
+
+// MockCodec is a mock of repositorysdk.Codec, a method panics if its function is not set.
+type MockCodec struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+var _ repositorysdk.Codec = (*MockCodec)(nil)
+
+func (m *MockCodec) Marshal(p0 interface{}) ([]byte, error) {
+	if m.MarshalFunc == nil {
+		panic("MockCodec.Marshal is not set")
+	}
+	return m.MarshalFunc(p0)
+}
+
+func (m *MockCodec) Unmarshal(p0 []byte, p1 interface{}) error {
+	if m.UnmarshalFunc == nil {
+		panic("MockCodec.Unmarshal is not set")
+	}
+	return m.UnmarshalFunc(p0, p1)
+}
+
+// MockConfigWatcher is a mock of repositorysdk.ConfigWatcher, a method panics if its function is not set.
+type MockConfigWatcher struct {
+	OnReloadFunc func(fn func(conf *repositorysdk.TunableConfig))
+	CurrentFunc  func() *repositorysdk.TunableConfig
+	ReloadFunc   func() error
+	RunFunc      func(ctx context.Context, interval time.Duration) error
+}
+
+var _ repositorysdk.ConfigWatcher = (*MockConfigWatcher)(nil)
+
+func (m *MockConfigWatcher) OnReload(p0 func(conf *repositorysdk.TunableConfig)) {
+	if m.OnReloadFunc == nil {
+		panic("MockConfigWatcher.OnReload is not set")
+	}
+	m.OnReloadFunc(p0)
+}
+
+func (m *MockConfigWatcher) Current() *repositorysdk.TunableConfig {
+	if m.CurrentFunc == nil {
+		panic("MockConfigWatcher.Current is not set")
+	}
+	return m.CurrentFunc()
+}
+
+func (m *MockConfigWatcher) Reload() error {
+	if m.ReloadFunc == nil {
+		panic("MockConfigWatcher.Reload is not set")
+	}
+	return m.ReloadFunc()
+}
+
+func (m *MockConfigWatcher) Run(p0 context.Context, p1 time.Duration) error {
+	if m.RunFunc == nil {
+		panic("MockConfigWatcher.Run is not set")
+	}
+	return m.RunFunc(p0, p1)
+}
+
+// MockDelayedScheduler is a mock of repositorysdk.DelayedScheduler, a method panics if its function is not set.
+type MockDelayedScheduler struct {
+	ScheduleTaskFunc func(payload interface{}, at time.Time) (string, error)
+	CancelFunc       func(id string) error
+	LenFunc          func() (int64, error)
+	ConsumeDueFunc   func(ctx context.Context, handler repositorysdk.DelayedTaskHandler) error
+}
+
+var _ repositorysdk.DelayedScheduler = (*MockDelayedScheduler)(nil)
+
+func (m *MockDelayedScheduler) ScheduleTask(p0 interface{}, p1 time.Time) (string, error) {
+	if m.ScheduleTaskFunc == nil {
+		panic("MockDelayedScheduler.ScheduleTask is not set")
+	}
+	return m.ScheduleTaskFunc(p0, p1)
+}
+
+func (m *MockDelayedScheduler) Cancel(p0 string) error {
+	if m.CancelFunc == nil {
+		panic("MockDelayedScheduler.Cancel is not set")
+	}
+	return m.CancelFunc(p0)
+}
+
+func (m *MockDelayedScheduler) Len() (int64, error) {
+	if m.LenFunc == nil {
+		panic("MockDelayedScheduler.Len is not set")
+	}
+	return m.LenFunc()
+}
+
+func (m *MockDelayedScheduler) ConsumeDue(p0 context.Context, p1 repositorysdk.DelayedTaskHandler) error {
+	if m.ConsumeDueFunc == nil {
+		panic("MockDelayedScheduler.ConsumeDue is not set")
+	}
+	return m.ConsumeDueFunc(p0, p1)
+}
+
+// MockDiagnosticsReporter is a mock of repositorysdk.DiagnosticsReporter, a method panics if its function is not set.
+type MockDiagnosticsReporter struct {
+	SampleFunc    func(ctx context.Context) (*repositorysdk.DiagnosticsReport, error)
+	LatestFunc    func() *repositorysdk.DiagnosticsReport
+	RunFunc       func(ctx context.Context, interval time.Duration) error
+	ServeHTTPFunc func(w http.ResponseWriter, req *http.Request)
+}
+
+var _ repositorysdk.DiagnosticsReporter = (*MockDiagnosticsReporter)(nil)
+
+func (m *MockDiagnosticsReporter) Sample(p0 context.Context) (*repositorysdk.DiagnosticsReport, error) {
+	if m.SampleFunc == nil {
+		panic("MockDiagnosticsReporter.Sample is not set")
+	}
+	return m.SampleFunc(p0)
+}
+
+func (m *MockDiagnosticsReporter) Latest() *repositorysdk.DiagnosticsReport {
+	if m.LatestFunc == nil {
+		panic("MockDiagnosticsReporter.Latest is not set")
+	}
+	return m.LatestFunc()
+}
+
+func (m *MockDiagnosticsReporter) Run(p0 context.Context, p1 time.Duration) error {
+	if m.RunFunc == nil {
+		panic("MockDiagnosticsReporter.Run is not set")
+	}
+	return m.RunFunc(p0, p1)
+}
+
+func (m *MockDiagnosticsReporter) ServeHTTP(p0 http.ResponseWriter, p1 *http.Request) {
+	if m.ServeHTTPFunc == nil {
+		panic("MockDiagnosticsReporter.ServeHTTP is not set")
+	}
+	m.ServeHTTPFunc(p0, p1)
+}
+
+// MockDomainEventEmitter is a mock of repositorysdk.DomainEventEmitter, a method panics if its function is not set.
+type MockDomainEventEmitter struct {
+	DomainEventsFunc func() []repositorysdk.Event
+}
+
+var _ repositorysdk.DomainEventEmitter = (*MockDomainEventEmitter)(nil)
+
+func (m *MockDomainEventEmitter) DomainEvents() []repositorysdk.Event {
+	if m.DomainEventsFunc == nil {
+		panic("MockDomainEventEmitter.DomainEvents is not set")
+	}
+	return m.DomainEventsFunc()
+}
+
+// MockEntity is a mock of repositorysdk.Entity, a method panics if its function is not set.
+type MockEntity struct {
+	TableNameFunc func() string
+}
+
+var _ repositorysdk.Entity = (*MockEntity)(nil)
+
+func (m *MockEntity) TableName() string {
+	if m.TableNameFunc == nil {
+		panic("MockEntity.TableName is not set")
+	}
+	return m.TableNameFunc()
+}
+
+// MockEventPublisher is a mock of repositorysdk.EventPublisher, a method panics if its function is not set.
+type MockEventPublisher struct {
+	PublishFunc func(ctx context.Context, events ...repositorysdk.Event) error
+}
+
+var _ repositorysdk.EventPublisher = (*MockEventPublisher)(nil)
+
+func (m *MockEventPublisher) Publish(p0 context.Context, p1 ...repositorysdk.Event) error {
+	if m.PublishFunc == nil {
+		panic("MockEventPublisher.Publish is not set")
+	}
+	return m.PublishFunc(p0, p1...)
+}
+
+// MockFileRecord is a mock of repositorysdk.FileRecord, a method panics if its function is not set.
+type MockFileRecord struct {
+	MockEntity
+	FileMetadataFunc func() *repositorysdk.FileEntity
+}
+
+var _ repositorysdk.FileRecord = (*MockFileRecord)(nil)
+
+func (m *MockFileRecord) FileMetadata() *repositorysdk.FileEntity {
+	if m.FileMetadataFunc == nil {
+		panic("MockFileRecord.FileMetadata is not set")
+	}
+	return m.FileMetadataFunc()
+}
+
+// MockGormRepository is a mock of repositorysdk.GormRepository, a method panics if its function is not set.
+type MockGormRepository[T repositorysdk.Entity] struct {
+	FindAllFunc              func(metadata *repositorysdk.PaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error
+	FindAllWithRelationsFunc func(metadata *repositorysdk.PaginationMetadata, entities *[]T, relations ...string) error
+	FindAllCursorFunc        func(metadata *repositorysdk.CursorPaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error
+	FindOneFunc              func(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	CreateFunc               func(entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	CreateManyFunc           func(entities []T, batchSize int, scope ...func(db *gorm.DB) *gorm.DB) error
+	UpsertFunc               func(entity T, conflictColumns []string, updateColumns []string) error
+	UpsertManyFunc           func(entities []T, batchSize int, conflictColumns []string, updateColumns []string) error
+	UpdateFunc               func(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	DeleteFunc               func(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	CloneFunc                func(src T, overrides ...func(*T)) (T, error)
+	FindAsOfFunc             func(id string, at time.Time, entity T) error
+	WithTransactionFunc      func(fns ...func(tx *gorm.DB) error) error
+	WithContextFunc          func(ctx context.Context) repositorysdk.GormRepository[T]
+	GetDBFunc                func() *gorm.DB
+}
+
+var _ repositorysdk.GormRepository[repositorysdk.Entity] = (*MockGormRepository[repositorysdk.Entity])(nil)
+
+func (m *MockGormRepository[T]) FindAll(p0 *repositorysdk.PaginationMetadata, p1 *[]T, p2 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.FindAllFunc == nil {
+		panic("MockGormRepository.FindAll is not set")
+	}
+	return m.FindAllFunc(p0, p1, p2...)
+}
+
+func (m *MockGormRepository[T]) FindAllWithRelations(p0 *repositorysdk.PaginationMetadata, p1 *[]T, p2 ...string) error {
+	if m.FindAllWithRelationsFunc == nil {
+		panic("MockGormRepository.FindAllWithRelations is not set")
+	}
+	return m.FindAllWithRelationsFunc(p0, p1, p2...)
+}
+
+func (m *MockGormRepository[T]) FindAllCursor(p0 *repositorysdk.CursorPaginationMetadata, p1 *[]T, p2 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.FindAllCursorFunc == nil {
+		panic("MockGormRepository.FindAllCursor is not set")
+	}
+	return m.FindAllCursorFunc(p0, p1, p2...)
+}
+
+func (m *MockGormRepository[T]) FindOne(p0 string, p1 T, p2 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.FindOneFunc == nil {
+		panic("MockGormRepository.FindOne is not set")
+	}
+	return m.FindOneFunc(p0, p1, p2...)
+}
+
+func (m *MockGormRepository[T]) Create(p0 T, p1 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.CreateFunc == nil {
+		panic("MockGormRepository.Create is not set")
+	}
+	return m.CreateFunc(p0, p1...)
+}
+
+func (m *MockGormRepository[T]) CreateMany(p0 []T, p1 int, p2 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.CreateManyFunc == nil {
+		panic("MockGormRepository.CreateMany is not set")
+	}
+	return m.CreateManyFunc(p0, p1, p2...)
+}
+
+func (m *MockGormRepository[T]) Upsert(p0 T, p1 []string, p2 []string) error {
+	if m.UpsertFunc == nil {
+		panic("MockGormRepository.Upsert is not set")
+	}
+	return m.UpsertFunc(p0, p1, p2)
+}
+
+func (m *MockGormRepository[T]) UpsertMany(p0 []T, p1 int, p2 []string, p3 []string) error {
+	if m.UpsertManyFunc == nil {
+		panic("MockGormRepository.UpsertMany is not set")
+	}
+	return m.UpsertManyFunc(p0, p1, p2, p3)
+}
+
+func (m *MockGormRepository[T]) Update(p0 string, p1 T, p2 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.UpdateFunc == nil {
+		panic("MockGormRepository.Update is not set")
+	}
+	return m.UpdateFunc(p0, p1, p2...)
+}
+
+func (m *MockGormRepository[T]) Delete(p0 string, p1 T, p2 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.DeleteFunc == nil {
+		panic("MockGormRepository.Delete is not set")
+	}
+	return m.DeleteFunc(p0, p1, p2...)
+}
+
+func (m *MockGormRepository[T]) Clone(p0 T, p1 ...func(*T)) (T, error) {
+	if m.CloneFunc == nil {
+		panic("MockGormRepository.Clone is not set")
+	}
+	return m.CloneFunc(p0, p1...)
+}
+
+func (m *MockGormRepository[T]) FindAsOf(p0 string, p1 time.Time, p2 T) error {
+	if m.FindAsOfFunc == nil {
+		panic("MockGormRepository.FindAsOf is not set")
+	}
+	return m.FindAsOfFunc(p0, p1, p2)
+}
+
+func (m *MockGormRepository[T]) WithTransaction(p0 ...func(tx *gorm.DB) error) error {
+	if m.WithTransactionFunc == nil {
+		panic("MockGormRepository.WithTransaction is not set")
+	}
+	return m.WithTransactionFunc(p0...)
+}
+
+func (m *MockGormRepository[T]) WithContext(p0 context.Context) repositorysdk.GormRepository[T] {
+	if m.WithContextFunc == nil {
+		panic("MockGormRepository.WithContext is not set")
+	}
+	return m.WithContextFunc(p0)
+}
+
+func (m *MockGormRepository[T]) GetDB() *gorm.DB {
+	if m.GetDBFunc == nil {
+		panic("MockGormRepository.GetDB is not set")
+	}
+	return m.GetDBFunc()
+}
+
+// MockIdempotencyStore is a mock of repositorysdk.IdempotencyStore, a method panics if its function is not set.
+type MockIdempotencyStore struct {
+	BeginFunc    func(key string) (bool, []byte, error)
+	CompleteFunc func(key string, response []byte, ttl time.Duration) error
+	AbortFunc    func(key string) error
+}
+
+var _ repositorysdk.IdempotencyStore = (*MockIdempotencyStore)(nil)
+
+func (m *MockIdempotencyStore) Begin(p0 string) (bool, []byte, error) {
+	if m.BeginFunc == nil {
+		panic("MockIdempotencyStore.Begin is not set")
+	}
+	return m.BeginFunc(p0)
+}
+
+func (m *MockIdempotencyStore) Complete(p0 string, p1 []byte, p2 time.Duration) error {
+	if m.CompleteFunc == nil {
+		panic("MockIdempotencyStore.Complete is not set")
+	}
+	return m.CompleteFunc(p0, p1, p2)
+}
+
+func (m *MockIdempotencyStore) Abort(p0 string) error {
+	if m.AbortFunc == nil {
+		panic("MockIdempotencyStore.Abort is not set")
+	}
+	return m.AbortFunc(p0)
+}
+
+// MockJSONRepository is a mock of repositorysdk.JSONRepository, a method panics if its function is not set.
+type MockJSONRepository struct {
+	JSONSetFunc             func(key string, path string, value interface{}, ttl int) error
+	JSONGetFunc             func(key string, path string, dest interface{}) error
+	JSONIncrementNumberFunc func(key string, path string, by float64) (float64, error)
+	JSONDeleteFunc          func(key string, path string) (int64, error)
+}
+
+var _ repositorysdk.JSONRepository = (*MockJSONRepository)(nil)
+
+func (m *MockJSONRepository) JSONSet(p0 string, p1 string, p2 interface{}, p3 int) error {
+	if m.JSONSetFunc == nil {
+		panic("MockJSONRepository.JSONSet is not set")
+	}
+	return m.JSONSetFunc(p0, p1, p2, p3)
+}
+
+func (m *MockJSONRepository) JSONGet(p0 string, p1 string, p2 interface{}) error {
+	if m.JSONGetFunc == nil {
+		panic("MockJSONRepository.JSONGet is not set")
+	}
+	return m.JSONGetFunc(p0, p1, p2)
+}
+
+func (m *MockJSONRepository) JSONIncrementNumber(p0 string, p1 string, p2 float64) (float64, error) {
+	if m.JSONIncrementNumberFunc == nil {
+		panic("MockJSONRepository.JSONIncrementNumber is not set")
+	}
+	return m.JSONIncrementNumberFunc(p0, p1, p2)
+}
+
+func (m *MockJSONRepository) JSONDelete(p0 string, p1 string) (int64, error) {
+	if m.JSONDeleteFunc == nil {
+		panic("MockJSONRepository.JSONDelete is not set")
+	}
+	return m.JSONDeleteFunc(p0, p1)
+}
+
+// MockKeyBuilder is a mock of repositorysdk.KeyBuilder, a method panics if its function is not set.
+type MockKeyBuilder struct {
+	KeyFunc     func(parts ...interface{}) (string, error)
+	MustKeyFunc func(parts ...interface{}) string
+}
+
+var _ repositorysdk.KeyBuilder = (*MockKeyBuilder)(nil)
+
+func (m *MockKeyBuilder) Key(p0 ...interface{}) (string, error) {
+	if m.KeyFunc == nil {
+		panic("MockKeyBuilder.Key is not set")
+	}
+	return m.KeyFunc(p0...)
+}
+
+func (m *MockKeyBuilder) MustKey(p0 ...interface{}) string {
+	if m.MustKeyFunc == nil {
+		panic("MockKeyBuilder.MustKey is not set")
+	}
+	return m.MustKeyFunc(p0...)
+}
+
+// MockLeaderboard is a mock of repositorysdk.Leaderboard, a method panics if its function is not set.
+type MockLeaderboard struct {
+	SubmitFunc    func(member string, score float64) error
+	IncrementFunc func(member string, by float64) (float64, error)
+	TopFunc       func(n int64) ([]repositorysdk.LeaderboardEntry, error)
+	RankFunc      func(member string) (repositorysdk.LeaderboardEntry, error)
+	AroundFunc    func(member string, radius int64) ([]repositorysdk.LeaderboardEntry, error)
+	LenFunc       func() (int64, error)
+	ResetFunc     func() error
+}
+
+var _ repositorysdk.Leaderboard = (*MockLeaderboard)(nil)
+
+func (m *MockLeaderboard) Submit(p0 string, p1 float64) error {
+	if m.SubmitFunc == nil {
+		panic("MockLeaderboard.Submit is not set")
+	}
+	return m.SubmitFunc(p0, p1)
+}
+
+func (m *MockLeaderboard) Increment(p0 string, p1 float64) (float64, error) {
+	if m.IncrementFunc == nil {
+		panic("MockLeaderboard.Increment is not set")
+	}
+	return m.IncrementFunc(p0, p1)
+}
+
+func (m *MockLeaderboard) Top(p0 int64) ([]repositorysdk.LeaderboardEntry, error) {
+	if m.TopFunc == nil {
+		panic("MockLeaderboard.Top is not set")
+	}
+	return m.TopFunc(p0)
+}
+
+func (m *MockLeaderboard) Rank(p0 string) (repositorysdk.LeaderboardEntry, error) {
+	if m.RankFunc == nil {
+		panic("MockLeaderboard.Rank is not set")
+	}
+	return m.RankFunc(p0)
+}
+
+func (m *MockLeaderboard) Around(p0 string, p1 int64) ([]repositorysdk.LeaderboardEntry, error) {
+	if m.AroundFunc == nil {
+		panic("MockLeaderboard.Around is not set")
+	}
+	return m.AroundFunc(p0, p1)
+}
+
+func (m *MockLeaderboard) Len() (int64, error) {
+	if m.LenFunc == nil {
+		panic("MockLeaderboard.Len is not set")
+	}
+	return m.LenFunc()
+}
+
+func (m *MockLeaderboard) Reset() error {
+	if m.ResetFunc == nil {
+		panic("MockLeaderboard.Reset is not set")
+	}
+	return m.ResetFunc()
+}
+
+// MockLoader is a mock of repositorysdk.Loader, a method panics if its function is not set.
+type MockLoader[T repositorysdk.Entity] struct {
+	LoadFunc     func(id string) (T, error)
+	LoadManyFunc func(ids []string) ([]T, []error)
+}
+
+var _ repositorysdk.Loader[repositorysdk.Entity] = (*MockLoader[repositorysdk.Entity])(nil)
+
+func (m *MockLoader[T]) Load(p0 string) (T, error) {
+	if m.LoadFunc == nil {
+		panic("MockLoader.Load is not set")
+	}
+	return m.LoadFunc(p0)
+}
+
+func (m *MockLoader[T]) LoadMany(p0 []string) ([]T, []error) {
+	if m.LoadManyFunc == nil {
+		panic("MockLoader.LoadMany is not set")
+	}
+	return m.LoadManyFunc(p0)
+}
+
+// MockLock is a mock of repositorysdk.Lock, a method panics if its function is not set.
+type MockLock struct {
+	KeyFunc     func() string
+	ReleaseFunc func() error
+	ExtendFunc  func(ttl time.Duration) error
+}
+
+var _ repositorysdk.Lock = (*MockLock)(nil)
+
+func (m *MockLock) Key() string {
+	if m.KeyFunc == nil {
+		panic("MockLock.Key is not set")
+	}
+	return m.KeyFunc()
+}
+
+func (m *MockLock) Release() error {
+	if m.ReleaseFunc == nil {
+		panic("MockLock.Release is not set")
+	}
+	return m.ReleaseFunc()
+}
+
+func (m *MockLock) Extend(p0 time.Duration) error {
+	if m.ExtendFunc == nil {
+		panic("MockLock.Extend is not set")
+	}
+	return m.ExtendFunc(p0)
+}
+
+// MockNamespacedRedisRepository is a mock of repositorysdk.NamespacedRedisRepository, a method panics if its function is not set.
+type MockNamespacedRedisRepository struct {
+	MockRedisRepository
+	FlushNamespaceFunc func() (int64, error)
+}
+
+var _ repositorysdk.NamespacedRedisRepository = (*MockNamespacedRedisRepository)(nil)
+
+func (m *MockNamespacedRedisRepository) FlushNamespace() (int64, error) {
+	if m.FlushNamespaceFunc == nil {
+		panic("MockNamespacedRedisRepository.FlushNamespace is not set")
+	}
+	return m.FlushNamespaceFunc()
+}
+
+// MockObjectStorage is a mock of repositorysdk.ObjectStorage, a method panics if its function is not set.
+type MockObjectStorage struct {
+	PutObjectFunc    func(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	DeleteObjectFunc func(ctx context.Context, key string) error
+}
+
+var _ repositorysdk.ObjectStorage = (*MockObjectStorage)(nil)
+
+func (m *MockObjectStorage) PutObject(p0 context.Context, p1 string, p2 io.Reader, p3 int64, p4 string) error {
+	if m.PutObjectFunc == nil {
+		panic("MockObjectStorage.PutObject is not set")
+	}
+	return m.PutObjectFunc(p0, p1, p2, p3, p4)
+}
+
+func (m *MockObjectStorage) DeleteObject(p0 context.Context, p1 string) error {
+	if m.DeleteObjectFunc == nil {
+		panic("MockObjectStorage.DeleteObject is not set")
+	}
+	return m.DeleteObjectFunc(p0, p1)
+}
+
+// MockOutboxRelay is a mock of repositorysdk.OutboxRelay, a method panics if its function is not set.
+type MockOutboxRelay struct {
+	HandleFunc             func(topic string, handler repositorysdk.OutboxHandler)
+	ProcessBatchFunc       func(ctx context.Context) (int, error)
+	RunFunc                func(ctx context.Context, interval time.Duration) error
+	ReplaySinceFunc        func(ctx context.Context, since time.Time, topics ...string) (int64, error)
+	ReplayAggregateFunc    func(ctx context.Context, aggregateID string, topics ...string) (int64, error)
+	BackfillPartitionsFunc func(ctx context.Context) (int64, error)
+}
+
+var _ repositorysdk.OutboxRelay = (*MockOutboxRelay)(nil)
+
+func (m *MockOutboxRelay) Handle(p0 string, p1 repositorysdk.OutboxHandler) {
+	if m.HandleFunc == nil {
+		panic("MockOutboxRelay.Handle is not set")
+	}
+	m.HandleFunc(p0, p1)
+}
+
+func (m *MockOutboxRelay) ProcessBatch(p0 context.Context) (int, error) {
+	if m.ProcessBatchFunc == nil {
+		panic("MockOutboxRelay.ProcessBatch is not set")
+	}
+	return m.ProcessBatchFunc(p0)
+}
+
+func (m *MockOutboxRelay) Run(p0 context.Context, p1 time.Duration) error {
+	if m.RunFunc == nil {
+		panic("MockOutboxRelay.Run is not set")
+	}
+	return m.RunFunc(p0, p1)
+}
+
+func (m *MockOutboxRelay) ReplaySince(p0 context.Context, p1 time.Time, p2 ...string) (int64, error) {
+	if m.ReplaySinceFunc == nil {
+		panic("MockOutboxRelay.ReplaySince is not set")
+	}
+	return m.ReplaySinceFunc(p0, p1, p2...)
+}
+
+func (m *MockOutboxRelay) ReplayAggregate(p0 context.Context, p1 string, p2 ...string) (int64, error) {
+	if m.ReplayAggregateFunc == nil {
+		panic("MockOutboxRelay.ReplayAggregate is not set")
+	}
+	return m.ReplayAggregateFunc(p0, p1, p2...)
+}
+
+func (m *MockOutboxRelay) BackfillPartitions(p0 context.Context) (int64, error) {
+	if m.BackfillPartitionsFunc == nil {
+		panic("MockOutboxRelay.BackfillPartitions is not set")
+	}
+	return m.BackfillPartitionsFunc(p0)
+}
+
+// MockPriorityQueue is a mock of repositorysdk.PriorityQueue, a method panics if its function is not set.
+type MockPriorityQueue struct {
+	PushFunc     func(item string, priority float64) error
+	PopFunc      func() (string, float64, error)
+	AckFunc      func(item string) error
+	LenFunc      func() (int64, error)
+	InFlightFunc func() (int64, error)
+}
+
+var _ repositorysdk.PriorityQueue = (*MockPriorityQueue)(nil)
+
+func (m *MockPriorityQueue) Push(p0 string, p1 float64) error {
+	if m.PushFunc == nil {
+		panic("MockPriorityQueue.Push is not set")
+	}
+	return m.PushFunc(p0, p1)
+}
+
+func (m *MockPriorityQueue) Pop() (string, float64, error) {
+	if m.PopFunc == nil {
+		panic("MockPriorityQueue.Pop is not set")
+	}
+	return m.PopFunc()
+}
+
+func (m *MockPriorityQueue) Ack(p0 string) error {
+	if m.AckFunc == nil {
+		panic("MockPriorityQueue.Ack is not set")
+	}
+	return m.AckFunc(p0)
+}
+
+func (m *MockPriorityQueue) Len() (int64, error) {
+	if m.LenFunc == nil {
+		panic("MockPriorityQueue.Len is not set")
+	}
+	return m.LenFunc()
+}
+
+func (m *MockPriorityQueue) InFlight() (int64, error) {
+	if m.InFlightFunc == nil {
+		panic("MockPriorityQueue.InFlight is not set")
+	}
+	return m.InFlightFunc()
+}
+
+// MockQuotaManager is a mock of repositorysdk.QuotaManager, a method panics if its function is not set.
+type MockQuotaManager struct {
+	CheckQuotaFunc    func(ctx context.Context, resource repositorysdk.QuotaResource) error
+	CheckQuotaForFunc func(ctx context.Context, resource repositorysdk.QuotaResource, n int64) error
+	GetUsageFunc      func(ctx context.Context, resource repositorysdk.QuotaResource) (int64, error)
+	AddUsageFunc      func(ctx context.Context, resource repositorysdk.QuotaResource, n int64) (int64, error)
+	ReconcileFunc     func(db *gorm.DB, tenantColumn string, entities ...repositorysdk.Entity) error
+}
+
+var _ repositorysdk.QuotaManager = (*MockQuotaManager)(nil)
+
+func (m *MockQuotaManager) CheckQuota(p0 context.Context, p1 repositorysdk.QuotaResource) error {
+	if m.CheckQuotaFunc == nil {
+		panic("MockQuotaManager.CheckQuota is not set")
+	}
+	return m.CheckQuotaFunc(p0, p1)
+}
+
+func (m *MockQuotaManager) CheckQuotaFor(p0 context.Context, p1 repositorysdk.QuotaResource, p2 int64) error {
+	if m.CheckQuotaForFunc == nil {
+		panic("MockQuotaManager.CheckQuotaFor is not set")
+	}
+	return m.CheckQuotaForFunc(p0, p1, p2)
+}
+
+func (m *MockQuotaManager) GetUsage(p0 context.Context, p1 repositorysdk.QuotaResource) (int64, error) {
+	if m.GetUsageFunc == nil {
+		panic("MockQuotaManager.GetUsage is not set")
+	}
+	return m.GetUsageFunc(p0, p1)
+}
+
+func (m *MockQuotaManager) AddUsage(p0 context.Context, p1 repositorysdk.QuotaResource, p2 int64) (int64, error) {
+	if m.AddUsageFunc == nil {
+		panic("MockQuotaManager.AddUsage is not set")
+	}
+	return m.AddUsageFunc(p0, p1, p2)
+}
+
+func (m *MockQuotaManager) Reconcile(p0 *gorm.DB, p1 string, p2 ...repositorysdk.Entity) error {
+	if m.ReconcileFunc == nil {
+		panic("MockQuotaManager.Reconcile is not set")
+	}
+	return m.ReconcileFunc(p0, p1, p2...)
+}
+
+// MockRateLimiter is a mock of repositorysdk.RateLimiter, a method panics if its function is not set.
+type MockRateLimiter struct {
+	AllowFunc func(key string, limit int, window time.Duration) (bool, int, error)
+}
+
+var _ repositorysdk.RateLimiter = (*MockRateLimiter)(nil)
+
+func (m *MockRateLimiter) Allow(p0 string, p1 int, p2 time.Duration) (bool, int, error) {
+	if m.AllowFunc == nil {
+		panic("MockRateLimiter.Allow is not set")
+	}
+	return m.AllowFunc(p0, p1, p2)
+}
+
+// MockRedisMetrics is a mock of repositorysdk.RedisMetrics, a method panics if its function is not set.
+type MockRedisMetrics struct {
+	ObserveRedisCallFunc func(method string, result repositorysdk.RedisCallResult, duration time.Duration)
+}
+
+var _ repositorysdk.RedisMetrics = (*MockRedisMetrics)(nil)
+
+func (m *MockRedisMetrics) ObserveRedisCall(p0 string, p1 repositorysdk.RedisCallResult, p2 time.Duration) {
+	if m.ObserveRedisCallFunc == nil {
+		panic("MockRedisMetrics.ObserveRedisCall is not set")
+	}
+	m.ObserveRedisCallFunc(p0, p1, p2)
+}
+
+// MockRedisPipeline is a mock of repositorysdk.RedisPipeline, a method panics if its function is not set.
+type MockRedisPipeline struct {
+	SaveCacheFunc     func(key string, value interface{}, ttl int) error
+	SaveHashCacheFunc func(key string, field string, value string, ttl int)
+	AddSetMemberFunc  func(key string, ttl int, member ...interface{})
+	SetExpireFunc     func(key string, ttl int)
+	RemoveCacheFunc   func(key string)
+}
+
+var _ repositorysdk.RedisPipeline = (*MockRedisPipeline)(nil)
+
+func (m *MockRedisPipeline) SaveCache(p0 string, p1 interface{}, p2 int) error {
+	if m.SaveCacheFunc == nil {
+		panic("MockRedisPipeline.SaveCache is not set")
+	}
+	return m.SaveCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisPipeline) SaveHashCache(p0 string, p1 string, p2 string, p3 int) {
+	if m.SaveHashCacheFunc == nil {
+		panic("MockRedisPipeline.SaveHashCache is not set")
+	}
+	m.SaveHashCacheFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisPipeline) AddSetMember(p0 string, p1 int, p2 ...interface{}) {
+	if m.AddSetMemberFunc == nil {
+		panic("MockRedisPipeline.AddSetMember is not set")
+	}
+	m.AddSetMemberFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisPipeline) SetExpire(p0 string, p1 int) {
+	if m.SetExpireFunc == nil {
+		panic("MockRedisPipeline.SetExpire is not set")
+	}
+	m.SetExpireFunc(p0, p1)
+}
+
+func (m *MockRedisPipeline) RemoveCache(p0 string) {
+	if m.RemoveCacheFunc == nil {
+		panic("MockRedisPipeline.RemoveCache is not set")
+	}
+	m.RemoveCacheFunc(p0)
+}
+
+// MockRedisRepository is a mock of repositorysdk.RedisRepository, a method panics if its function is not set.
+type MockRedisRepository struct {
+	SaveCacheFunc                  func(string, interface{}, int) error
+	SaveCacheDFunc                 func(key string, value interface{}, ttl time.Duration) error
+	SaveCacheNXFunc                func(key string, value interface{}, ttl int) (bool, error)
+	SaveCacheNXDFunc               func(key string, value interface{}, ttl time.Duration) (bool, error)
+	SaveCacheXXFunc                func(key string, value interface{}, ttl int) (bool, error)
+	SaveCacheXXDFunc               func(key string, value interface{}, ttl time.Duration) (bool, error)
+	SaveHashCacheFunc              func(string, string, string, int) error
+	SaveHashCacheDFunc             func(key string, field string, value string, ttl time.Duration) error
+	SaveAllHashCacheFunc           func(string, map[string]string, int) error
+	SaveAllHashCacheDFunc          func(key string, value map[string]string, ttl time.Duration) error
+	AddSetMemberFunc               func(key string, ttl int, member ...interface{}) error
+	AddSetMemberDFunc              func(key string, ttl time.Duration, member ...interface{}) error
+	GetCacheFunc                   func(string, interface{}) error
+	GetDelCacheFunc                func(key string, dest interface{}) error
+	SaveMultiCacheFunc             func(values map[string]interface{}, ttl int) error
+	SaveMultiCacheDFunc            func(values map[string]interface{}, ttl time.Duration) error
+	GetMultiCacheFunc              func(keys []string, dest map[string]json.RawMessage) error
+	GetOrSetCacheFunc              func(key string, ttl int, dest interface{}, loader func() (interface{}, error)) error
+	GetOrSetCacheDFunc             func(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error
+	GetHashCacheFunc               func(string, string) (string, error)
+	GetAllHashCacheFunc            func(string) (map[string]string, error)
+	GetHashFieldsFunc              func(key string, fields ...string) (map[string]string, error)
+	IncrementHashFieldFunc         func(key string, field string, by int64) (int64, error)
+	RemoveCacheFunc                func(string) error
+	RemoveCacheByPatternFunc       func(pattern string) (int64, error)
+	RemoveSetMemberFunc            func(key string, member interface{}) error
+	RemoveHashCacheFunc            func(key string, field string) error
+	SetExpireFunc                  func(string, int) error
+	SetExpireDFunc                 func(key string, ttl time.Duration) error
+	SetExpireAtFunc                func(key string, at time.Time) error
+	CopyCacheFunc                  func(src string, dst string, replace bool) (bool, error)
+	RenameCacheFunc                func(src string, dst string) error
+	CheckSetMemberFunc             func(key string, member interface{}) (bool, error)
+	ExistFunc                      func(key string) (bool, error)
+	ExistMultiFunc                 func(keys ...string) (map[string]bool, error)
+	GetTTLMultiFunc                func(keys ...string) (map[string]time.Duration, error)
+	NamespaceStatsFunc             func(prefix string) (*repositorysdk.NamespaceStats, error)
+	KeyMemoryUsageFunc             func(key string) (int64, error)
+	KeyTypeFunc                    func(key string) (string, error)
+	ObjectIdleTimeFunc             func(key string) (time.Duration, error)
+	TopKeysByMemoryFunc            func(pattern string, n int) ([]repositorysdk.KeyMemory, error)
+	ScanKeysFunc                   func(pattern string, fn func(key string) error) error
+	RandomSetMembersFunc           func(key string, n int) ([]string, error)
+	GetSetMembersFunc              func(key string) ([]string, error)
+	CountSetMembersFunc            func(key string) (int64, error)
+	UnionSetsFunc                  func(keys ...string) ([]string, error)
+	UnionSetsStoreFunc             func(dst string, keys ...string) (int64, error)
+	IntersectSetsFunc              func(keys ...string) ([]string, error)
+	IntersectSetsStoreFunc         func(dst string, keys ...string) (int64, error)
+	DiffSetsFunc                   func(keys ...string) ([]string, error)
+	DiffSetsStoreFunc              func(dst string, keys ...string) (int64, error)
+	AddUniqueFunc                  func(key string, ttl int, items ...interface{}) error
+	AddUniqueDFunc                 func(key string, ttl time.Duration, items ...interface{}) error
+	CountUniqueFunc                func(keys ...string) (int64, error)
+	SetBitFunc                     func(key string, offset int64, value bool, ttl int) (bool, error)
+	SetBitDFunc                    func(key string, offset int64, value bool, ttl time.Duration) (bool, error)
+	GetBitFunc                     func(key string, offset int64) (bool, error)
+	CountBitsFunc                  func(key string) (int64, error)
+	RandomHashFieldsFunc           func(key string, n int) ([]string, error)
+	SaveVersionedCacheFunc         func(key string, value interface{}, ttl int) (string, error)
+	SaveVersionedCacheDFunc        func(key string, value interface{}, ttl time.Duration) (string, error)
+	SaveVersionedCacheIfMatchFunc  func(key string, version string, value interface{}, ttl int) (string, error)
+	SaveVersionedCacheIfMatchDFunc func(key string, version string, value interface{}, ttl time.Duration) (string, error)
+	GetVersionedCacheFunc          func(key string, version string, value interface{}) (string, bool, error)
+	PushListFunc                   func(key string, ttl int, values ...interface{}) (int64, error)
+	PushListDFunc                  func(key string, ttl time.Duration, values ...interface{}) (int64, error)
+	PopListFunc                    func(key string) (string, error)
+	BPopListFunc                   func(timeout int, keys ...string) (string, string, error)
+	GetListRangeFunc               func(key string, start int64, stop int64) ([]string, error)
+	TrimListFunc                   func(key string, start int64, stop int64) error
+	IncrementCacheFunc             func(key string, by int64, ttl int) (int64, error)
+	IncrementCacheDFunc            func(key string, by int64, ttl time.Duration) (int64, error)
+	DecrementCacheFunc             func(key string, by int64, ttl int) (int64, error)
+	DecrementCacheDFunc            func(key string, by int64, ttl time.Duration) (int64, error)
+	WatchTransactionFunc           func(keys []string, fn func(tx repositorysdk.RedisTx) error, retries int) error
+	PipelineFunc                   func(fn func(p repositorysdk.RedisPipeline) error) error
+	LoadScriptFunc                 func(name string, body string) error
+	RunScriptFunc                  func(name string, keys []string, args ...interface{}) (interface{}, error)
+	PublishFunc                    func(channel string, payload interface{}) error
+	ShardedPublishFunc             func(channel string, payload interface{}) error
+	ShardedSubscribeFunc           func(ctx context.Context, channel string, handler func(payload []byte) error) error
+	SubscribeFunc                  func(ctx context.Context, channel string, handler func(payload []byte) error) error
+	PSubscribeFunc                 func(ctx context.Context, pattern string, handler func(channel string, payload []byte) error) error
+	AcquireLockFunc                func(key string, ttl time.Duration) (repositorysdk.Lock, error)
+	AcquireSemaphoreFunc           func(key string, limit int, ttl time.Duration) (repositorysdk.Lock, error)
+	AcquireSemaphoreWaitFunc       func(key string, limit int, ttl time.Duration, wait time.Duration) (repositorysdk.Lock, error)
+	ReleaseSemaphoreFunc           func(permit repositorysdk.Lock) error
+	HealthCheckFunc                func(ctx context.Context) error
+	PoolStatsFunc                  func() repositorysdk.RedisPoolStats
+	GetClientFunc                  func() *redis.Client
+	GetUniversalClientFunc         func() redis.UniversalClient
+	WithContextFunc                func(ctx context.Context) repositorysdk.RedisRepository
+}
+
+var _ repositorysdk.RedisRepository = (*MockRedisRepository)(nil)
+
+func (m *MockRedisRepository) SaveCache(p0 string, p1 interface{}, p2 int) error {
+	if m.SaveCacheFunc == nil {
+		panic("MockRedisRepository.SaveCache is not set")
+	}
+	return m.SaveCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveCacheD(p0 string, p1 interface{}, p2 time.Duration) error {
+	if m.SaveCacheDFunc == nil {
+		panic("MockRedisRepository.SaveCacheD is not set")
+	}
+	return m.SaveCacheDFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveCacheNX(p0 string, p1 interface{}, p2 int) (bool, error) {
+	if m.SaveCacheNXFunc == nil {
+		panic("MockRedisRepository.SaveCacheNX is not set")
+	}
+	return m.SaveCacheNXFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveCacheNXD(p0 string, p1 interface{}, p2 time.Duration) (bool, error) {
+	if m.SaveCacheNXDFunc == nil {
+		panic("MockRedisRepository.SaveCacheNXD is not set")
+	}
+	return m.SaveCacheNXDFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveCacheXX(p0 string, p1 interface{}, p2 int) (bool, error) {
+	if m.SaveCacheXXFunc == nil {
+		panic("MockRedisRepository.SaveCacheXX is not set")
+	}
+	return m.SaveCacheXXFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveCacheXXD(p0 string, p1 interface{}, p2 time.Duration) (bool, error) {
+	if m.SaveCacheXXDFunc == nil {
+		panic("MockRedisRepository.SaveCacheXXD is not set")
+	}
+	return m.SaveCacheXXDFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveHashCache(p0 string, p1 string, p2 string, p3 int) error {
+	if m.SaveHashCacheFunc == nil {
+		panic("MockRedisRepository.SaveHashCache is not set")
+	}
+	return m.SaveHashCacheFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) SaveHashCacheD(p0 string, p1 string, p2 string, p3 time.Duration) error {
+	if m.SaveHashCacheDFunc == nil {
+		panic("MockRedisRepository.SaveHashCacheD is not set")
+	}
+	return m.SaveHashCacheDFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) SaveAllHashCache(p0 string, p1 map[string]string, p2 int) error {
+	if m.SaveAllHashCacheFunc == nil {
+		panic("MockRedisRepository.SaveAllHashCache is not set")
+	}
+	return m.SaveAllHashCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveAllHashCacheD(p0 string, p1 map[string]string, p2 time.Duration) error {
+	if m.SaveAllHashCacheDFunc == nil {
+		panic("MockRedisRepository.SaveAllHashCacheD is not set")
+	}
+	return m.SaveAllHashCacheDFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) AddSetMember(p0 string, p1 int, p2 ...interface{}) error {
+	if m.AddSetMemberFunc == nil {
+		panic("MockRedisRepository.AddSetMember is not set")
+	}
+	return m.AddSetMemberFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisRepository) AddSetMemberD(p0 string, p1 time.Duration, p2 ...interface{}) error {
+	if m.AddSetMemberDFunc == nil {
+		panic("MockRedisRepository.AddSetMemberD is not set")
+	}
+	return m.AddSetMemberDFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisRepository) GetCache(p0 string, p1 interface{}) error {
+	if m.GetCacheFunc == nil {
+		panic("MockRedisRepository.GetCache is not set")
+	}
+	return m.GetCacheFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) GetDelCache(p0 string, p1 interface{}) error {
+	if m.GetDelCacheFunc == nil {
+		panic("MockRedisRepository.GetDelCache is not set")
+	}
+	return m.GetDelCacheFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) SaveMultiCache(p0 map[string]interface{}, p1 int) error {
+	if m.SaveMultiCacheFunc == nil {
+		panic("MockRedisRepository.SaveMultiCache is not set")
+	}
+	return m.SaveMultiCacheFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) SaveMultiCacheD(p0 map[string]interface{}, p1 time.Duration) error {
+	if m.SaveMultiCacheDFunc == nil {
+		panic("MockRedisRepository.SaveMultiCacheD is not set")
+	}
+	return m.SaveMultiCacheDFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) GetMultiCache(p0 []string, p1 map[string]json.RawMessage) error {
+	if m.GetMultiCacheFunc == nil {
+		panic("MockRedisRepository.GetMultiCache is not set")
+	}
+	return m.GetMultiCacheFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) GetOrSetCache(p0 string, p1 int, p2 interface{}, p3 func() (interface{}, error)) error {
+	if m.GetOrSetCacheFunc == nil {
+		panic("MockRedisRepository.GetOrSetCache is not set")
+	}
+	return m.GetOrSetCacheFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) GetOrSetCacheD(p0 string, p1 time.Duration, p2 interface{}, p3 func() (interface{}, error)) error {
+	if m.GetOrSetCacheDFunc == nil {
+		panic("MockRedisRepository.GetOrSetCacheD is not set")
+	}
+	return m.GetOrSetCacheDFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) GetHashCache(p0 string, p1 string) (string, error) {
+	if m.GetHashCacheFunc == nil {
+		panic("MockRedisRepository.GetHashCache is not set")
+	}
+	return m.GetHashCacheFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) GetAllHashCache(p0 string) (map[string]string, error) {
+	if m.GetAllHashCacheFunc == nil {
+		panic("MockRedisRepository.GetAllHashCache is not set")
+	}
+	return m.GetAllHashCacheFunc(p0)
+}
+
+func (m *MockRedisRepository) GetHashFields(p0 string, p1 ...string) (map[string]string, error) {
+	if m.GetHashFieldsFunc == nil {
+		panic("MockRedisRepository.GetHashFields is not set")
+	}
+	return m.GetHashFieldsFunc(p0, p1...)
+}
+
+func (m *MockRedisRepository) IncrementHashField(p0 string, p1 string, p2 int64) (int64, error) {
+	if m.IncrementHashFieldFunc == nil {
+		panic("MockRedisRepository.IncrementHashField is not set")
+	}
+	return m.IncrementHashFieldFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) RemoveCache(p0 string) error {
+	if m.RemoveCacheFunc == nil {
+		panic("MockRedisRepository.RemoveCache is not set")
+	}
+	return m.RemoveCacheFunc(p0)
+}
+
+func (m *MockRedisRepository) RemoveCacheByPattern(p0 string) (int64, error) {
+	if m.RemoveCacheByPatternFunc == nil {
+		panic("MockRedisRepository.RemoveCacheByPattern is not set")
+	}
+	return m.RemoveCacheByPatternFunc(p0)
+}
+
+func (m *MockRedisRepository) RemoveSetMember(p0 string, p1 interface{}) error {
+	if m.RemoveSetMemberFunc == nil {
+		panic("MockRedisRepository.RemoveSetMember is not set")
+	}
+	return m.RemoveSetMemberFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) RemoveHashCache(p0 string, p1 string) error {
+	if m.RemoveHashCacheFunc == nil {
+		panic("MockRedisRepository.RemoveHashCache is not set")
+	}
+	return m.RemoveHashCacheFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) SetExpire(p0 string, p1 int) error {
+	if m.SetExpireFunc == nil {
+		panic("MockRedisRepository.SetExpire is not set")
+	}
+	return m.SetExpireFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) SetExpireD(p0 string, p1 time.Duration) error {
+	if m.SetExpireDFunc == nil {
+		panic("MockRedisRepository.SetExpireD is not set")
+	}
+	return m.SetExpireDFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) SetExpireAt(p0 string, p1 time.Time) error {
+	if m.SetExpireAtFunc == nil {
+		panic("MockRedisRepository.SetExpireAt is not set")
+	}
+	return m.SetExpireAtFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) CopyCache(p0 string, p1 string, p2 bool) (bool, error) {
+	if m.CopyCacheFunc == nil {
+		panic("MockRedisRepository.CopyCache is not set")
+	}
+	return m.CopyCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) RenameCache(p0 string, p1 string) error {
+	if m.RenameCacheFunc == nil {
+		panic("MockRedisRepository.RenameCache is not set")
+	}
+	return m.RenameCacheFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) CheckSetMember(p0 string, p1 interface{}) (bool, error) {
+	if m.CheckSetMemberFunc == nil {
+		panic("MockRedisRepository.CheckSetMember is not set")
+	}
+	return m.CheckSetMemberFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) Exist(p0 string) (bool, error) {
+	if m.ExistFunc == nil {
+		panic("MockRedisRepository.Exist is not set")
+	}
+	return m.ExistFunc(p0)
+}
+
+func (m *MockRedisRepository) ExistMulti(p0 ...string) (map[string]bool, error) {
+	if m.ExistMultiFunc == nil {
+		panic("MockRedisRepository.ExistMulti is not set")
+	}
+	return m.ExistMultiFunc(p0...)
+}
+
+func (m *MockRedisRepository) GetTTLMulti(p0 ...string) (map[string]time.Duration, error) {
+	if m.GetTTLMultiFunc == nil {
+		panic("MockRedisRepository.GetTTLMulti is not set")
+	}
+	return m.GetTTLMultiFunc(p0...)
+}
+
+func (m *MockRedisRepository) NamespaceStats(p0 string) (*repositorysdk.NamespaceStats, error) {
+	if m.NamespaceStatsFunc == nil {
+		panic("MockRedisRepository.NamespaceStats is not set")
+	}
+	return m.NamespaceStatsFunc(p0)
+}
+
+func (m *MockRedisRepository) KeyMemoryUsage(p0 string) (int64, error) {
+	if m.KeyMemoryUsageFunc == nil {
+		panic("MockRedisRepository.KeyMemoryUsage is not set")
+	}
+	return m.KeyMemoryUsageFunc(p0)
+}
+
+func (m *MockRedisRepository) KeyType(p0 string) (string, error) {
+	if m.KeyTypeFunc == nil {
+		panic("MockRedisRepository.KeyType is not set")
+	}
+	return m.KeyTypeFunc(p0)
+}
+
+func (m *MockRedisRepository) ObjectIdleTime(p0 string) (time.Duration, error) {
+	if m.ObjectIdleTimeFunc == nil {
+		panic("MockRedisRepository.ObjectIdleTime is not set")
+	}
+	return m.ObjectIdleTimeFunc(p0)
+}
+
+func (m *MockRedisRepository) TopKeysByMemory(p0 string, p1 int) ([]repositorysdk.KeyMemory, error) {
+	if m.TopKeysByMemoryFunc == nil {
+		panic("MockRedisRepository.TopKeysByMemory is not set")
+	}
+	return m.TopKeysByMemoryFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) ScanKeys(p0 string, p1 func(key string) error) error {
+	if m.ScanKeysFunc == nil {
+		panic("MockRedisRepository.ScanKeys is not set")
+	}
+	return m.ScanKeysFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) RandomSetMembers(p0 string, p1 int) ([]string, error) {
+	if m.RandomSetMembersFunc == nil {
+		panic("MockRedisRepository.RandomSetMembers is not set")
+	}
+	return m.RandomSetMembersFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) GetSetMembers(p0 string) ([]string, error) {
+	if m.GetSetMembersFunc == nil {
+		panic("MockRedisRepository.GetSetMembers is not set")
+	}
+	return m.GetSetMembersFunc(p0)
+}
+
+func (m *MockRedisRepository) CountSetMembers(p0 string) (int64, error) {
+	if m.CountSetMembersFunc == nil {
+		panic("MockRedisRepository.CountSetMembers is not set")
+	}
+	return m.CountSetMembersFunc(p0)
+}
+
+func (m *MockRedisRepository) UnionSets(p0 ...string) ([]string, error) {
+	if m.UnionSetsFunc == nil {
+		panic("MockRedisRepository.UnionSets is not set")
+	}
+	return m.UnionSetsFunc(p0...)
+}
+
+func (m *MockRedisRepository) UnionSetsStore(p0 string, p1 ...string) (int64, error) {
+	if m.UnionSetsStoreFunc == nil {
+		panic("MockRedisRepository.UnionSetsStore is not set")
+	}
+	return m.UnionSetsStoreFunc(p0, p1...)
+}
+
+func (m *MockRedisRepository) IntersectSets(p0 ...string) ([]string, error) {
+	if m.IntersectSetsFunc == nil {
+		panic("MockRedisRepository.IntersectSets is not set")
+	}
+	return m.IntersectSetsFunc(p0...)
+}
+
+func (m *MockRedisRepository) IntersectSetsStore(p0 string, p1 ...string) (int64, error) {
+	if m.IntersectSetsStoreFunc == nil {
+		panic("MockRedisRepository.IntersectSetsStore is not set")
+	}
+	return m.IntersectSetsStoreFunc(p0, p1...)
+}
+
+func (m *MockRedisRepository) DiffSets(p0 ...string) ([]string, error) {
+	if m.DiffSetsFunc == nil {
+		panic("MockRedisRepository.DiffSets is not set")
+	}
+	return m.DiffSetsFunc(p0...)
+}
+
+func (m *MockRedisRepository) DiffSetsStore(p0 string, p1 ...string) (int64, error) {
+	if m.DiffSetsStoreFunc == nil {
+		panic("MockRedisRepository.DiffSetsStore is not set")
+	}
+	return m.DiffSetsStoreFunc(p0, p1...)
+}
+
+func (m *MockRedisRepository) AddUnique(p0 string, p1 int, p2 ...interface{}) error {
+	if m.AddUniqueFunc == nil {
+		panic("MockRedisRepository.AddUnique is not set")
+	}
+	return m.AddUniqueFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisRepository) AddUniqueD(p0 string, p1 time.Duration, p2 ...interface{}) error {
+	if m.AddUniqueDFunc == nil {
+		panic("MockRedisRepository.AddUniqueD is not set")
+	}
+	return m.AddUniqueDFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisRepository) CountUnique(p0 ...string) (int64, error) {
+	if m.CountUniqueFunc == nil {
+		panic("MockRedisRepository.CountUnique is not set")
+	}
+	return m.CountUniqueFunc(p0...)
+}
+
+func (m *MockRedisRepository) SetBit(p0 string, p1 int64, p2 bool, p3 int) (bool, error) {
+	if m.SetBitFunc == nil {
+		panic("MockRedisRepository.SetBit is not set")
+	}
+	return m.SetBitFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) SetBitD(p0 string, p1 int64, p2 bool, p3 time.Duration) (bool, error) {
+	if m.SetBitDFunc == nil {
+		panic("MockRedisRepository.SetBitD is not set")
+	}
+	return m.SetBitDFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) GetBit(p0 string, p1 int64) (bool, error) {
+	if m.GetBitFunc == nil {
+		panic("MockRedisRepository.GetBit is not set")
+	}
+	return m.GetBitFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) CountBits(p0 string) (int64, error) {
+	if m.CountBitsFunc == nil {
+		panic("MockRedisRepository.CountBits is not set")
+	}
+	return m.CountBitsFunc(p0)
+}
+
+func (m *MockRedisRepository) RandomHashFields(p0 string, p1 int) ([]string, error) {
+	if m.RandomHashFieldsFunc == nil {
+		panic("MockRedisRepository.RandomHashFields is not set")
+	}
+	return m.RandomHashFieldsFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) SaveVersionedCache(p0 string, p1 interface{}, p2 int) (string, error) {
+	if m.SaveVersionedCacheFunc == nil {
+		panic("MockRedisRepository.SaveVersionedCache is not set")
+	}
+	return m.SaveVersionedCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveVersionedCacheD(p0 string, p1 interface{}, p2 time.Duration) (string, error) {
+	if m.SaveVersionedCacheDFunc == nil {
+		panic("MockRedisRepository.SaveVersionedCacheD is not set")
+	}
+	return m.SaveVersionedCacheDFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) SaveVersionedCacheIfMatch(p0 string, p1 string, p2 interface{}, p3 int) (string, error) {
+	if m.SaveVersionedCacheIfMatchFunc == nil {
+		panic("MockRedisRepository.SaveVersionedCacheIfMatch is not set")
+	}
+	return m.SaveVersionedCacheIfMatchFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) SaveVersionedCacheIfMatchD(p0 string, p1 string, p2 interface{}, p3 time.Duration) (string, error) {
+	if m.SaveVersionedCacheIfMatchDFunc == nil {
+		panic("MockRedisRepository.SaveVersionedCacheIfMatchD is not set")
+	}
+	return m.SaveVersionedCacheIfMatchDFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) GetVersionedCache(p0 string, p1 string, p2 interface{}) (string, bool, error) {
+	if m.GetVersionedCacheFunc == nil {
+		panic("MockRedisRepository.GetVersionedCache is not set")
+	}
+	return m.GetVersionedCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) PushList(p0 string, p1 int, p2 ...interface{}) (int64, error) {
+	if m.PushListFunc == nil {
+		panic("MockRedisRepository.PushList is not set")
+	}
+	return m.PushListFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisRepository) PushListD(p0 string, p1 time.Duration, p2 ...interface{}) (int64, error) {
+	if m.PushListDFunc == nil {
+		panic("MockRedisRepository.PushListD is not set")
+	}
+	return m.PushListDFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisRepository) PopList(p0 string) (string, error) {
+	if m.PopListFunc == nil {
+		panic("MockRedisRepository.PopList is not set")
+	}
+	return m.PopListFunc(p0)
+}
+
+func (m *MockRedisRepository) BPopList(p0 int, p1 ...string) (string, string, error) {
+	if m.BPopListFunc == nil {
+		panic("MockRedisRepository.BPopList is not set")
+	}
+	return m.BPopListFunc(p0, p1...)
+}
+
+func (m *MockRedisRepository) GetListRange(p0 string, p1 int64, p2 int64) ([]string, error) {
+	if m.GetListRangeFunc == nil {
+		panic("MockRedisRepository.GetListRange is not set")
+	}
+	return m.GetListRangeFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) TrimList(p0 string, p1 int64, p2 int64) error {
+	if m.TrimListFunc == nil {
+		panic("MockRedisRepository.TrimList is not set")
+	}
+	return m.TrimListFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) IncrementCache(p0 string, p1 int64, p2 int) (int64, error) {
+	if m.IncrementCacheFunc == nil {
+		panic("MockRedisRepository.IncrementCache is not set")
+	}
+	return m.IncrementCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) IncrementCacheD(p0 string, p1 int64, p2 time.Duration) (int64, error) {
+	if m.IncrementCacheDFunc == nil {
+		panic("MockRedisRepository.IncrementCacheD is not set")
+	}
+	return m.IncrementCacheDFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) DecrementCache(p0 string, p1 int64, p2 int) (int64, error) {
+	if m.DecrementCacheFunc == nil {
+		panic("MockRedisRepository.DecrementCache is not set")
+	}
+	return m.DecrementCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) DecrementCacheD(p0 string, p1 int64, p2 time.Duration) (int64, error) {
+	if m.DecrementCacheDFunc == nil {
+		panic("MockRedisRepository.DecrementCacheD is not set")
+	}
+	return m.DecrementCacheDFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) WatchTransaction(p0 []string, p1 func(tx repositorysdk.RedisTx) error, p2 int) error {
+	if m.WatchTransactionFunc == nil {
+		panic("MockRedisRepository.WatchTransaction is not set")
+	}
+	return m.WatchTransactionFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) Pipeline(p0 func(p repositorysdk.RedisPipeline) error) error {
+	if m.PipelineFunc == nil {
+		panic("MockRedisRepository.Pipeline is not set")
+	}
+	return m.PipelineFunc(p0)
+}
+
+func (m *MockRedisRepository) LoadScript(p0 string, p1 string) error {
+	if m.LoadScriptFunc == nil {
+		panic("MockRedisRepository.LoadScript is not set")
+	}
+	return m.LoadScriptFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) RunScript(p0 string, p1 []string, p2 ...interface{}) (interface{}, error) {
+	if m.RunScriptFunc == nil {
+		panic("MockRedisRepository.RunScript is not set")
+	}
+	return m.RunScriptFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisRepository) Publish(p0 string, p1 interface{}) error {
+	if m.PublishFunc == nil {
+		panic("MockRedisRepository.Publish is not set")
+	}
+	return m.PublishFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) ShardedPublish(p0 string, p1 interface{}) error {
+	if m.ShardedPublishFunc == nil {
+		panic("MockRedisRepository.ShardedPublish is not set")
+	}
+	return m.ShardedPublishFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) ShardedSubscribe(p0 context.Context, p1 string, p2 func(payload []byte) error) error {
+	if m.ShardedSubscribeFunc == nil {
+		panic("MockRedisRepository.ShardedSubscribe is not set")
+	}
+	return m.ShardedSubscribeFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) Subscribe(p0 context.Context, p1 string, p2 func(payload []byte) error) error {
+	if m.SubscribeFunc == nil {
+		panic("MockRedisRepository.Subscribe is not set")
+	}
+	return m.SubscribeFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) PSubscribe(p0 context.Context, p1 string, p2 func(channel string, payload []byte) error) error {
+	if m.PSubscribeFunc == nil {
+		panic("MockRedisRepository.PSubscribe is not set")
+	}
+	return m.PSubscribeFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) AcquireLock(p0 string, p1 time.Duration) (repositorysdk.Lock, error) {
+	if m.AcquireLockFunc == nil {
+		panic("MockRedisRepository.AcquireLock is not set")
+	}
+	return m.AcquireLockFunc(p0, p1)
+}
+
+func (m *MockRedisRepository) AcquireSemaphore(p0 string, p1 int, p2 time.Duration) (repositorysdk.Lock, error) {
+	if m.AcquireSemaphoreFunc == nil {
+		panic("MockRedisRepository.AcquireSemaphore is not set")
+	}
+	return m.AcquireSemaphoreFunc(p0, p1, p2)
+}
+
+func (m *MockRedisRepository) AcquireSemaphoreWait(p0 string, p1 int, p2 time.Duration, p3 time.Duration) (repositorysdk.Lock, error) {
+	if m.AcquireSemaphoreWaitFunc == nil {
+		panic("MockRedisRepository.AcquireSemaphoreWait is not set")
+	}
+	return m.AcquireSemaphoreWaitFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisRepository) ReleaseSemaphore(p0 repositorysdk.Lock) error {
+	if m.ReleaseSemaphoreFunc == nil {
+		panic("MockRedisRepository.ReleaseSemaphore is not set")
+	}
+	return m.ReleaseSemaphoreFunc(p0)
+}
+
+func (m *MockRedisRepository) HealthCheck(p0 context.Context) error {
+	if m.HealthCheckFunc == nil {
+		panic("MockRedisRepository.HealthCheck is not set")
+	}
+	return m.HealthCheckFunc(p0)
+}
+
+func (m *MockRedisRepository) PoolStats() repositorysdk.RedisPoolStats {
+	if m.PoolStatsFunc == nil {
+		panic("MockRedisRepository.PoolStats is not set")
+	}
+	return m.PoolStatsFunc()
+}
+
+func (m *MockRedisRepository) GetClient() *redis.Client {
+	if m.GetClientFunc == nil {
+		panic("MockRedisRepository.GetClient is not set")
+	}
+	return m.GetClientFunc()
+}
+
+func (m *MockRedisRepository) GetUniversalClient() redis.UniversalClient {
+	if m.GetUniversalClientFunc == nil {
+		panic("MockRedisRepository.GetUniversalClient is not set")
+	}
+	return m.GetUniversalClientFunc()
+}
+
+func (m *MockRedisRepository) WithContext(p0 context.Context) repositorysdk.RedisRepository {
+	if m.WithContextFunc == nil {
+		panic("MockRedisRepository.WithContext is not set")
+	}
+	return m.WithContextFunc(p0)
+}
+
+// MockRedisStreamRepository is a mock of repositorysdk.RedisStreamRepository, a method panics if its function is not set.
+type MockRedisStreamRepository struct {
+	AddToStreamFunc func(stream string, values map[string]interface{}, maxLen int64) (string, error)
+	CreateGroupFunc func(stream string, group string) error
+	ReadGroupFunc   func(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) ([]repositorysdk.StreamMessage, error)
+	AckFunc         func(stream string, group string, ids ...string) error
+	ClaimFunc       func(stream string, group string, consumer string, minIdle time.Duration, count int64) ([]repositorysdk.StreamMessage, error)
+	ConsumeFunc     func(ctx context.Context, stream string, group string, consumer string, handler repositorysdk.StreamHandler, conf repositorysdk.StreamConsumerConfig) error
+}
+
+var _ repositorysdk.RedisStreamRepository = (*MockRedisStreamRepository)(nil)
+
+func (m *MockRedisStreamRepository) AddToStream(p0 string, p1 map[string]interface{}, p2 int64) (string, error) {
+	if m.AddToStreamFunc == nil {
+		panic("MockRedisStreamRepository.AddToStream is not set")
+	}
+	return m.AddToStreamFunc(p0, p1, p2)
+}
+
+func (m *MockRedisStreamRepository) CreateGroup(p0 string, p1 string) error {
+	if m.CreateGroupFunc == nil {
+		panic("MockRedisStreamRepository.CreateGroup is not set")
+	}
+	return m.CreateGroupFunc(p0, p1)
+}
+
+func (m *MockRedisStreamRepository) ReadGroup(p0 context.Context, p1 string, p2 string, p3 string, p4 int64, p5 time.Duration) ([]repositorysdk.StreamMessage, error) {
+	if m.ReadGroupFunc == nil {
+		panic("MockRedisStreamRepository.ReadGroup is not set")
+	}
+	return m.ReadGroupFunc(p0, p1, p2, p3, p4, p5)
+}
+
+func (m *MockRedisStreamRepository) Ack(p0 string, p1 string, p2 ...string) error {
+	if m.AckFunc == nil {
+		panic("MockRedisStreamRepository.Ack is not set")
+	}
+	return m.AckFunc(p0, p1, p2...)
+}
+
+func (m *MockRedisStreamRepository) Claim(p0 string, p1 string, p2 string, p3 time.Duration, p4 int64) ([]repositorysdk.StreamMessage, error) {
+	if m.ClaimFunc == nil {
+		panic("MockRedisStreamRepository.Claim is not set")
+	}
+	return m.ClaimFunc(p0, p1, p2, p3, p4)
+}
+
+func (m *MockRedisStreamRepository) Consume(p0 context.Context, p1 string, p2 string, p3 string, p4 repositorysdk.StreamHandler, p5 repositorysdk.StreamConsumerConfig) error {
+	if m.ConsumeFunc == nil {
+		panic("MockRedisStreamRepository.Consume is not set")
+	}
+	return m.ConsumeFunc(p0, p1, p2, p3, p4, p5)
+}
+
+// MockRedisTx is a mock of repositorysdk.RedisTx, a method panics if its function is not set.
+type MockRedisTx struct {
+	GetCacheFunc      func(key string, value interface{}) error
+	GetHashCacheFunc  func(key string, field string) (string, error)
+	SaveCacheFunc     func(key string, value interface{}, ttl int) error
+	SaveHashCacheFunc func(key string, field string, value string, ttl int)
+	RemoveCacheFunc   func(key string)
+}
+
+var _ repositorysdk.RedisTx = (*MockRedisTx)(nil)
+
+func (m *MockRedisTx) GetCache(p0 string, p1 interface{}) error {
+	if m.GetCacheFunc == nil {
+		panic("MockRedisTx.GetCache is not set")
+	}
+	return m.GetCacheFunc(p0, p1)
+}
+
+func (m *MockRedisTx) GetHashCache(p0 string, p1 string) (string, error) {
+	if m.GetHashCacheFunc == nil {
+		panic("MockRedisTx.GetHashCache is not set")
+	}
+	return m.GetHashCacheFunc(p0, p1)
+}
+
+func (m *MockRedisTx) SaveCache(p0 string, p1 interface{}, p2 int) error {
+	if m.SaveCacheFunc == nil {
+		panic("MockRedisTx.SaveCache is not set")
+	}
+	return m.SaveCacheFunc(p0, p1, p2)
+}
+
+func (m *MockRedisTx) SaveHashCache(p0 string, p1 string, p2 string, p3 int) {
+	if m.SaveHashCacheFunc == nil {
+		panic("MockRedisTx.SaveHashCache is not set")
+	}
+	m.SaveHashCacheFunc(p0, p1, p2, p3)
+}
+
+func (m *MockRedisTx) RemoveCache(p0 string) {
+	if m.RemoveCacheFunc == nil {
+		panic("MockRedisTx.RemoveCache is not set")
+	}
+	m.RemoveCacheFunc(p0)
+}
+
+// MockReliableQueue is a mock of repositorysdk.ReliableQueue, a method panics if its function is not set.
+type MockReliableQueue struct {
+	EnqueueReliableFunc     func(payload interface{}) (string, error)
+	DequeueToProcessingFunc func(timeout int) (*repositorysdk.ReliableMessage, error)
+	AckProcessedFunc        func(id string) error
+	RequeueStaleFunc        func() (int64, error)
+	LenFunc                 func() (int64, error)
+	ProcessingFunc          func() (int64, error)
+}
+
+var _ repositorysdk.ReliableQueue = (*MockReliableQueue)(nil)
+
+func (m *MockReliableQueue) EnqueueReliable(p0 interface{}) (string, error) {
+	if m.EnqueueReliableFunc == nil {
+		panic("MockReliableQueue.EnqueueReliable is not set")
+	}
+	return m.EnqueueReliableFunc(p0)
+}
+
+func (m *MockReliableQueue) DequeueToProcessing(p0 int) (*repositorysdk.ReliableMessage, error) {
+	if m.DequeueToProcessingFunc == nil {
+		panic("MockReliableQueue.DequeueToProcessing is not set")
+	}
+	return m.DequeueToProcessingFunc(p0)
+}
+
+func (m *MockReliableQueue) AckProcessed(p0 string) error {
+	if m.AckProcessedFunc == nil {
+		panic("MockReliableQueue.AckProcessed is not set")
+	}
+	return m.AckProcessedFunc(p0)
+}
+
+func (m *MockReliableQueue) RequeueStale() (int64, error) {
+	if m.RequeueStaleFunc == nil {
+		panic("MockReliableQueue.RequeueStale is not set")
+	}
+	return m.RequeueStaleFunc()
+}
+
+func (m *MockReliableQueue) Len() (int64, error) {
+	if m.LenFunc == nil {
+		panic("MockReliableQueue.Len is not set")
+	}
+	return m.LenFunc()
+}
+
+func (m *MockReliableQueue) Processing() (int64, error) {
+	if m.ProcessingFunc == nil {
+		panic("MockReliableQueue.Processing is not set")
+	}
+	return m.ProcessingFunc()
+}
+
+// MockSearchDocumentReader is a mock of repositorysdk.SearchDocumentReader, a method panics if its function is not set.
+type MockSearchDocumentReader struct {
+	GetDocumentsFunc func(ctx context.Context, index string, ids []string) (map[string]map[string]interface{}, error)
+}
+
+var _ repositorysdk.SearchDocumentReader = (*MockSearchDocumentReader)(nil)
+
+func (m *MockSearchDocumentReader) GetDocuments(p0 context.Context, p1 string, p2 []string) (map[string]map[string]interface{}, error) {
+	if m.GetDocumentsFunc == nil {
+		panic("MockSearchDocumentReader.GetDocuments is not set")
+	}
+	return m.GetDocumentsFunc(p0, p1, p2)
+}
+
+// MockSearchIndexer is a mock of repositorysdk.SearchIndexer, a method panics if its function is not set.
+type MockSearchIndexer struct {
+	IndexFunc  func(ctx context.Context, index string, id string, document interface{}) error
+	RemoveFunc func(ctx context.Context, index string, id string) error
+}
+
+var _ repositorysdk.SearchIndexer = (*MockSearchIndexer)(nil)
+
+func (m *MockSearchIndexer) Index(p0 context.Context, p1 string, p2 string, p3 interface{}) error {
+	if m.IndexFunc == nil {
+		panic("MockSearchIndexer.Index is not set")
+	}
+	return m.IndexFunc(p0, p1, p2, p3)
+}
+
+func (m *MockSearchIndexer) Remove(p0 context.Context, p1 string, p2 string) error {
+	if m.RemoveFunc == nil {
+		panic("MockSearchIndexer.Remove is not set")
+	}
+	return m.RemoveFunc(p0, p1, p2)
+}
+
+// MockShardedGormRepository is a mock of repositorysdk.ShardedGormRepository, a method panics if its function is not set.
+type MockShardedGormRepository[T repositorysdk.Entity] struct {
+	ShardFunc      func(key string) repositorysdk.GormRepository[T]
+	ShardsFunc     func() []repositorysdk.GormRepository[T]
+	FindOneFunc    func(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	CreateFunc     func(key string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	UpdateFunc     func(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	DeleteFunc     func(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	FanOutFunc     func(fn func(shard int, repo repositorysdk.GormRepository[T]) error) error
+	FanOutFindFunc func(entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error
+}
+
+var _ repositorysdk.ShardedGormRepository[repositorysdk.Entity] = (*MockShardedGormRepository[repositorysdk.Entity])(nil)
+
+func (m *MockShardedGormRepository[T]) Shard(p0 string) repositorysdk.GormRepository[T] {
+	if m.ShardFunc == nil {
+		panic("MockShardedGormRepository.Shard is not set")
+	}
+	return m.ShardFunc(p0)
+}
+
+func (m *MockShardedGormRepository[T]) Shards() []repositorysdk.GormRepository[T] {
+	if m.ShardsFunc == nil {
+		panic("MockShardedGormRepository.Shards is not set")
+	}
+	return m.ShardsFunc()
+}
+
+func (m *MockShardedGormRepository[T]) FindOne(p0 string, p1 string, p2 T, p3 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.FindOneFunc == nil {
+		panic("MockShardedGormRepository.FindOne is not set")
+	}
+	return m.FindOneFunc(p0, p1, p2, p3...)
+}
+
+func (m *MockShardedGormRepository[T]) Create(p0 string, p1 T, p2 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.CreateFunc == nil {
+		panic("MockShardedGormRepository.Create is not set")
+	}
+	return m.CreateFunc(p0, p1, p2...)
+}
+
+func (m *MockShardedGormRepository[T]) Update(p0 string, p1 string, p2 T, p3 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.UpdateFunc == nil {
+		panic("MockShardedGormRepository.Update is not set")
+	}
+	return m.UpdateFunc(p0, p1, p2, p3...)
+}
+
+func (m *MockShardedGormRepository[T]) Delete(p0 string, p1 string, p2 T, p3 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.DeleteFunc == nil {
+		panic("MockShardedGormRepository.Delete is not set")
+	}
+	return m.DeleteFunc(p0, p1, p2, p3...)
+}
+
+func (m *MockShardedGormRepository[T]) FanOut(p0 func(shard int, repo repositorysdk.GormRepository[T]) error) error {
+	if m.FanOutFunc == nil {
+		panic("MockShardedGormRepository.FanOut is not set")
+	}
+	return m.FanOutFunc(p0)
+}
+
+func (m *MockShardedGormRepository[T]) FanOutFind(p0 *[]T, p1 ...func(db *gorm.DB) *gorm.DB) error {
+	if m.FanOutFindFunc == nil {
+		panic("MockShardedGormRepository.FanOutFind is not set")
+	}
+	return m.FanOutFindFunc(p0, p1...)
+}
+
+// MockTieredCache is a mock of repositorysdk.TieredCache, a method panics if its function is not set.
+type MockTieredCache struct {
+	MockRedisRepository
+	CloseFunc func() error
+}
+
+var _ repositorysdk.TieredCache = (*MockTieredCache)(nil)
+
+func (m *MockTieredCache) Close() error {
+	if m.CloseFunc == nil {
+		panic("MockTieredCache.Close is not set")
+	}
+	return m.CloseFunc()
+}
+
+// MockTimeSeriesCounter is a mock of repositorysdk.TimeSeriesCounter, a method panics if its function is not set.
+type MockTimeSeriesCounter struct {
+	IncrementFunc   func(ctx context.Context, name string, by int64) error
+	IncrementAtFunc func(ctx context.Context, name string, at time.Time, by int64) error
+	SeriesFunc      func(ctx context.Context, name string, size repositorysdk.BucketSize, from time.Time, to time.Time) ([]repositorysdk.TimeBucket, error)
+}
+
+var _ repositorysdk.TimeSeriesCounter = (*MockTimeSeriesCounter)(nil)
+
+func (m *MockTimeSeriesCounter) Increment(p0 context.Context, p1 string, p2 int64) error {
+	if m.IncrementFunc == nil {
+		panic("MockTimeSeriesCounter.Increment is not set")
+	}
+	return m.IncrementFunc(p0, p1, p2)
+}
+
+func (m *MockTimeSeriesCounter) IncrementAt(p0 context.Context, p1 string, p2 time.Time, p3 int64) error {
+	if m.IncrementAtFunc == nil {
+		panic("MockTimeSeriesCounter.IncrementAt is not set")
+	}
+	return m.IncrementAtFunc(p0, p1, p2, p3)
+}
+
+func (m *MockTimeSeriesCounter) Series(p0 context.Context, p1 string, p2 repositorysdk.BucketSize, p3 time.Time, p4 time.Time) ([]repositorysdk.TimeBucket, error) {
+	if m.SeriesFunc == nil {
+		panic("MockTimeSeriesCounter.Series is not set")
+	}
+	return m.SeriesFunc(p0, p1, p2, p3, p4)
+}
+
+// MockWriteAheadRedisRepository is a mock of repositorysdk.WriteAheadRedisRepository, a method panics if its function is not set.
+type MockWriteAheadRedisRepository struct {
+	MockRedisRepository
+	PendingFunc func() int
+	FlushFunc   func() error
+	CloseFunc   func() error
+}
+
+var _ repositorysdk.WriteAheadRedisRepository = (*MockWriteAheadRedisRepository)(nil)
+
+func (m *MockWriteAheadRedisRepository) Pending() int {
+	if m.PendingFunc == nil {
+		panic("MockWriteAheadRedisRepository.Pending is not set")
+	}
+	return m.PendingFunc()
+}
+
+func (m *MockWriteAheadRedisRepository) Flush() error {
+	if m.FlushFunc == nil {
+		panic("MockWriteAheadRedisRepository.Flush is not set")
+	}
+	return m.FlushFunc()
+}
+
+func (m *MockWriteAheadRedisRepository) Close() error {
+	if m.CloseFunc == nil {
+		panic("MockWriteAheadRedisRepository.Close is not set")
+	}
+	return m.CloseFunc()
+}
@@ -0,0 +1,123 @@
+package repositorysdk
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// TableNaming is a GORM naming strategy which adds a prefix and a suffix to every table name, including the names
+// resolved from Entity.TableName() which GORM does not pass to the naming strategy.
+type TableNaming struct {
+	schema.Namer
+	Prefix string
+	Suffix string
+}
+
+// TableName returns the table name of a model without a TableName method.
+func (n TableNaming) TableName(table string) string {
+	return n.Table(n.Namer.TableName(table))
+}
+
+// JoinTableName returns the name of a many-to-many join table.
+func (n TableNaming) JoinTableName(table string) string {
+	return n.Table(n.Namer.JoinTableName(table))
+}
+
+// Table adds the prefix and the suffix to the table name.
+func (n TableNaming) Table(name string) string {
+	return n.Prefix + name + n.Suffix
+}
+
+// UseTableNaming installs the TableNaming with the given prefix and suffix on db. The tables of the entities are
+// resolved with the prefix and suffix by every query, create, update, and delete; the migrations should be run with
+// AutoMigrate so the tables are created under the same names.
+//
+// Parameters:
+// - db: the GORM database connection.
+// - prefix: the prefix of the table names.
+// - suffix: the suffix of the table names.
+//
+// Returns:
+// - error: an error if the callbacks cannot be registered, otherwise nil.
+func UseTableNaming(db *gorm.DB, prefix string, suffix string) error {
+	namer := db.NamingStrategy
+	if current, ok := namer.(TableNaming); ok {
+		namer = current.Namer
+	}
+
+	naming := TableNaming{Namer: namer, Prefix: prefix, Suffix: suffix}
+	db.NamingStrategy = naming
+
+	name := "repositorysdk:table_naming"
+	callbacks := db.Callback()
+
+	if err := callbacks.Create().Before("*").Register(name, applyTableNaming); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("*").Register(name, applyTableNaming); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("*").Register(name, applyTableNaming); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("*").Register(name, applyTableNaming); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("*").Register(name, applyTableNaming); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AutoMigrate migrates the tables of the entities under the names resolved by the TableNaming of db, if any.
+//
+// Parameters:
+// - db: the GORM database connection.
+// - entities: the entities to be migrated.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func AutoMigrate(db *gorm.DB, entities ...Entity) error {
+	for _, entity := range entities {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(entity); err != nil {
+			return err
+		}
+
+		if err := db.Table(tableName(stmt)).AutoMigrate(entity); err != nil {
+			return fmt.Errorf("migrate %s: %w", tableName(stmt), err)
+		}
+	}
+
+	return nil
+}
+
+// applyTableNaming is the callback which replaces the table resolved from the TableName method of the model by the
+// table with the prefix and suffix. The tables set explicitly by `Table` are left as they are.
+func applyTableNaming(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.TableExpr != nil || stmt.Table != stmt.Schema.Table {
+		return
+	}
+
+	stmt.Table = tableName(stmt)
+}
+
+// tableName returns the table of the parsed statement with the TableNaming of its database applied, the name
+// resolved by the naming strategy already carries the prefix and suffix.
+func tableName(stmt *gorm.Statement) string {
+	naming, ok := stmt.DB.NamingStrategy.(TableNaming)
+	if !ok {
+		return stmt.Schema.Table
+	}
+
+	tabler, ok := reflect.New(stmt.Schema.ModelType).Interface().(schema.Tabler)
+	if !ok || tabler.TableName() != stmt.Schema.Table {
+		return stmt.Schema.Table
+	}
+
+	return naming.Table(stmt.Schema.Table)
+}
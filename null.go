@@ -0,0 +1,204 @@
+package repositorysdk
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"sync"
+)
+
+// Null is a value of type T which may be null, it is stored as a nullable column by GORM and encoded as null in json
+// when it is not valid. The column type is the type of T, it is given to GORM by GormDataType and GormDBDataType.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NullOf returns a valid Null holding v.
+func NullOf[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// NullFromPtr returns a Null holding the value of p, it is not valid if p is nil.
+func NullFromPtr[T any](p *T) Null[T] {
+	if p == nil {
+		return Null[T]{}
+	}
+
+	return NullOf(*p)
+}
+
+// MapNull converts the value of n with fn, the result is not valid if n is not valid.
+func MapNull[T any, U any](n Null[T], fn func(T) U) Null[U] {
+	if !n.Valid {
+		return Null[U]{}
+	}
+
+	return NullOf(fn(n.V))
+}
+
+// Ptr returns a pointer to a copy of the value, nil if it is not valid.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+
+	v := n.V
+	return &v
+}
+
+// ValueOr returns the value, or def if it is not valid.
+func (n Null[T]) ValueOr(def T) T {
+	if !n.Valid {
+		return def
+	}
+
+	return n.V
+}
+
+// valueType returns the type of T, e.g. for the search mappings.
+func (n Null[T]) valueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// MarshalJSON encodes the value, or null if it is not valid.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON decodes the value, null makes it not valid.
+func (n *Null[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*n = Null[T]{}
+		return nil
+	}
+
+	if err := json.Unmarshal(b, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+
+	return nil
+}
+
+// Scan implements the sql.Scanner interface, a NULL column makes the value not valid.
+func (n *Null[T]) Scan(src interface{}) error {
+	if src == nil {
+		*n = Null[T]{}
+		return nil
+	}
+
+	if scanner, ok := any(&n.V).(sql.Scanner); ok {
+		if err := scanner.Scan(src); err != nil {
+			return err
+		}
+		n.Valid = true
+		return nil
+	}
+
+	dst := reflect.ValueOf(&n.V).Elem()
+	v := reflect.ValueOf(src)
+
+	switch {
+	case v.Type() == reflect.TypeOf([]byte(nil)) && dst.Type() == v.Type():
+		// the bytes are owned by the driver, they are copied
+		dst.SetBytes(append([]byte(nil), src.([]byte)...))
+	case v.Type().AssignableTo(dst.Type()):
+		dst.Set(v)
+	case dst.Kind() == reflect.String && v.Kind() == reflect.String:
+		dst.SetString(v.String())
+	case dst.Kind() == reflect.String && v.Type() == reflect.TypeOf([]byte(nil)):
+		dst.SetString(string(src.([]byte)))
+	case isNumericKind(v.Kind()) && isNumericKind(dst.Kind()):
+		dst.Set(v.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type %T", src, n.V)
+	}
+	n.Valid = true
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface, the value is NULL if it is not valid.
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	if valuer, ok := any(n.V).(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+// nullColumn holds a value of type T, it is parsed by GORM to find the column type of Null[T].
+type nullColumn[T any] struct {
+	V T
+}
+
+// nullSchemas caches the schemas of the nullColumn types.
+var nullSchemas sync.Map
+
+// valueField returns the field of the value as parsed by GORM, nil if T cannot be stored.
+func (n Null[T]) valueField() *schema.Field {
+	s, err := schema.Parse(&nullColumn[T]{}, &nullSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil
+	}
+
+	return s.FieldsByName["V"]
+}
+
+// GormDataType implements the schema.GormDataTypeInterface interface, the data type is the one of T.
+func (n Null[T]) GormDataType() string {
+	field := n.valueField()
+	if field == nil {
+		return ""
+	}
+
+	return string(field.DataType)
+}
+
+// GormDBDataType returns the column type of T in the database of db, e.g. for AutoMigrate. The type given by the tag
+// `type` of the field is kept.
+func (n Null[T]) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	value := n.valueField()
+	if value == nil || field.DataType != value.DataType {
+		return ""
+	}
+
+	if typer, ok := reflect.New(value.IndirectFieldType).Interface().(interface {
+		GormDBDataType(*gorm.DB, *schema.Field) string
+	}); ok {
+		return typer.GormDBDataType(db, field)
+	}
+
+	// the size of T is lost since the field is a Null, e.g. int64 would be a smallint
+	column := *field
+	if column.Size == 0 {
+		column.Size = value.Size
+	}
+
+	return db.Dialector.DataTypeOf(&column)
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,329 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// OutboxMessage is the entity of a message written in the same transaction as the entity it describes, and delivered
+// by the OutboxRelay once the transaction commits. The messages are partitioned by the id of their aggregate, so the
+// messages of an aggregate are delivered in the order they were written. A message which keeps failing is
+// dead-lettered, DeadAt is set and it is no longer delivered.
+type OutboxMessage struct {
+	ID          uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Topic       string     `json:"topic" gorm:"index"`
+	AggregateID string     `json:"aggregate_id" gorm:"index"`
+	Partition   int        `json:"partition" gorm:"index;not null;default:0"`
+	Payload     []byte     `json:"payload" gorm:"type:jsonb"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"type:timestamp;autoCreateTime:nano"`
+	ProcessedAt *time.Time `json:"processed_at" gorm:"index;type:timestamp"`
+	DeadAt      *time.Time `json:"dead_at" gorm:"index;type:timestamp"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// EnqueueOutbox writes a message into the outbox, the tx should be the transaction which writes the aggregate.
+//
+// Parameters:
+// - tx: the GORM transaction.
+// - topic: the topic of the message, which selects the handler of the relay.
+// - aggregateID: the id of the entity the message describes.
+// - payload: the payload of the message, encoded in json.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func EnqueueOutbox(tx *gorm.DB, topic string, aggregateID string, payload interface{}) error {
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&OutboxMessage{
+		Topic:       topic,
+		AggregateID: aggregateID,
+		Partition:   HashShardKey(aggregateID, OutboxPartitions),
+		Payload:     p,
+	}).Error
+}
+
+// OutboxHandler delivers a message of the outbox, a non-nil error leaves the message to be retried.
+type OutboxHandler func(ctx context.Context, message *OutboxMessage) error
+
+type OutboxRelay interface {
+	Handle(topic string, handler OutboxHandler)
+	ProcessBatch(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration) error
+	ReplaySince(ctx context.Context, since time.Time, topics ...string) (int64, error)
+	ReplayAggregate(ctx context.Context, aggregateID string, topics ...string) (int64, error)
+	BackfillPartitions(ctx context.Context) (int64, error)
+}
+
+// OutboxRelayOption is a setting of the OutboxRelay.
+type OutboxRelayOption func(*outboxRelay)
+
+// WithOutboxMaxAttempts sets the number of failed deliveries after which a message is dead-lettered, so it no longer
+// holds back the next messages of its aggregate. 0 or less never dead-letters the messages.
+func WithOutboxMaxAttempts(n int) OutboxRelayOption {
+	return func(r *outboxRelay) {
+		r.maxAttempts = n
+	}
+}
+
+// outboxLockKey is the first key of the advisory locks held on the partitions of the outbox.
+const outboxLockKey = 0x6f7574
+
+type outboxRelay struct {
+	db          *gorm.DB
+	batchSize   int
+	maxAttempts int
+	handlers    map[string]OutboxHandler
+}
+
+// NewOutboxRelay function that create a new instance of OutboxRelay which delivers the pending messages of the outbox
+// to the handlers of their topic. The handlers must be registered before the relay runs. A message is dead-lettered
+// after OutboxMaxAttempts failed deliveries, unless another limit is set with WithOutboxMaxAttempts.
+func NewOutboxRelay(db *gorm.DB, batchSize int, opts ...OutboxRelayOption) OutboxRelay {
+	if batchSize <= 0 {
+		batchSize = MaximumQueryEntities
+	}
+
+	r := &outboxRelay{
+		db:          db,
+		batchSize:   batchSize,
+		maxAttempts: OutboxMaxAttempts,
+		handlers:    map[string]OutboxHandler{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Handle registers the handler of the topic.
+func (r *outboxRelay) Handle(topic string, handler OutboxHandler) {
+	r.handlers[topic] = handler
+}
+
+// ProcessBatch delivers a batch of pending messages in the order they were written. Each partition of the outbox is
+// processed by a single relay at a time, held by a transaction-level advisory lock, so several relays can run
+// concurrently without reordering the messages of an aggregate. Once a message fails, the next messages of its
+// aggregate are left pending until it is delivered, or until it is dead-lettered after the maximum number of attempts.
+//
+// Parameters:
+// - ctx: the context of the batch.
+//
+// Returns:
+// - int: the number of messages processed, including the failed ones.
+// - error: an error if the outbox cannot be read or updated, otherwise nil.
+func (r *outboxRelay) ProcessBatch(ctx context.Context) (processed int, err error) {
+	defer wrapError(&err, "ProcessBatch", OutboxMessage{}.TableName(), "", time.Now())
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var partitions []int
+		if err := tx.
+			Raw("SELECT p FROM generate_series(0, ?::int) AS p WHERE pg_try_advisory_xact_lock(?::int, p)", OutboxPartitions-1, outboxLockKey).
+			Scan(&partitions).
+			Error; err != nil {
+			return err
+		}
+		if len(partitions) == 0 {
+			return nil
+		}
+
+		var messages []*OutboxMessage
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("processed_at IS NULL AND dead_at IS NULL AND partition IN ?", partitions).
+			Order("id").
+			Limit(r.batchSize).
+			Find(&messages).
+			Error; err != nil {
+			return err
+		}
+
+		blocked := map[string]struct{}{}
+		for _, message := range messages {
+			if _, ok := blocked[message.AggregateID]; ok {
+				continue
+			}
+
+			updates := map[string]interface{}{"attempts": message.Attempts + 1}
+
+			if err := r.deliver(ctx, message); err != nil {
+				updates["last_error"] = err.Error()
+				if r.maxAttempts > 0 && message.Attempts+1 >= r.maxAttempts {
+					// the message is given up, so it no longer holds back its aggregate
+					updates["dead_at"] = time.Now()
+				}
+				blocked[message.AggregateID] = struct{}{}
+			} else {
+				updates["processed_at"] = time.Now()
+				updates["last_error"] = ""
+			}
+
+			if err := tx.Model(message).Updates(updates).Error; err != nil {
+				return err
+			}
+
+			processed++
+		}
+
+		return nil
+	})
+
+	return processed, err
+}
+
+// Run processes the outbox every interval until ctx is done.
+//
+// Parameters:
+// - ctx: the context which stops the relay.
+// - interval: the waiting time when the outbox is drained.
+//
+// Returns:
+// - error: the error of ctx when it is done, or an error if the outbox cannot be read or updated.
+func (r *outboxRelay) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		n, err := r.ProcessBatch(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// BackfillPartitions moves the messages written before the outbox was partitioned, which are all in the partition 0,
+// into the partition of their aggregate. It takes the locks of every partition, so the relays wait for it, and it is
+// meant to be run once after the upgrade.
+//
+// Parameters:
+// - ctx: the context of the request.
+//
+// Returns:
+// - int64: the number of messages moved.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *outboxRelay) BackfillPartitions(ctx context.Context) (moved int64, err error) {
+	defer wrapError(&err, "BackfillPartitions", OutboxMessage{}.TableName(), "", time.Now())
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Exec("SELECT pg_advisory_xact_lock(?::int, p) FROM generate_series(0, ?::int) AS p", outboxLockKey, OutboxPartitions-1).
+			Error; err != nil {
+			return err
+		}
+
+		var aggregateIDs []string
+		if err := tx.
+			Model(&OutboxMessage{}).
+			Where("partition = 0").
+			Distinct("aggregate_id").
+			Pluck("aggregate_id", &aggregateIDs).
+			Error; err != nil {
+			return err
+		}
+
+		partitions := map[int][]string{}
+		for _, aggregateID := range aggregateIDs {
+			if p := HashShardKey(aggregateID, OutboxPartitions); p != 0 {
+				partitions[p] = append(partitions[p], aggregateID)
+			}
+		}
+
+		for p, ids := range partitions {
+			for start := 0; start < len(ids); start += MaximumQueryEntities {
+				end := start + MaximumQueryEntities
+				if end > len(ids) {
+					end = len(ids)
+				}
+
+				res := tx.
+					Model(&OutboxMessage{}).
+					Where("partition = 0 AND aggregate_id IN ?", ids[start:end]).
+					Update("partition", p)
+				if res.Error != nil {
+					return res.Error
+				}
+				moved += res.RowsAffected
+			}
+		}
+
+		return nil
+	})
+
+	return moved, err
+}
+
+// ReplaySince marks the messages written since the given time as pending again, so the relay delivers them again in
+// the order they were written. It is used to rebuild the downstream consumers.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - since: the time of the first message to be replayed.
+// - topics: the topics to be replayed, all the topics if empty.
+//
+// Returns:
+// - int64: the number of messages to be replayed.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *outboxRelay) ReplaySince(ctx context.Context, since time.Time, topics ...string) (replayed int64, err error) {
+	defer wrapError(&err, "ReplaySince", OutboxMessage{}.TableName(), "", time.Now())
+
+	return r.replay(r.db.WithContext(ctx).Where("created_at >= ?", since), topics)
+}
+
+// ReplayAggregate marks the messages of the aggregate as pending again, so the relay delivers them again in the order
+// they were written.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - aggregateID: the id of the aggregate.
+// - topics: the topics to be replayed, all the topics if empty.
+//
+// Returns:
+// - int64: the number of messages to be replayed.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *outboxRelay) ReplayAggregate(ctx context.Context, aggregateID string, topics ...string) (replayed int64, err error) {
+	defer wrapError(&err, "ReplayAggregate", OutboxMessage{}.TableName(), aggregateID, time.Now())
+
+	return r.replay(r.db.WithContext(ctx).Where("aggregate_id = ?", aggregateID), topics)
+}
+
+func (r *outboxRelay) replay(db *gorm.DB, topics []string) (int64, error) {
+	if len(topics) > 0 {
+		db = db.Where("topic IN ?", topics)
+	}
+
+	res := db.
+		Model(&OutboxMessage{}).
+		Where("processed_at IS NOT NULL OR dead_at IS NOT NULL").
+		Updates(map[string]interface{}{"processed_at": nil, "dead_at": nil, "attempts": 0, "last_error": ""})
+
+	return res.RowsAffected, res.Error
+}
+
+func (r *outboxRelay) deliver(ctx context.Context, message *OutboxMessage) error {
+	handler, ok := r.handlers[message.Topic]
+	if !ok {
+		return fmt.Errorf("no handler for topic %s", message.Topic)
+	}
+
+	return handler(ctx, message)
+}
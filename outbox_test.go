@@ -0,0 +1,20 @@
+package repositorysdk
+
+import (
+	"gorm.io/gorm/schema"
+	"sync"
+	"testing"
+)
+
+func TestOutboxPartitionDefaultsToZero(t *testing.T) {
+	s, err := schema.Parse(&OutboxMessage{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+
+	// the column added to an existing outbox gives the partition 0 to the stored messages rather than NULL
+	field := s.LookUpField("partition")
+	if !field.NotNull || !field.HasDefaultValue || field.DefaultValue != "0" {
+		t.Errorf("partition column: not null %v, default %q, want a non-null column with the default 0", field.NotNull, field.DefaultValue)
+	}
+}
@@ -0,0 +1,227 @@
+package repositorysdk
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// QueryPlanViolation is a struct that holds a sequential scan found in the plan of a query.
+type QueryPlanViolation struct {
+	SQL   string
+	Table string
+	Rows  int64
+}
+
+// Error returns the description of the violation.
+func (v QueryPlanViolation) Error() string {
+	return fmt.Sprintf("%s: sequential scan on table %s with about %d rows: %s", ErrSequentialScan, v.Table, v.Rows, v.SQL)
+}
+
+// Unwrap returns ErrSequentialScan.
+func (v QueryPlanViolation) Unwrap() error {
+	return ErrSequentialScan
+}
+
+// QueryPlanGuardConfig is a struct that holds the settings of the query plan guard.
+type QueryPlanGuardConfig struct {
+	// RowThreshold is the number of rows of a table above which a sequential scan is a violation.
+	RowThreshold int64
+	// Strict fails the query with the violation, otherwise the violation is only reported.
+	Strict bool
+	// OnViolation reports the violation, nil means it is logged as an error by the logger of GORM.
+	OnViolation func(ctx context.Context, violation QueryPlanViolation)
+}
+
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	Schema       string     `json:"Schema"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// UseQueryPlanGuard enables the query plan guard on db, the plan of every query, update, and delete is checked with
+// `EXPLAIN` and a sequential scan over a table with more rows than the threshold is reported as a violation. The
+// updates and the deletes are checked before they run, so the strict mode prevents them. It is meant for the staging
+// environments and the tests, as it doubles the number of statements.
+//
+// Parameters:
+// - db: the GORM database connection.
+// - conf: the settings of the guard.
+//
+// Returns:
+// - error: an error if the callbacks cannot be registered, otherwise nil.
+func UseQueryPlanGuard(db *gorm.DB, conf QueryPlanGuardConfig) error {
+	name := "repositorysdk:query_plan_guard"
+	processors := db.Callback()
+
+	if err := processors.Query().After("gorm:query").Register(name, checkQueryPlan(conf, nil)); err != nil {
+		return err
+	}
+	if err := processors.Update().Before("gorm:update").Register(name, checkQueryPlan(conf, buildUpdate)); err != nil {
+		return err
+	}
+	if err := processors.Delete().Before("gorm:delete").Register(name, checkQueryPlan(conf, buildDelete)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkQueryPlan returns the callback which explains the statement of db and reports its violations. The statements
+// which are not built yet are built by build, the callbacks of GORM run them as they are.
+func checkQueryPlan(conf QueryPlanGuardConfig, build func(stmt *gorm.Statement)) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		if db.Error != nil || db.DryRun {
+			return
+		}
+		if build != nil && stmt.SQL.Len() == 0 {
+			build(stmt)
+		}
+		if stmt.SQL.Len() == 0 {
+			return
+		}
+
+		ctx := stmt.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		violations, err := explainQuery(ctx, stmt, conf.RowThreshold)
+		if err != nil {
+			db.Logger.Error(ctx, "explain query %q: %v", stmt.SQL.String(), err)
+			return
+		}
+
+		for _, violation := range violations {
+			if conf.OnViolation != nil {
+				conf.OnViolation(ctx, violation)
+			} else {
+				db.Logger.Error(ctx, "%v", violation)
+			}
+
+			if conf.Strict {
+				_ = db.AddError(violation)
+			}
+		}
+	}
+}
+
+// explainQuery explains the statement and returns its sequential scans over the tables with more rows than the
+// threshold. The statements are run on the connection of stmt, so the guard follows the transactions.
+func explainQuery(ctx context.Context, stmt *gorm.Statement, threshold int64) ([]QueryPlanViolation, error) {
+	query := stmt.SQL.String()
+
+	var raw []byte
+	// the schemas of the relations are only given by the verbose plan
+	if err := stmt.ConnPool.QueryRowContext(ctx, "EXPLAIN (VERBOSE, FORMAT JSON) "+query, stmt.Vars...).Scan(&raw); err != nil {
+		return nil, err
+	}
+
+	var plans []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return nil, err
+	}
+
+	var violations []QueryPlanViolation
+	for _, p := range plans {
+		for _, table := range sequentialScans(p.Plan, nil) {
+			var rows int64
+			err := stmt.ConnPool.QueryRowContext(ctx, "SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)", table).Scan(&rows)
+			if errors.Is(err, sql.ErrNoRows) {
+				// to_regclass is NULL for the relations it cannot resolve, e.g. a function or a dropped table
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if rows > threshold {
+				violations = append(violations, QueryPlanViolation{SQL: query, Table: table, Rows: rows})
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// buildUpdate builds the statement of an update as the update callback of GORM does.
+func buildUpdate(stmt *gorm.Statement) {
+	if stmt.Schema != nil {
+		for _, c := range stmt.Schema.UpdateClauses {
+			stmt.AddClause(c)
+		}
+	}
+
+	stmt.AddClauseIfNotExists(clause.Update{})
+	if _, ok := stmt.Clauses["SET"]; !ok {
+		set := callbacks.ConvertToAssignments(stmt)
+		if len(set) == 0 {
+			return
+		}
+		stmt.AddClause(set)
+	}
+
+	stmt.Build(stmt.BuildClauses...)
+}
+
+// buildDelete builds the statement of a delete as the delete callback of GORM does, the soft deletes are built by
+// their clause.
+func buildDelete(stmt *gorm.Statement) {
+	if stmt.Schema != nil {
+		for _, c := range stmt.Schema.DeleteClauses {
+			stmt.AddClause(c)
+		}
+	}
+	if stmt.SQL.Len() > 0 {
+		return
+	}
+
+	stmt.AddClauseIfNotExists(clause.Delete{})
+
+	if stmt.Schema != nil {
+		_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+		column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+		if len(values) > 0 {
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+		}
+
+		if stmt.ReflectValue.CanAddr() && stmt.Dest != stmt.Model && stmt.Model != nil {
+			_, queryValues = schema.GetIdentityFieldValuesMap(stmt.Context, reflect.ValueOf(stmt.Model), stmt.Schema.PrimaryFields)
+			column, values = schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+			if len(values) > 0 {
+				stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+			}
+		}
+	}
+
+	stmt.AddClauseIfNotExists(clause.From{})
+	stmt.Build(stmt.BuildClauses...)
+}
+
+// sequentialScans collects the tables scanned sequentially by the plan and its sub-plans.
+func sequentialScans(node planNode, tables []string) []string {
+	if node.NodeType == "Seq Scan" {
+		table := node.RelationName
+		if node.Schema != "" {
+			table = node.Schema + "." + table
+		}
+		tables = append(tables, table)
+	}
+
+	for _, child := range node.Plans {
+		tables = sequentialScans(child, tables)
+	}
+
+	return tables
+}
@@ -0,0 +1,565 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+type NamespacedRedisRepository interface {
+	RedisRepository
+	FlushNamespace() (int64, error)
+}
+
+// prefixedRedisRepository is a RedisRepository which adds a prefix to the keys and the channels of repo, the keys
+// returned to the caller are given back without the prefix.
+type prefixedRedisRepository struct {
+	repo   RedisRepository
+	prefix string
+}
+
+func newPrefixedRedisRepository(repo RedisRepository, prefix string) RedisRepository {
+	return &prefixedRedisRepository{repo: repo, prefix: prefix}
+}
+
+// NewRedisRepositoryWithPrefix function that create a new instance of RedisRepository whose keys and channels are
+// prefixed, e.g. `svc:orders:`, so the services sharing a redis do not collide. The keys returned by the repository
+// are given back without the prefix, and the client returned by GetUniversalClient is not prefixed.
+func NewRedisRepositoryWithPrefix(client redis.UniversalClient, prefix string, opts ...RedisOption) NamespacedRedisRepository {
+	return &prefixedRedisRepository{repo: NewRedisRepository(client, opts...), prefix: prefix}
+}
+
+func (r *prefixedRedisRepository) key(key string) string {
+	return r.prefix + key
+}
+
+func (r *prefixedRedisRepository) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+
+	return prefixed
+}
+
+// prefixedRedisPipeline is the RedisPipeline of a prefixedRedisRepository.
+type prefixedRedisPipeline struct {
+	p      RedisPipeline
+	prefix string
+}
+
+func (t *prefixedRedisPipeline) SaveCache(key string, value interface{}, ttl int) error {
+	return t.p.SaveCache(t.prefix+key, value, ttl)
+}
+
+func (t *prefixedRedisPipeline) SaveHashCache(key string, field string, value string, ttl int) {
+	t.p.SaveHashCache(t.prefix+key, field, value, ttl)
+}
+
+func (t *prefixedRedisPipeline) AddSetMember(key string, ttl int, member ...interface{}) {
+	t.p.AddSetMember(t.prefix+key, ttl, member...)
+}
+
+func (t *prefixedRedisPipeline) SetExpire(key string, ttl int) {
+	t.p.SetExpire(t.prefix+key, ttl)
+}
+
+func (t *prefixedRedisPipeline) RemoveCache(key string) {
+	t.p.RemoveCache(t.prefix + key)
+}
+
+// redisKey returns the key stored in redis for the key of the repository.
+func (r *prefixedRedisRepository) redisKey(key string) string {
+	return redisKey(r.repo, r.prefix+key)
+}
+
+// valueCodec returns the codec of the wrapped repository.
+func (r *prefixedRedisRepository) valueCodec() Codec {
+	return codecOf(r.repo)
+}
+
+// maxTTLJitter returns the ttl jitter of the wrapped repository.
+func (r *prefixedRedisRepository) maxTTLJitter() float64 {
+	return ttlJitterOf(r.repo)
+}
+
+func (r *prefixedRedisRepository) GetClient() *redis.Client {
+	return r.repo.GetClient()
+}
+
+func (r *prefixedRedisRepository) GetUniversalClient() redis.UniversalClient {
+	return r.repo.GetUniversalClient()
+}
+
+func (r *prefixedRedisRepository) WithContext(ctx context.Context) RedisRepository {
+	return &prefixedRedisRepository{repo: r.repo.WithContext(ctx), prefix: r.prefix}
+}
+
+func (r *prefixedRedisRepository) HealthCheck(ctx context.Context) error {
+	return r.repo.HealthCheck(ctx)
+}
+
+func (r *prefixedRedisRepository) PoolStats() RedisPoolStats {
+	return r.repo.PoolStats()
+}
+
+func (r *prefixedRedisRepository) SaveCache(key string, value interface{}, ttl int) error {
+	return r.repo.SaveCache(r.key(key), value, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveCacheD(key string, value interface{}, ttl time.Duration) error {
+	return r.SaveCache(key, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) SaveCacheNX(key string, value interface{}, ttl int) (bool, error) {
+	return r.repo.SaveCacheNX(r.key(key), value, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveCacheNXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.SaveCacheNX(key, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) SaveCacheXX(key string, value interface{}, ttl int) (bool, error) {
+	return r.repo.SaveCacheXX(r.key(key), value, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveCacheXXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.SaveCacheXX(key, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) SaveHashCache(key string, field string, value string, ttl int) error {
+	return r.repo.SaveHashCache(r.key(key), field, value, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveHashCacheD(key string, field string, value string, ttl time.Duration) error {
+	return r.SaveHashCache(key, field, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) SaveAllHashCache(key string, value map[string]string, ttl int) error {
+	return r.repo.SaveAllHashCache(r.key(key), value, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveAllHashCacheD(key string, value map[string]string, ttl time.Duration) error {
+	return r.SaveAllHashCache(key, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) AddSetMember(key string, ttl int, member ...interface{}) error {
+	return r.repo.AddSetMember(r.key(key), ttl, member...)
+}
+
+func (r *prefixedRedisRepository) AddSetMemberD(key string, ttl time.Duration, member ...interface{}) error {
+	return r.AddSetMember(key, ttlSeconds(ttl), member...)
+}
+
+func (r *prefixedRedisRepository) GetCache(key string, value interface{}) error {
+	return r.repo.GetCache(r.key(key), value)
+}
+
+func (r *prefixedRedisRepository) GetDelCache(key string, dest interface{}) error {
+	return r.repo.GetDelCache(r.key(key), dest)
+}
+
+func (r *prefixedRedisRepository) SaveMultiCache(values map[string]interface{}, ttl int) error {
+	prefixed := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		prefixed[r.key(key)] = value
+	}
+
+	return r.repo.SaveMultiCache(prefixed, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveMultiCacheD(values map[string]interface{}, ttl time.Duration) error {
+	return r.SaveMultiCache(values, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) GetMultiCache(keys []string, dest map[string]json.RawMessage) error {
+	prefixed := make(map[string]json.RawMessage, len(keys))
+	if err := r.repo.GetMultiCache(r.keys(keys), prefixed); err != nil {
+		return err
+	}
+
+	for key, value := range prefixed {
+		dest[strings.TrimPrefix(key, r.prefix)] = value
+	}
+
+	return nil
+}
+
+func (r *prefixedRedisRepository) GetOrSetCache(key string, ttl int, dest interface{}, loader func() (interface{}, error)) error {
+	return r.repo.GetOrSetCache(r.key(key), ttl, dest, loader)
+}
+
+func (r *prefixedRedisRepository) GetOrSetCacheD(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error {
+	return r.GetOrSetCache(key, ttlSeconds(ttl), dest, loader)
+}
+
+func (r *prefixedRedisRepository) GetHashCache(key string, field string) (string, error) {
+	return r.repo.GetHashCache(r.key(key), field)
+}
+
+func (r *prefixedRedisRepository) GetAllHashCache(key string) (map[string]string, error) {
+	return r.repo.GetAllHashCache(r.key(key))
+}
+
+func (r *prefixedRedisRepository) GetHashFields(key string, fields ...string) (map[string]string, error) {
+	return r.repo.GetHashFields(r.key(key), fields...)
+}
+
+func (r *prefixedRedisRepository) IncrementHashField(key string, field string, by int64) (int64, error) {
+	return r.repo.IncrementHashField(r.key(key), field, by)
+}
+
+func (r *prefixedRedisRepository) RemoveCache(key string) error {
+	return r.repo.RemoveCache(r.key(key))
+}
+
+func (r *prefixedRedisRepository) RemoveCacheByPattern(pattern string) (int64, error) {
+	return r.repo.RemoveCacheByPattern(escapePattern(r.prefix) + pattern)
+}
+
+func (r *prefixedRedisRepository) RemoveSetMember(key string, member interface{}) error {
+	return r.repo.RemoveSetMember(r.key(key), member)
+}
+
+func (r *prefixedRedisRepository) RemoveHashCache(key string, field string) error {
+	return r.repo.RemoveHashCache(r.key(key), field)
+}
+
+func (r *prefixedRedisRepository) SetExpire(key string, ttl int) error {
+	return r.repo.SetExpire(r.key(key), ttl)
+}
+
+func (r *prefixedRedisRepository) SetExpireD(key string, ttl time.Duration) error {
+	return r.SetExpire(key, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) SetExpireAt(key string, at time.Time) error {
+	return r.repo.SetExpireAt(r.key(key), at)
+}
+
+func (r *prefixedRedisRepository) CopyCache(src string, dst string, replace bool) (bool, error) {
+	return r.repo.CopyCache(r.key(src), r.key(dst), replace)
+}
+
+func (r *prefixedRedisRepository) RenameCache(src string, dst string) error {
+	return r.repo.RenameCache(r.key(src), r.key(dst))
+}
+
+func (r *prefixedRedisRepository) CheckSetMember(key string, member interface{}) (bool, error) {
+	return r.repo.CheckSetMember(r.key(key), member)
+}
+
+func (r *prefixedRedisRepository) Exist(key string) (bool, error) {
+	return r.repo.Exist(r.key(key))
+}
+
+func (r *prefixedRedisRepository) ExistMulti(keys ...string) (map[string]bool, error) {
+	prefixed, err := r.repo.ExistMulti(r.keys(keys)...)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool, len(prefixed))
+	for key, ok := range prefixed {
+		exists[strings.TrimPrefix(key, r.prefix)] = ok
+	}
+	return exists, nil
+}
+
+func (r *prefixedRedisRepository) GetTTLMulti(keys ...string) (map[string]time.Duration, error) {
+	prefixed, err := r.repo.GetTTLMulti(r.keys(keys)...)
+	if err != nil {
+		return nil, err
+	}
+
+	ttls := make(map[string]time.Duration, len(prefixed))
+	for key, ttl := range prefixed {
+		ttls[strings.TrimPrefix(key, r.prefix)] = ttl
+	}
+	return ttls, nil
+}
+
+func (r *prefixedRedisRepository) NamespaceStats(prefix string) (*NamespaceStats, error) {
+	stats, err := r.repo.NamespaceStats(r.key(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Prefix = prefix
+	return stats, nil
+}
+
+func (r *prefixedRedisRepository) KeyMemoryUsage(key string) (int64, error) {
+	return r.repo.KeyMemoryUsage(r.key(key))
+}
+
+func (r *prefixedRedisRepository) KeyType(key string) (string, error) {
+	return r.repo.KeyType(r.key(key))
+}
+
+func (r *prefixedRedisRepository) ObjectIdleTime(key string) (time.Duration, error) {
+	return r.repo.ObjectIdleTime(r.key(key))
+}
+
+func (r *prefixedRedisRepository) TopKeysByMemory(pattern string, n int) ([]KeyMemory, error) {
+	top, err := r.repo.TopKeysByMemory(escapePattern(r.prefix)+pattern, n)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range top {
+		top[i].Key = strings.TrimPrefix(top[i].Key, r.prefix)
+	}
+	return top, nil
+}
+
+func (r *prefixedRedisRepository) ScanKeys(pattern string, fn func(key string) error) error {
+	return r.repo.ScanKeys(escapePattern(r.prefix)+pattern, func(key string) error {
+		return fn(strings.TrimPrefix(key, r.prefix))
+	})
+}
+
+func (r *prefixedRedisRepository) RandomSetMembers(key string, n int) ([]string, error) {
+	return r.repo.RandomSetMembers(r.key(key), n)
+}
+
+func (r *prefixedRedisRepository) GetSetMembers(key string) ([]string, error) {
+	return r.repo.GetSetMembers(r.key(key))
+}
+
+func (r *prefixedRedisRepository) CountSetMembers(key string) (int64, error) {
+	return r.repo.CountSetMembers(r.key(key))
+}
+
+func (r *prefixedRedisRepository) UnionSets(keys ...string) ([]string, error) {
+	return r.repo.UnionSets(r.keys(keys)...)
+}
+
+func (r *prefixedRedisRepository) UnionSetsStore(dst string, keys ...string) (int64, error) {
+	return r.repo.UnionSetsStore(r.key(dst), r.keys(keys)...)
+}
+
+func (r *prefixedRedisRepository) IntersectSets(keys ...string) ([]string, error) {
+	return r.repo.IntersectSets(r.keys(keys)...)
+}
+
+func (r *prefixedRedisRepository) IntersectSetsStore(dst string, keys ...string) (int64, error) {
+	return r.repo.IntersectSetsStore(r.key(dst), r.keys(keys)...)
+}
+
+func (r *prefixedRedisRepository) DiffSets(keys ...string) ([]string, error) {
+	return r.repo.DiffSets(r.keys(keys)...)
+}
+
+func (r *prefixedRedisRepository) DiffSetsStore(dst string, keys ...string) (int64, error) {
+	return r.repo.DiffSetsStore(r.key(dst), r.keys(keys)...)
+}
+
+func (r *prefixedRedisRepository) AddUnique(key string, ttl int, items ...interface{}) error {
+	return r.repo.AddUnique(r.key(key), ttl, items...)
+}
+
+func (r *prefixedRedisRepository) AddUniqueD(key string, ttl time.Duration, items ...interface{}) error {
+	return r.AddUnique(key, ttlSeconds(ttl), items...)
+}
+
+func (r *prefixedRedisRepository) CountUnique(keys ...string) (int64, error) {
+	return r.repo.CountUnique(r.keys(keys)...)
+}
+
+func (r *prefixedRedisRepository) SetBit(key string, offset int64, value bool, ttl int) (bool, error) {
+	return r.repo.SetBit(r.key(key), offset, value, ttl)
+}
+
+func (r *prefixedRedisRepository) SetBitD(key string, offset int64, value bool, ttl time.Duration) (bool, error) {
+	return r.SetBit(key, offset, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) GetBit(key string, offset int64) (bool, error) {
+	return r.repo.GetBit(r.key(key), offset)
+}
+
+func (r *prefixedRedisRepository) CountBits(key string) (int64, error) {
+	return r.repo.CountBits(r.key(key))
+}
+
+func (r *prefixedRedisRepository) RandomHashFields(key string, n int) ([]string, error) {
+	return r.repo.RandomHashFields(r.key(key), n)
+}
+
+func (r *prefixedRedisRepository) SaveVersionedCache(key string, value interface{}, ttl int) (string, error) {
+	return r.repo.SaveVersionedCache(r.key(key), value, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveVersionedCacheD(key string, value interface{}, ttl time.Duration) (string, error) {
+	return r.SaveVersionedCache(key, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) SaveVersionedCacheIfMatch(key string, version string, value interface{}, ttl int) (string, error) {
+	return r.repo.SaveVersionedCacheIfMatch(r.key(key), version, value, ttl)
+}
+
+func (r *prefixedRedisRepository) SaveVersionedCacheIfMatchD(key string, version string, value interface{}, ttl time.Duration) (string, error) {
+	return r.SaveVersionedCacheIfMatch(key, version, value, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) GetVersionedCache(key string, version string, value interface{}) (string, bool, error) {
+	return r.repo.GetVersionedCache(r.key(key), version, value)
+}
+
+func (r *prefixedRedisRepository) PushList(key string, ttl int, values ...interface{}) (int64, error) {
+	return r.repo.PushList(r.key(key), ttl, values...)
+}
+
+func (r *prefixedRedisRepository) PushListD(key string, ttl time.Duration, values ...interface{}) (int64, error) {
+	return r.PushList(key, ttlSeconds(ttl), values...)
+}
+
+func (r *prefixedRedisRepository) PopList(key string) (string, error) {
+	return r.repo.PopList(r.key(key))
+}
+
+func (r *prefixedRedisRepository) BPopList(timeout int, keys ...string) (string, string, error) {
+	key, value, err := r.repo.BPopList(timeout, r.keys(keys)...)
+	return strings.TrimPrefix(key, r.prefix), value, err
+}
+
+func (r *prefixedRedisRepository) GetListRange(key string, start int64, stop int64) ([]string, error) {
+	return r.repo.GetListRange(r.key(key), start, stop)
+}
+
+func (r *prefixedRedisRepository) TrimList(key string, start int64, stop int64) error {
+	return r.repo.TrimList(r.key(key), start, stop)
+}
+
+func (r *prefixedRedisRepository) IncrementCache(key string, by int64, ttl int) (int64, error) {
+	return r.repo.IncrementCache(r.key(key), by, ttl)
+}
+
+func (r *prefixedRedisRepository) IncrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return r.IncrementCache(key, by, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) DecrementCache(key string, by int64, ttl int) (int64, error) {
+	return r.repo.DecrementCache(r.key(key), by, ttl)
+}
+
+func (r *prefixedRedisRepository) DecrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return r.DecrementCache(key, by, ttlSeconds(ttl))
+}
+
+func (r *prefixedRedisRepository) WatchTransaction(keys []string, fn func(tx RedisTx) error, retries int) error {
+	return r.repo.WatchTransaction(r.keys(keys), func(tx RedisTx) error {
+		return fn(&prefixedRedisTx{tx: tx, prefix: r.prefix})
+	}, retries)
+}
+
+func (r *prefixedRedisRepository) Pipeline(fn func(p RedisPipeline) error) error {
+	return r.repo.Pipeline(func(p RedisPipeline) error {
+		return fn(&prefixedRedisPipeline{p: p, prefix: r.prefix})
+	})
+}
+
+func (r *prefixedRedisRepository) LoadScript(name string, body string) error {
+	return r.repo.LoadScript(name, body)
+}
+
+func (r *prefixedRedisRepository) RunScript(name string, keys []string, args ...interface{}) (interface{}, error) {
+	return r.repo.RunScript(name, r.keys(keys), args...)
+}
+
+func (r *prefixedRedisRepository) Publish(channel string, payload interface{}) error {
+	return r.repo.Publish(r.key(channel), payload)
+}
+
+func (r *prefixedRedisRepository) ShardedPublish(channel string, payload interface{}) error {
+	return r.repo.ShardedPublish(r.key(channel), payload)
+}
+
+func (r *prefixedRedisRepository) ShardedSubscribe(ctx context.Context, channel string, handler func(payload []byte) error) error {
+	return r.repo.ShardedSubscribe(ctx, r.key(channel), handler)
+}
+
+func (r *prefixedRedisRepository) Subscribe(ctx context.Context, channel string, handler func(payload []byte) error) error {
+	return r.repo.Subscribe(ctx, r.key(channel), handler)
+}
+
+func (r *prefixedRedisRepository) PSubscribe(ctx context.Context, pattern string, handler func(channel string, payload []byte) error) error {
+	return r.repo.PSubscribe(ctx, escapePattern(r.prefix)+pattern, func(channel string, payload []byte) error {
+		return handler(strings.TrimPrefix(channel, r.prefix), payload)
+	})
+}
+
+func (r *prefixedRedisRepository) AcquireLock(key string, ttl time.Duration) (Lock, error) {
+	return r.repo.AcquireLock(r.key(key), ttl)
+}
+
+func (r *prefixedRedisRepository) AcquireSemaphore(key string, limit int, ttl time.Duration) (Lock, error) {
+	return r.repo.AcquireSemaphore(r.key(key), limit, ttl)
+}
+
+func (r *prefixedRedisRepository) AcquireSemaphoreWait(key string, limit int, ttl time.Duration, wait time.Duration) (Lock, error) {
+	return r.repo.AcquireSemaphoreWait(r.key(key), limit, ttl, wait)
+}
+
+func (r *prefixedRedisRepository) ReleaseSemaphore(permit Lock) error {
+	return r.repo.ReleaseSemaphore(permit)
+}
+
+// FlushNamespace removes every key of the namespace, the keys are found with `SCAN` and removed with `UNLINK` in
+// batches. The keys outside of the namespace are never removed, so an empty prefix removes nothing.
+//
+// Returns:
+// - int64: the number of keys removed.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *prefixedRedisRepository) FlushNamespace() (removed int64, err error) {
+	defer wrapError(&err, "FlushNamespace", "", r.prefix, time.Now())
+
+	prefix := r.redisKey("")
+	if prefix == "" {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return unlinkByPattern(ctx, r.GetUniversalClient(), escapePattern(prefix)+"*")
+}
+
+// prefixedRedisTx is the RedisTx of a prefixedRedisRepository.
+type prefixedRedisTx struct {
+	tx     RedisTx
+	prefix string
+}
+
+func (t *prefixedRedisTx) GetCache(key string, value interface{}) error {
+	return t.tx.GetCache(t.prefix+key, value)
+}
+
+func (t *prefixedRedisTx) GetHashCache(key string, field string) (string, error) {
+	return t.tx.GetHashCache(t.prefix+key, field)
+}
+
+func (t *prefixedRedisTx) SaveCache(key string, value interface{}, ttl int) error {
+	return t.tx.SaveCache(t.prefix+key, value, ttl)
+}
+
+func (t *prefixedRedisTx) SaveHashCache(key string, field string, value string, ttl int) {
+	t.tx.SaveHashCache(t.prefix+key, field, value, ttl)
+}
+
+func (t *prefixedRedisTx) RemoveCache(key string) {
+	t.tx.RemoveCache(t.prefix + key)
+}
+
+// redisKey returns the key stored in redis for the key of the repository, the prefixes of the wrapping repositories
+// included. It is used by the helpers which access the client of the repository directly.
+func redisKey(repo RedisRepository, key string) string {
+	if p, ok := repo.(interface{ redisKey(key string) string }); ok {
+		return p.redisKey(key)
+	}
+
+	return key
+}
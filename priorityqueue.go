@@ -0,0 +1,170 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// popPriorityScript pops the item with the highest priority of the queue KEYS[1]. With a visibility timeout of ARGV[1]
+// milliseconds, the items of the in-flight set KEYS[2] whose deadline has passed are first put back into the queue
+// with their priority kept in the hash KEYS[3], and the popped item is moved to the in-flight set until it is acked.
+// The time is taken from the redis server so the instances share the same clock.
+var popPriorityScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local visibility = tonumber(ARGV[1])
+
+if visibility > 0 then
+	local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', now)
+	for _, item in ipairs(expired) do
+		redis.call('ZADD', KEYS[1], redis.call('HGET', KEYS[3], item) or 0, item)
+		redis.call('ZREM', KEYS[2], item)
+		redis.call('HDEL', KEYS[3], item)
+	end
+end
+
+local popped = redis.call('ZPOPMAX', KEYS[1])
+if #popped == 0 then
+	return false
+end
+
+if visibility > 0 then
+	redis.call('ZADD', KEYS[2], now + visibility, popped[1])
+	redis.call('HSET', KEYS[3], popped[1], popped[2])
+end
+
+return {popped[1], popped[2]}
+`)
+
+type PriorityQueue interface {
+	Push(item string, priority float64) error
+	Pop() (string, float64, error)
+	Ack(item string) error
+	Len() (int64, error)
+	InFlight() (int64, error)
+}
+
+type priorityQueue struct {
+	repo       RedisRepository
+	key        string
+	visibility time.Duration
+}
+
+// NewPriorityQueue function that create a new instance of PriorityQueue which pops the item with the highest priority
+// first, backed by a sorted set of redis. With a visibility timeout, a popped item is put back into the queue when it
+// is not acked within the timeout, e.g. when its worker crashed. A zero visibility timeout removes the items when they
+// are popped.
+func NewPriorityQueue(repo RedisRepository, name string, visibility time.Duration) PriorityQueue {
+	return &priorityQueue{
+		repo:       repo,
+		key:        PriorityQueueKeyPrefix + "{" + name + "}",
+		visibility: visibility,
+	}
+}
+
+func (q *priorityQueue) keys() []string {
+	key := redisKey(q.repo, q.key)
+	return []string{key, key + ":inflight", key + ":priorities"}
+}
+
+// Push adds an item to the queue by using the command `ZADD`, pushing an item already in the queue updates its
+// priority.
+//
+// Parameters:
+// - item: the item, e.g. the id of a job.
+// - priority: the priority of the item, the higher the more urgent.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (q *priorityQueue) Push(item string, priority float64) (err error) {
+	defer wrapError(&err, "Push", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return q.repo.GetUniversalClient().ZAdd(ctx, q.keys()[0], &redis.Z{Score: priority, Member: item}).Err()
+}
+
+// Pop removes the item with the highest priority from the queue by using a Lua script, the items of the same
+// priority are popped in reverse lexicographical order. With a visibility timeout the item must be acked once it is
+// processed.
+//
+// Returns:
+// - string: the item.
+// - float64: the priority of the item.
+// - error: ErrKeyNotFound if the queue is empty, otherwise an error if something goes wrong.
+func (q *priorityQueue) Pop() (item string, priority float64, err error) {
+	defer wrapError(&err, "Pop", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := popPriorityScript.Run(ctx, q.repo.GetUniversalClient(), q.keys(), q.visibility.Milliseconds()).Slice()
+	if err != nil {
+		return "", 0, err
+	}
+
+	item, _ = res[0].(string)
+	score, _ := res[1].(string)
+
+	priority, err = strconv.ParseFloat(score, 64)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return item, priority, nil
+}
+
+// Ack removes a popped item from the in-flight items, so it is not put back into the queue. An item whose visibility
+// timeout has elapsed is already back in the queue and is not removed from it.
+//
+// Parameters:
+// - item: the item.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (q *priorityQueue) Ack(item string) (err error) {
+	defer wrapError(&err, "Ack", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys := q.keys()
+	_, err = q.repo.GetUniversalClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, keys[1], item)
+		pipe.HDel(ctx, keys[2], item)
+		return nil
+	})
+
+	return err
+}
+
+// Len returns the number of items waiting in the queue by using the command `ZCARD`.
+//
+// Returns:
+// - int64: the number of items waiting in the queue.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *priorityQueue) Len() (n int64, err error) {
+	defer wrapError(&err, "Len", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return q.repo.GetUniversalClient().ZCard(ctx, q.keys()[0]).Result()
+}
+
+// InFlight returns the number of items popped and not acked yet by using the command `ZCARD`.
+//
+// Returns:
+// - int64: the number of in-flight items.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *priorityQueue) InFlight() (n int64, err error) {
+	defer wrapError(&err, "InFlight", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return q.repo.GetUniversalClient().ZCard(ctx, q.keys()[1]).Result()
+}
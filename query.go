@@ -0,0 +1,121 @@
+package repositorysdk
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+	"time"
+)
+
+// addCacheTagScript adds a cache key to the set of a tag and extends the expiration of the set, so the set lives at
+// least as long as every key it holds.
+var addCacheTagScript = redis.NewScript(`
+local existed = redis.call('EXISTS', KEYS[1])
+redis.call('SADD', KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if ttl <= 0 then
+	return redis.call('PERSIST', KEYS[1])
+end
+local current = redis.call('TTL', KEYS[1])
+if existed == 0 or (current >= 0 and current < ttl) then
+	redis.call('EXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// CachedQuery returns the result of the query from the cache, or runs the query and caches its result when the cache
+// is missing. The cache key is attached to the tags, so the result can be invalidated by InvalidateCacheTags. The
+// result of the query is returned even if it cannot be cached, the error of the cache is then reported to the error
+// handler of OpenTelemetry.
+//
+// Parameters:
+// - cache: the redis repository that holds the result.
+// - db: the GORM database connection given to the query.
+// - key: the cache key of the result.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+// - queryFn: the function that runs the query.
+// - tags: the tags of the result, e.g. the table names the query reads.
+//
+// Returns:
+// - []T: the result of the query.
+// - error: the error of the query or of the read of the cache, otherwise nil.
+func CachedQuery[T any](cache RedisRepository, db *gorm.DB, key string, ttl int, queryFn func(db *gorm.DB) ([]T, error), tags ...string) ([]T, error) {
+	var result []T
+
+	err := cache.GetCache(key, &result)
+	if err == nil {
+		return result, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	result, err = queryFn(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// the key is attached to the tags before it is written, so a cache is never written without its tags, and the tags
+	// outlive the ttl of the key moved by the jitter
+	if err := tagCache(cache, key, maxJitteredTTL(cache, ttl), tags...); err != nil {
+		otel.Handle(err)
+		return result, nil
+	}
+
+	if err := cache.SaveCache(key, result, ttl); err != nil {
+		otel.Handle(err)
+	}
+
+	return result, nil
+}
+
+// InvalidateCacheTags removes every cache attached to the tags and the tags themselves.
+//
+// Parameters:
+// - cache: the redis repository that holds the caches.
+// - tags: the tags to be invalidated.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func InvalidateCacheTags(cache RedisRepository, tags ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := cache.GetUniversalClient()
+
+	for _, tag := range tags {
+		tagKey := redisKey(cache, cacheTagKey(tag))
+
+		keys, err := client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return err
+		}
+
+		if _, err := unlinkKeys(ctx, client, append(keys, tagKey)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tagCache attaches the cache key to the tags.
+func tagCache(cache RedisRepository, key string, ttl int, tags ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, tag := range tags {
+		if err := addCacheTagScript.Run(ctx, cache.GetUniversalClient(), []string{redisKey(cache, cacheTagKey(tag))}, redisKey(cache, key), ttl).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cacheTagKey returns the key of the set which holds the cache keys attached to the tag.
+func cacheTagKey(tag string) string {
+	return CacheTagPrefix + tag
+}
@@ -0,0 +1,57 @@
+package repositorysdk
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestCachedQueryTagsOutliveTheJitteredCaches(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisRepository(redis.NewClient(&redis.Options{Addr: mr.Addr()}), WithTTLJitter(1))
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("products:%d", i)
+		tag := fmt.Sprintf("products-%d", i)
+		if _, err := CachedQuery(cache, nil, key, 100, func(*gorm.DB) ([]int, error) {
+			return []int{i}, nil
+		}, tag); err != nil {
+			t.Fatalf("cached query: %v", err)
+		}
+
+		if keyTTL, tagTTL := mr.TTL(key), mr.TTL(cacheTagKey(tag)); tagTTL < keyTTL {
+			t.Errorf("the tag expires in %s before the cache it tracks, which expires in %s", tagTTL, keyTTL)
+		}
+	}
+
+	if err := InvalidateCacheTags(cache, "products-0"); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	if mr.Exists("products:0") {
+		t.Error("the tagged cache survived the invalidation")
+	}
+}
+
+// failingSaveCache is a RedisRepository whose SaveCache fails.
+type failingSaveCache struct {
+	RedisRepository
+}
+
+func (failingSaveCache) SaveCache(string, interface{}, int) error {
+	return errors.New("redis is read-only")
+}
+
+func TestCachedQueryReturnsTheResultWhenTheCacheWriteFails(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := failingSaveCache{NewRedisRepository(redis.NewClient(&redis.Options{Addr: mr.Addr()}))}
+
+	result, err := CachedQuery(cache, nil, "products", 100, func(*gorm.DB) ([]string, error) {
+		return []string{"keyboard"}, nil
+	}, "products")
+	if err != nil || len(result) != 1 || result[0] != "keyboard" {
+		t.Fatalf("cached query = %v, %v, want the result of the query", result, err)
+	}
+}
@@ -0,0 +1,251 @@
+package repositorysdk
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// QuotaResource is a resource whose usage is limited per tenant.
+type QuotaResource string
+
+const (
+	QuotaRows         QuotaResource = "rows"
+	QuotaStorageBytes QuotaResource = "storage_bytes"
+)
+
+type QuotaManager interface {
+	CheckQuota(ctx context.Context, resource QuotaResource) error
+	CheckQuotaFor(ctx context.Context, resource QuotaResource, n int64) error
+	GetUsage(ctx context.Context, resource QuotaResource) (int64, error)
+	AddUsage(ctx context.Context, resource QuotaResource, n int64) (int64, error)
+	Reconcile(db *gorm.DB, tenantColumn string, entities ...Entity) error
+}
+
+type quotaManager struct {
+	client redis.UniversalClient
+	limits map[QuotaResource]int64
+}
+
+// NewQuotaManager function that create a new instance of QuotaManager which keeps the usage counters of the tenants in redis.
+// The resources without a limit are counted but never exceed their quota.
+func NewQuotaManager(client redis.UniversalClient, limits map[QuotaResource]int64) QuotaManager {
+	return &quotaManager{
+		client: client,
+		limits: limits,
+	}
+}
+
+// WithQuota enables counting the rows created and deleted by the repository per tenant, along with the bytes of the
+// entities embedding FileEntity, and rejects the creation with ErrQuotaExceeded once the tenant of the context has
+// reached its row or storage quota. The usage counted inside WithTransaction is added once the transaction commits.
+func WithQuota(quota QuotaManager) GormOption {
+	return func(o *gormOptions) {
+		o.quota = quota
+	}
+}
+
+// CheckQuota checks the usage of the resource by the tenant of ctx against its limit.
+//
+// Parameters:
+// - ctx: the context which carries the tenant id.
+// - resource: the resource to be checked.
+//
+// Returns:
+// - error: ErrQuotaExceeded if the usage has reached the limit, otherwise an error if something goes wrong.
+func (q *quotaManager) CheckQuota(ctx context.Context, resource QuotaResource) (err error) {
+	defer wrapError(&err, "CheckQuota", "", string(resource), time.Now())
+
+	return q.checkQuota(ctx, resource, 1)
+}
+
+// CheckQuotaFor checks that n more of the resource, e.g. the rows of a batch, fit in the quota of the tenant of ctx.
+//
+// Parameters:
+// - ctx: the context which carries the tenant id.
+// - resource: the resource to be checked.
+// - n: the amount to be added.
+//
+// Returns:
+// - error: ErrQuotaExceeded if the usage plus n exceeds the limit, otherwise an error if something goes wrong.
+func (q *quotaManager) CheckQuotaFor(ctx context.Context, resource QuotaResource, n int64) (err error) {
+	defer wrapError(&err, "CheckQuotaFor", "", string(resource), time.Now())
+
+	return q.checkQuota(ctx, resource, n)
+}
+
+func (q *quotaManager) checkQuota(ctx context.Context, resource QuotaResource, n int64) error {
+	limit, ok := q.limits[resource]
+	if !ok {
+		return nil
+	}
+
+	usage, err := q.GetUsage(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	if usage+n > limit {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// GetUsage retrieves the usage of the resource by the tenant of ctx.
+//
+// Parameters:
+// - ctx: the context which carries the tenant id.
+// - resource: the resource.
+//
+// Returns:
+// - int64: the usage, 0 if it has never been counted.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *quotaManager) GetUsage(ctx context.Context, resource QuotaResource) (usage int64, err error) {
+	defer wrapError(&err, "GetUsage", "", string(resource), time.Now())
+
+	key, err := quotaKey(ctx, resource)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	usage, err = q.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return usage, err
+}
+
+// AddUsage adds n, which may be negative, to the usage of the resource by the tenant of ctx by using the command `INCRBY`.
+//
+// Parameters:
+// - ctx: the context which carries the tenant id.
+// - resource: the resource.
+// - n: the amount to be added.
+//
+// Returns:
+// - int64: the usage after the addition.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *quotaManager) AddUsage(ctx context.Context, resource QuotaResource, n int64) (usage int64, err error) {
+	defer wrapError(&err, "AddUsage", "", string(resource), time.Now())
+
+	key, err := quotaKey(ctx, resource)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return q.client.IncrBy(ctx, key, n).Result()
+}
+
+// Reconcile overwrites the usage counters of every tenant with the usage counted from the data. The rows of the
+// entities are summed in QuotaRows, the sizes of the entities embedding FileEntity in QuotaStorageBytes. The counters of the tenants
+// left without usage are reset to 0.
+//
+// Parameters:
+// - db: the GORM database connection.
+// - tenantColumn: the column which holds the tenant id.
+// - entities: the entities whose rows are counted.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (q *quotaManager) Reconcile(db *gorm.DB, tenantColumn string, entities ...Entity) (err error) {
+	defer wrapError(&err, "Reconcile", "", tenantColumn, time.Now())
+
+	usage := map[QuotaResource]map[string]int64{
+		QuotaRows:         {},
+		QuotaStorageBytes: {},
+	}
+
+	for _, entity := range entities {
+		_, file := entity.(interface{ FileMetadata() *FileEntity })
+
+		sum := "0"
+		if file {
+			sum = "COALESCE(SUM(size), 0)"
+		}
+
+		var counts []struct {
+			TenantID string
+			Count    int64
+			Bytes    int64
+		}
+
+		if err := db.
+			Model(entity).
+			Select(fmt.Sprintf("%s AS tenant_id, COUNT(*) AS count, %s AS bytes", tenantColumn, sum)).
+			Group(tenantColumn).
+			Scan(&counts).
+			Error; err != nil {
+			return err
+		}
+
+		for _, c := range counts {
+			usage[QuotaRows][c.TenantID] += c.Count
+			if file {
+				usage[QuotaStorageBytes][c.TenantID] += c.Bytes
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// the counters of the tenants which no longer use a resource are reset
+	for resource, tenants := range usage {
+		suffix := ":" + string(resource)
+		if err := q.scan(ctx, escapePattern(QuotaKeyPrefix)+"*"+escapePattern(suffix), func(key string) {
+			tenantID := strings.TrimSuffix(strings.TrimPrefix(key, QuotaKeyPrefix), suffix)
+			if _, ok := tenants[tenantID]; !ok {
+				tenants[tenantID] = 0
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	_, err = q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for resource, tenants := range usage {
+			for tenantID, n := range tenants {
+				pipe.Set(ctx, quotaTenantKey(tenantID, resource), n, 0)
+			}
+		}
+		return nil
+	})
+
+	return err
+}
+
+// scan calls fn with every key matching the pattern, on every master of a cluster.
+func (q *quotaManager) scan(ctx context.Context, pattern string, fn func(key string)) error {
+	return forEachRedisNode(ctx, q.client, func(ctx context.Context, node redis.UniversalClient) error {
+		iter := node.Scan(ctx, 0, pattern, ScanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			fn(iter.Val())
+		}
+		return iter.Err()
+	})
+}
+
+// quotaKey returns the key of the usage counter of the resource by the tenant of ctx.
+func quotaKey(ctx context.Context, resource QuotaResource) (string, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return "", ErrMissingTenant
+	}
+
+	return quotaTenantKey(tenantID, resource), nil
+}
+
+func quotaTenantKey(tenantID string, resource QuotaResource) string {
+	return fmt.Sprintf("%s%s:%s", QuotaKeyPrefix, tenantID, resource)
+}
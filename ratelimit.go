@@ -0,0 +1,76 @@
+package repositorysdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// slidingWindowScript records a request in the sliding window log KEYS[1] if fewer than ARGV[1] requests were made
+// within the last ARGV[2] microseconds, the time is taken from the redis server so the instances share the same clock.
+// It returns whether the request is allowed and the number of remaining requests.
+var slidingWindowScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+
+local count = redis.call('ZCARD', KEYS[1])
+if count >= limit then
+	return {0, 0}
+end
+
+redis.call('ZADD', KEYS[1], now, ARGV[3])
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+
+return {1, limit - count - 1}
+`)
+
+type RateLimiter interface {
+	Allow(key string, limit int, window time.Duration) (bool, int, error)
+}
+
+type rateLimiter struct {
+	repo RedisRepository
+}
+
+// NewRateLimiter function that create a new instance of RateLimiter which keeps a sliding window log of the requests
+// of each key in redis
+func NewRateLimiter(repo RedisRepository) RateLimiter {
+	return &rateLimiter{repo: repo}
+}
+
+// Allow records a request of the key if fewer than limit requests were allowed within the last window, by using a
+// Lua script over a sorted set. The rejected requests are not recorded.
+//
+// Parameters:
+// - key: the key of the rate limit, e.g. the user id or the client ip.
+// - limit: the maximum number of requests within the window.
+// - window: the duration of the sliding window.
+//
+// Returns:
+// - bool: true if the request is allowed.
+// - int: the number of requests remaining within the window.
+// - error: an error if something goes wrong, otherwise nil.
+func (l *rateLimiter) Allow(key string, limit int, window time.Duration) (allowed bool, remaining int, err error) {
+	defer wrapError(&err, "Allow", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return false, 0, err
+	}
+
+	res, err := slidingWindowScript.Run(ctx, l.repo.GetUniversalClient(), []string{RateLimitKeyPrefix + key}, limit, window.Microseconds(), hex.EncodeToString(b)).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	return res[0] == 1, int(res[1]), nil
+}
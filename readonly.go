@@ -0,0 +1,148 @@
+package repositorysdk
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"sync/atomic"
+	"time"
+)
+
+type readOnlyRepository[T Entity] struct {
+	GormRepository[T]
+	replicas []GormRepository[T]
+	next     uint32
+}
+
+// ReadOnly returns a repository which rejects the writes of repo with ErrReadOnly, and runs WithTransaction in a
+// read-only transaction. When replicas are given, the queries are routed to the replicas in round-robin, with the same
+// options as repo.
+//
+// Parameters:
+// - repo: the repository to be wrapped.
+// - replicas: the GORM database connections of the read replicas (optional).
+//
+// Returns:
+// - GormRepository[T]: the read-only repository.
+func ReadOnly[T Entity](repo GormRepository[T], replicas ...*gorm.DB) GormRepository[T] {
+	ro := &readOnlyRepository[T]{GormRepository: repo}
+	for _, replica := range replicas {
+		ro.replicas = append(ro.replicas, repositoryWithDB(repo, replica))
+	}
+
+	return ro
+}
+
+// reader returns the repository which runs the next query.
+func (r *readOnlyRepository[T]) reader() GormRepository[T] {
+	if len(r.replicas) == 0 {
+		return r.GormRepository
+	}
+
+	n := atomic.AddUint32(&r.next, 1)
+	return r.replicas[int(n-1)%len(r.replicas)]
+}
+
+func (r *readOnlyRepository[T]) GetDB() *gorm.DB {
+	return r.reader().GetDB()
+}
+
+func (r *readOnlyRepository[T]) FindAll(metadata *PaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	return r.reader().FindAll(metadata, entities, scope...)
+}
+
+func (r *readOnlyRepository[T]) FindAllWithRelations(metadata *PaginationMetadata, entities *[]T, relations ...string) error {
+	return r.reader().FindAllWithRelations(metadata, entities, relations...)
+}
+
+func (r *readOnlyRepository[T]) FindAllCursor(metadata *CursorPaginationMetadata, entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	return r.reader().FindAllCursor(metadata, entities, scope...)
+}
+
+func (r *readOnlyRepository[T]) FindOne(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	return r.reader().FindOne(id, entity, scope...)
+}
+
+func (r *readOnlyRepository[T]) FindAsOf(id string, at time.Time, entity T) error {
+	return r.reader().FindAsOf(id, at, entity)
+}
+
+func (r *readOnlyRepository[T]) Create(entity T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "Create", entityTypeName[T](), "", time.Now())
+
+	return ErrReadOnly
+}
+
+func (r *readOnlyRepository[T]) CreateMany(entities []T, batchSize int, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "CreateMany", entityTypeName[T](), "", time.Now())
+
+	return ErrReadOnly
+}
+
+func (r *readOnlyRepository[T]) Upsert(entity T, conflictColumns []string, updateColumns []string) (err error) {
+	defer wrapError(&err, "Upsert", entityTypeName[T](), "", time.Now())
+
+	return ErrReadOnly
+}
+
+func (r *readOnlyRepository[T]) UpsertMany(entities []T, batchSize int, conflictColumns []string, updateColumns []string) (err error) {
+	defer wrapError(&err, "UpsertMany", entityTypeName[T](), "", time.Now())
+
+	return ErrReadOnly
+}
+
+func (r *readOnlyRepository[T]) Update(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "Update", entityTypeName[T](), id, time.Now())
+
+	return ErrReadOnly
+}
+
+func (r *readOnlyRepository[T]) Delete(id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "Delete", entityTypeName[T](), id, time.Now())
+
+	return ErrReadOnly
+}
+
+func (r *readOnlyRepository[T]) Clone(src T, overrides ...func(*T)) (clone T, err error) {
+	defer wrapError(&err, "Clone", entityTypeName[T](), "", time.Now())
+
+	return clone, ErrReadOnly
+}
+
+// WithContext returns a copy of the read-only repository whose queries run with ctx, on the replicas as well.
+func (r *readOnlyRepository[T]) WithContext(ctx context.Context) GormRepository[T] {
+	ro := &readOnlyRepository[T]{GormRepository: r.GormRepository.WithContext(ctx)}
+	for _, replica := range r.replicas {
+		ro.replicas = append(ro.replicas, replica.WithContext(ctx))
+	}
+
+	return ro
+}
+
+// WithTransaction runs a list of functions inside a single read-only transaction, the writes made by the functions
+// are rejected by the database.
+func (r *readOnlyRepository[T]) WithTransaction(fns ...func(tx *gorm.DB) error) (err error) {
+	defer wrapError(&err, "WithTransaction", entityTypeName[T](), "", time.Now())
+
+	return r.reader().GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, fn := range fns {
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, &sql.TxOptions{ReadOnly: true})
+}
+
+// repositoryWithDB returns a copy of repo which runs on db, the options of repo are kept when it is a gormRepository.
+func repositoryWithDB[T Entity](repo GormRepository[T], db *gorm.DB) GormRepository[T] {
+	r, ok := repo.(*gormRepository[T])
+	if !ok {
+		return NewGormRepository[T](db)
+	}
+
+	c := *r
+	c.db = db
+
+	return &c
+}
@@ -2,116 +2,423 @@ package repositorysdk
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
+	"strings"
+	"sync"
 	"time"
 )
 
 type RedisRepository interface {
 	SaveCache(string, interface{}, int) error
+	SaveCacheD(key string, value interface{}, ttl time.Duration) error
+	SaveCacheNX(key string, value interface{}, ttl int) (bool, error)
+	SaveCacheNXD(key string, value interface{}, ttl time.Duration) (bool, error)
+	SaveCacheXX(key string, value interface{}, ttl int) (bool, error)
+	SaveCacheXXD(key string, value interface{}, ttl time.Duration) (bool, error)
 	SaveHashCache(string, string, string, int) error
+	SaveHashCacheD(key string, field string, value string, ttl time.Duration) error
 	SaveAllHashCache(string, map[string]string, int) error
+	SaveAllHashCacheD(key string, value map[string]string, ttl time.Duration) error
 	AddSetMember(key string, ttl int, member ...interface{}) error
+	AddSetMemberD(key string, ttl time.Duration, member ...interface{}) error
 	GetCache(string, interface{}) error
+	GetDelCache(key string, dest interface{}) error
+	SaveMultiCache(values map[string]interface{}, ttl int) error
+	SaveMultiCacheD(values map[string]interface{}, ttl time.Duration) error
+	GetMultiCache(keys []string, dest map[string]json.RawMessage) error
+	GetOrSetCache(key string, ttl int, dest interface{}, loader func() (interface{}, error)) error
+	GetOrSetCacheD(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error
 	GetHashCache(string, string) (string, error)
 	GetAllHashCache(string) (map[string]string, error)
+	GetHashFields(key string, fields ...string) (map[string]string, error)
+	IncrementHashField(key string, field string, by int64) (int64, error)
 	RemoveCache(string) error
+	RemoveCacheByPattern(pattern string) (int64, error)
 	RemoveSetMember(key string, member interface{}) error
 	RemoveHashCache(key string, field string) error
 	SetExpire(string, int) error
+	SetExpireD(key string, ttl time.Duration) error
+	SetExpireAt(key string, at time.Time) error
+	CopyCache(src string, dst string, replace bool) (bool, error)
+	RenameCache(src string, dst string) error
 	CheckSetMember(key string, member interface{}) (bool, error)
 	Exist(key string) (bool, error)
+	ExistMulti(keys ...string) (map[string]bool, error)
+	GetTTLMulti(keys ...string) (map[string]time.Duration, error)
+	NamespaceStats(prefix string) (*NamespaceStats, error)
+	KeyMemoryUsage(key string) (int64, error)
+	KeyType(key string) (string, error)
+	ObjectIdleTime(key string) (time.Duration, error)
+	TopKeysByMemory(pattern string, n int) ([]KeyMemory, error)
+	ScanKeys(pattern string, fn func(key string) error) error
+	RandomSetMembers(key string, n int) ([]string, error)
+	GetSetMembers(key string) ([]string, error)
+	CountSetMembers(key string) (int64, error)
+	UnionSets(keys ...string) ([]string, error)
+	UnionSetsStore(dst string, keys ...string) (int64, error)
+	IntersectSets(keys ...string) ([]string, error)
+	IntersectSetsStore(dst string, keys ...string) (int64, error)
+	DiffSets(keys ...string) ([]string, error)
+	DiffSetsStore(dst string, keys ...string) (int64, error)
+	AddUnique(key string, ttl int, items ...interface{}) error
+	AddUniqueD(key string, ttl time.Duration, items ...interface{}) error
+	CountUnique(keys ...string) (int64, error)
+	SetBit(key string, offset int64, value bool, ttl int) (bool, error)
+	SetBitD(key string, offset int64, value bool, ttl time.Duration) (bool, error)
+	GetBit(key string, offset int64) (bool, error)
+	CountBits(key string) (int64, error)
+	RandomHashFields(key string, n int) ([]string, error)
+	SaveVersionedCache(key string, value interface{}, ttl int) (string, error)
+	SaveVersionedCacheD(key string, value interface{}, ttl time.Duration) (string, error)
+	SaveVersionedCacheIfMatch(key string, version string, value interface{}, ttl int) (string, error)
+	SaveVersionedCacheIfMatchD(key string, version string, value interface{}, ttl time.Duration) (string, error)
+	GetVersionedCache(key string, version string, value interface{}) (string, bool, error)
+	PushList(key string, ttl int, values ...interface{}) (int64, error)
+	PushListD(key string, ttl time.Duration, values ...interface{}) (int64, error)
+	PopList(key string) (string, error)
+	BPopList(timeout int, keys ...string) (string, string, error)
+	GetListRange(key string, start int64, stop int64) ([]string, error)
+	TrimList(key string, start int64, stop int64) error
+	IncrementCache(key string, by int64, ttl int) (int64, error)
+	IncrementCacheD(key string, by int64, ttl time.Duration) (int64, error)
+	DecrementCache(key string, by int64, ttl int) (int64, error)
+	DecrementCacheD(key string, by int64, ttl time.Duration) (int64, error)
+	WatchTransaction(keys []string, fn func(tx RedisTx) error, retries int) error
+	Pipeline(fn func(p RedisPipeline) error) error
+	LoadScript(name string, body string) error
+	RunScript(name string, keys []string, args ...interface{}) (interface{}, error)
+	Publish(channel string, payload interface{}) error
+	ShardedPublish(channel string, payload interface{}) error
+	ShardedSubscribe(ctx context.Context, channel string, handler func(payload []byte) error) error
+	Subscribe(ctx context.Context, channel string, handler func(payload []byte) error) error
+	PSubscribe(ctx context.Context, pattern string, handler func(channel string, payload []byte) error) error
+	AcquireLock(key string, ttl time.Duration) (Lock, error)
+	AcquireSemaphore(key string, limit int, ttl time.Duration) (Lock, error)
+	AcquireSemaphoreWait(key string, limit int, ttl time.Duration, wait time.Duration) (Lock, error)
+	ReleaseSemaphore(permit Lock) error
+	HealthCheck(ctx context.Context) error
+	PoolStats() RedisPoolStats
 	GetClient() *redis.Client
+	GetUniversalClient() redis.UniversalClient
+	WithContext(ctx context.Context) RedisRepository
 }
 
-const RedisKeepTTL = 0
+// RedisKeepTTL is the ttl which keeps the current expiration time of a cache when it is saved again, by using the
+// option `KEEPTTL` of the command `SET`. It used to be 0, which means no expiration time.
+const RedisKeepTTL = -1
+
+const (
+	versionedCacheValueField   = "value"
+	versionedCacheVersionField = "version"
+)
+
+// saveVersionedCacheIfMatchScript writes the value and version of a versioned cache only if the stored version
+// equals ARGV[1], an empty ARGV[1] means the cache must not exist.
+var saveVersionedCacheIfMatchScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'version')
+if (current or '') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'value', ARGV[2], 'version', ARGV[3])
+if tonumber(ARGV[4]) > 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[4])
+else
+	redis.call('PERSIST', KEYS[1])
+end
+return 1
+`)
+
+// incrementCacheScript increments the counter by ARGV[1] and sets its expiration time to ARGV[2] seconds if the
+// counter has none, so the expiration time is set once when the counter is created.
+var incrementCacheScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('TTL', KEYS[1]) == -1 then
+	redis.call('EXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// NamespaceStats is a struct that holds the number of keys sharing a prefix and their approximate memory usage,
+// estimated from a sample of the keys.
+type NamespaceStats struct {
+	Prefix         string
+	KeyCount       int64
+	SampledKeys    int64
+	SampledBytes   int64
+	EstimatedBytes int64
+}
+
+// RedisPoolStats is a struct that holds the statistics of the connection pool of a redis client, summed over the nodes
+// of a cluster.
+type RedisPoolStats struct {
+	// Hits is the number of times a free connection was found in the pool.
+	Hits uint32
+	// Misses is the number of times a free connection was not found in the pool.
+	Misses uint32
+	// Timeouts is the number of times a connection could not be taken from the pool in time.
+	Timeouts uint32
+	// TotalConns is the number of connections in the pool.
+	TotalConns uint32
+	// IdleConns is the number of idle connections in the pool.
+	IdleConns uint32
+	// StaleConns is the number of stale connections removed from the pool.
+	StaleConns uint32
+}
 
 type redisRepository struct {
-	client *redis.Client
+	client  redis.UniversalClient
+	scripts *sync.Map
+	loads   *singleflight.Group
+	ctx     context.Context
+	redisOptions
 }
 
-func NewRedisRepository(client *redis.Client) RedisRepository {
-	return &redisRepository{client: client}
+// NewRedisRepository function that create a new instance of RedisRepository, the values are encoded in json unless
+// another codec is set with WithCodec.
+func NewRedisRepository(client redis.UniversalClient, opts ...RedisOption) RedisRepository {
+	r := &redisRepository{
+		client:       client,
+		scripts:      &sync.Map{},
+		loads:        &singleflight.Group{},
+		redisOptions: redisOptions{codec: JSONCodec{}},
+	}
+
+	for _, opt := range opts {
+		opt(&r.redisOptions)
+	}
+
+	return r
+}
+
+// valueCodec returns the codec of the values.
+func (r *redisRepository) valueCodec() Codec {
+	return r.codec
 }
 
 // GetClient get the redis client
 //
 // Returns:
-// - *redis.Client
+// - *redis.Client: the client, or nil when the repository runs on a cluster or failover client, see GetUniversalClient.
 func (r *redisRepository) GetClient() *redis.Client {
+	client, _ := r.client.(*redis.Client)
+	return client
+}
+
+// GetUniversalClient get the redis client whatever its kind, single-node, cluster, or failover.
+//
+// Returns:
+// - redis.UniversalClient
+func (r *redisRepository) GetUniversalClient() redis.UniversalClient {
 	return r.client
 }
 
+// WithContext returns a copy of the repository whose calls run under ctx, e.g. the context of a request, so they are
+// canceled with it and their spans join its trace. The calls keep their own timeout.
+//
+// Parameters:
+// - ctx: the context of the calls.
+//
+// Returns:
+// - RedisRepository: the copy of the repository.
+func (r *redisRepository) WithContext(ctx context.Context) RedisRepository {
+	c := *r
+	c.ctx = ctx
+
+	return &c
+}
+
+// context returns the context of the calls, set by WithContext.
+func (r *redisRepository) context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+
+	return r.ctx
+}
+
+// HealthCheck checks that redis can be reached by using the command `PING`, e.g. for a readiness probe. The error of a
+// failed check carries the statistics of the connection pool, so an exhausted pool is told apart from a server which
+// is down.
+//
+// Parameters:
+// - ctx: the context of the check.
+//
+// Returns:
+// - error: an error if redis cannot be reached, otherwise nil.
+func (r *redisRepository) HealthCheck(ctx context.Context) (err error) {
+	defer r.observe(&err, "HealthCheck", "", "", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		stats := r.PoolStats()
+		return fmt.Errorf("%w (pool: %d connections, %d idle, %d timeouts)", err, stats.TotalConns, stats.IdleConns, stats.Timeouts)
+	}
+
+	return nil
+}
+
+// PoolStats returns the statistics of the connection pool of the client.
+//
+// Returns:
+// - RedisPoolStats: the statistics of the pool.
+func (r *redisRepository) PoolStats() RedisPoolStats {
+	stats := r.client.PoolStats()
+
+	return RedisPoolStats{
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Timeouts:   stats.Timeouts,
+		TotalConns: stats.TotalConns,
+		IdleConns:  stats.IdleConns,
+		StaleConns: stats.StaleConns,
+	}
+}
+
 // SaveCache saves cache to redis by using the command `SET`.
 // Zero expiration time means no expiration time for cache.
 //
 // Parameters:
 // - key: the cache key.
 // - value: the cache value to be saved.
-// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time, RedisKeepTTL keeps the current one.
 //
 // Returns:
 // - err: an error if something goes wrong, otherwise nil.
 func (r *redisRepository) SaveCache(key string, value interface{}, ttl int) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer r.observe(&err, "SaveCache", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
-	v, err := json.Marshal(value)
+	v, err := encode(r.codec, value)
 	if err != nil {
 		return
 	}
 
-	return r.client.Set(ctx, key, v, time.Duration(ttl)*time.Second).Err()
+	if r.earlyRefresh > 0 && ttl > 0 {
+		return r.saveCacheEarly(ctx, key, v, ttl)
+	}
+
+	return r.client.Set(ctx, key, v, cacheExpiration(ttl)).Err()
 }
 
-// SaveHashCache saves a single field cache to redis.
+// SaveCacheNX saves cache to redis only if it does not exist by using the command `SET` with the option `NX`, e.g. for
+// the idempotency tokens.
 //
 // Parameters:
 // - key: the cache key.
-// - field: the cache field to be saved.
 // - value: the cache value to be saved.
-// - ttl: the expiration time for cache in seconds.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
 //
 // Returns:
+// - bool: true if the cache is saved, false if it already exists.
 // - err: an error if something goes wrong, otherwise nil.
-func (r *redisRepository) SaveHashCache(key string, field string, value string, ttl int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) SaveCacheNX(key string, value interface{}, ttl int) (saved bool, err error) {
+	defer r.observe(&err, "SaveCacheNX", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
-	if err := r.client.HSet(ctx, key, field, value).Err(); err != nil {
-		return err
+	v, err := encode(r.codec, value)
+	if err != nil {
+		return false, err
 	}
 
-	if ttl > 0 {
-		return r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Err()
+	return r.client.SetNX(ctx, key, v, cacheExpiration(ttl)).Result()
+}
+
+// SaveCacheXX saves cache to redis only if it already exists by using the command `SET` with the option `XX`.
+//
+// Parameters:
+// - key: the cache key.
+// - value: the cache value to be saved.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time, RedisKeepTTL keeps the current one.
+//
+// Returns:
+// - bool: true if the cache is saved, false if it does not exist.
+// - err: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) SaveCacheXX(key string, value interface{}, ttl int) (saved bool, err error) {
+	defer r.observe(&err, "SaveCacheXX", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	v, err := encode(r.codec, value)
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	return r.client.SetXX(ctx, key, v, cacheExpiration(ttl)).Result()
 }
 
-// SaveAllHashCache saves multiple field cache to redis.
+// SaveHashCache saves a single field cache to redis by using the commands `HSET` and `EXPIRE` in a transaction, so the
+// field is never saved without its expiration time. With WithHashFieldExpiry the ttl is set on the field by `HEXPIRE`.
 //
 // Parameters:
 // - key: the cache key.
-// - value: a map containing the fields and values to be saved.
+// - field: the cache field to be saved.
+// - value: the cache value to be saved.
 // - ttl: the expiration time for cache in seconds.
 //
 // Returns:
 // - err: an error if something goes wrong, otherwise nil.
-func (r *redisRepository) SaveAllHashCache(key string, value map[string]string, ttl int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) SaveHashCache(key string, field string, value string, ttl int) (err error) {
+	defer r.observe(&err, "SaveHashCache", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
-	if err := r.client.HSet(ctx, key, value).Err(); err != nil {
-		return err
-	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, field, value)
+		r.expireHash(ctx, pipe, key, ttl, field)
+		return nil
+	})
 
-	if ttl > 0 {
-		return r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Err()
+	return err
+}
+
+// SaveAllHashCache saves multiple field cache to redis by using the commands `HSET` and `EXPIRE` in a transaction, so
+// the fields are never saved without their expiration time. With WithHashFieldExpiry the ttl is set on the fields by
+// `HEXPIRE`.
+//
+// Parameters:
+// - key: the cache key.
+// - value: a map containing the fields and values to be saved.
+// - ttl: the expiration time for cache in seconds.
+//
+// Returns:
+// - err: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) SaveAllHashCache(key string, value map[string]string, ttl int) (err error) {
+	defer r.observe(&err, "SaveAllHashCache", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
 
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	fields := make([]string, 0, len(value))
+	for field := range value {
+		fields = append(fields, field)
 	}
 
-	return nil
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, value)
+		r.expireHash(ctx, pipe, key, ttl, fields...)
+		return nil
+	})
+
+	return err
 }
 
 // GetHashCache retrieves a single field cache from redis.
@@ -122,9 +429,11 @@ func (r *redisRepository) SaveAllHashCache(key string, value map[string]string,
 //
 // Returns:
 // - string: the cache value if it exists.
-// - err: an error if something goes wrong, otherwise nil.
-func (r *redisRepository) GetHashCache(key string, field string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// - err: ErrCacheMiss if the field does not exist, otherwise an error if something goes wrong.
+func (r *redisRepository) GetHashCache(key string, field string) (value string, err error) {
+	defer r.observe(&err, "GetHashCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
 	return r.client.HGet(ctx, key, field).Result()
@@ -138,13 +447,69 @@ func (r *redisRepository) GetHashCache(key string, field string) (string, error)
 // Returns:
 // - map[string]string: a map containing all the fields and their values if the hash exists.
 // - error: an error if something goes wrong, otherwise nil.
-func (r *redisRepository) GetAllHashCache(key string) (map[string]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) GetAllHashCache(key string) (values map[string]string, err error) {
+	defer r.observe(&err, "GetAllHashCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
 	return r.client.HGetAll(ctx, key).Result()
 }
 
+// GetHashFields retrieves the given fields of a hash cache by using the command `HMGET`.
+//
+// Parameters:
+// - key: the cache key.
+// - fields: the cache fields to be retrieved.
+//
+// Returns:
+// - map[string]string: a map containing the fields which exist and their values.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) GetHashFields(key string, fields ...string) (values map[string]string, err error) {
+	defer r.observe(&err, "GetHashFields", "", key, time.Now())
+
+	values = make(map[string]string, len(fields))
+	if len(fields) == 0 {
+		return values, nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	res, err := r.client.HMGet(ctx, key, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range res {
+		if v, ok := v.(string); ok {
+			values[fields[i]] = v
+		}
+	}
+
+	return values, nil
+}
+
+// IncrementHashField atomically increments a counter stored in a field of a hash by using the command `HINCRBY`, a
+// missing field starts from 0.
+//
+// Parameters:
+// - key: the cache key.
+// - field: the counter field.
+// - by: the increment, negative to decrement.
+//
+// Returns:
+// - int64: the value of the counter after the increment.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) IncrementHashField(key string, field string, by int64) (value int64, err error) {
+	defer r.observe(&err, "IncrementHashField", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.HIncrBy(ctx, key, field, by).Result()
+}
+
 // RemoveHashCache remove a single field of hash cache.
 //
 // Parameters:
@@ -153,34 +518,226 @@ func (r *redisRepository) GetAllHashCache(key string) (map[string]string, error)
 //
 // Returns:
 // - err: an error if something goes wrong, otherwise nil.
-func (r *redisRepository) RemoveHashCache(key string, field string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) RemoveHashCache(key string, field string) (err error) {
+	defer r.observe(&err, "RemoveHashCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
 	return r.client.HDel(ctx, key, field).Err()
 }
 
 // GetCache retrieves a cache from redis.
+// With WithEarlyRefresh, a cache close to its expiration is reported early as a miss, so a caller saves it again.
 //
 // Parameters:
 // - key: the cache key.
 // - value: a pointer to the object that will hold the unmarshalled cache value.
 //
 // Returns:
-// - error: an error if something goes wrong, otherwise nil.
+// - error: ErrCacheMiss if the cache does not exist, otherwise an error if something goes wrong.
 func (r *redisRepository) GetCache(key string, value interface{}) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer r.observe(&err, "GetCache", "", key, time.Now())
+
+	if r.earlyRefresh > 0 {
+		return r.getCacheEarly(key, value)
+	}
+
+	return r.getCache(key, value)
+}
+
+// getCache is GetCache without the metrics, for the methods which read a cache on their own behalf.
+func (r *redisRepository) getCache(key string, value interface{}) error {
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
 	v, err := r.client.Get(ctx, key).Result()
 	if err != nil {
-		return
+		return err
+	}
+
+	return r.codec.Unmarshal([]byte(v), value)
+}
+
+// GetDelCache retrieves a cache from redis and removes it atomically by using the command `GETDEL`, so a one-time
+// token is consumed by a single caller. It requires redis 6.2 or later.
+//
+// Parameters:
+// - key: the cache key.
+// - dest: a pointer to the object that will hold the unmarshalled cache value.
+//
+// Returns:
+// - error: ErrCacheMiss if the cache does not exist, otherwise an error if something goes wrong.
+func (r *redisRepository) GetDelCache(key string, dest interface{}) (err error) {
+	defer r.observe(&err, "GetDelCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	v, err := r.client.GetDel(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	return r.codec.Unmarshal([]byte(v), dest)
+}
+
+// SaveMultiCache saves several caches to redis by using the command `SET` for each cache in a single pipeline.
+// Zero expiration time means no expiration time for the caches.
+//
+// Parameters:
+// - values: the cache values to be saved by their key.
+// - ttl: the expiration time for the caches in seconds, 0 means no expiration time.
+//
+// Returns:
+// - err: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) SaveMultiCache(values map[string]interface{}, ttl int) (err error) {
+	defer r.observe(&err, "SaveMultiCache", "", "", time.Now())
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	encoded := make(map[string][]byte, len(values))
+	for key, value := range values {
+		v, err := encode(r.codec, value)
+		if err != nil {
+			return err
+		}
+		encoded[key] = v
+	}
+
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, v := range encoded {
+			pipe.Set(ctx, key, v, cacheExpiration(r.jitterTTL(ttl)))
+		}
+		return nil
+	})
+
+	return err
+}
+
+// GetMultiCache retrieves several caches from redis by using the command `MGET` in a single round trip.
+// The caches are left encoded so they can be unmarshalled into their own types with the codec of the repository.
+//
+// Parameters:
+// - keys: the cache keys.
+// - dest: the map which receives the cache values by their key, the missing caches are not added.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) GetMultiCache(keys []string, dest map[string]json.RawMessage) (err error) {
+	defer r.observe(&err, "GetMultiCache", "", "", time.Now())
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	values, err := multiGet(ctx, r.client, keys)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			dest[keys[i]] = json.RawMessage(s)
+		}
+	}
+
+	return nil
+}
+
+// GetOrSetCache retrieves the cache and unmarshal it into dest, or calls the loader and saves its result when the
+// cache does not exist. The concurrent loads of the same key within the instance share a single call of the loader.
+// With WithEarlyRefresh, the cache may also be loaded again shortly before it expires.
+//
+// Parameters:
+// - key: the cache key.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+// - dest: a pointer to the object that will hold the cache value.
+// - loader: the function which loads the value when the cache does not exist.
+//
+// Returns:
+// - error: the error of the loader, otherwise an error if something goes wrong.
+func (r *redisRepository) GetOrSetCache(key string, ttl int, dest interface{}, loader func() (interface{}, error)) (err error) {
+	start := time.Now()
+	loaded := false
+	defer func() {
+		wrapError(&err, "GetOrSetCache", "", key, start)
+		r.record("GetOrSetCache", key, start, err, loaded)
+	}()
+
+	if r.earlyRefresh > 0 && ttl > 0 {
+		loaded, err = r.getOrSetCacheEarly(key, ttl, dest, loader)
+		return err
+	}
+
+	err = r.getCache(key, dest)
+	if !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	loaded = true
+	v, err := r.loadCache(key, ttl, loader)
+	if err != nil {
+		return err
+	}
+
+	return r.codec.Unmarshal(v, dest)
+}
+
+// loadCache calls the loader and saves its result, the concurrent loads of the same key share a single call. With
+// WithEarlyRefresh, the duration of the loader is saved next to the cache.
+func (r *redisRepository) loadCache(key string, ttl int, loader func() (interface{}, error)) ([]byte, error) {
+	ttl = r.jitterTTL(ttl)
+	v, err, _ := r.loads.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		value, err := loader()
+		if err != nil {
+			return nil, err
+		}
+		delta := time.Since(start)
+
+		b, err := encode(r.codec, value)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+		defer cancel()
+
+		if r.earlyRefresh <= 0 || ttl <= 0 {
+			if err := r.client.Set(ctx, key, b, cacheExpiration(ttl)).Err(); err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+
+		if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, b, cacheExpiration(ttl))
+			pipe.Set(ctx, earlyRefreshKey(key), delta.Milliseconds(), time.Duration(ttl)*time.Second)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		return b, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return json.Unmarshal([]byte(v), value)
+	return v.([]byte), nil
 }
 
 // RemoveCache removes a cache from redis.
+// With WithEarlyRefresh, the duration of its loader saved next to it is removed as well.
 //
 // Parameters:
 // - key: the cache key to be removed.
@@ -188,13 +745,44 @@ func (r *redisRepository) GetCache(key string, value interface{}) (err error) {
 // Returns:
 // - error: an error if something goes wrong, otherwise nil.
 func (r *redisRepository) RemoveCache(key string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer r.observe(&err, "RemoveCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
+	if r.earlyRefresh > 0 {
+		// the keys may be in different slots, so they are removed by separate commands
+		_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.Del(ctx, earlyRefreshKey(key))
+			pipe.Del(ctx, earlyRefreshMissKey(key))
+			return nil
+		})
+		return err
+	}
+
 	_, err = r.client.Del(ctx, key).Result()
 	return err
 }
 
+// RemoveCacheByPattern removes the caches matching the pattern, the keys are found with `SCAN` and removed with
+// `UNLINK` in batches of ScanBatchSize keys, so the memory is reclaimed in the background.
+//
+// Parameters:
+// - pattern: the glob-style pattern of the keys, e.g. "user:123:*".
+//
+// Returns:
+// - int64: the number of caches removed.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) RemoveCacheByPattern(pattern string) (removed int64, err error) {
+	defer r.observe(&err, "RemoveCacheByPattern", "", pattern, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), time.Minute)
+	defer cancel()
+
+	return unlinkByPattern(ctx, r.client, pattern)
+}
+
 // CheckSetMember check is member existed in the set
 //
 // Parameters:
@@ -203,8 +791,10 @@ func (r *redisRepository) RemoveCache(key string) (err error) {
 // Return values:
 // - bool: true if the key exists, false otherwise.
 // - error: if the Redis operation fails.
-func (r *redisRepository) CheckSetMember(key string, member interface{}) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) CheckSetMember(key string, member interface{}) (exists bool, err error) {
+	defer r.observe(&err, "CheckSetMember", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
 	return r.client.SIsMember(ctx, key, member).Result()
@@ -219,8 +809,11 @@ func (r *redisRepository) CheckSetMember(key string, member interface{}) (bool,
 //
 // Return values:
 // - error: if the Redis operation fails.
-func (r *redisRepository) AddSetMember(key string, ttl int, member ...interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) AddSetMember(key string, ttl int, member ...interface{}) (err error) {
+	defer r.observe(&err, "AddSetMember", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
 	if err := r.client.SAdd(ctx, key, member...).Err(); err != nil {
@@ -242,40 +835,1139 @@ func (r *redisRepository) AddSetMember(key string, ttl int, member ...interface{
 //
 // Return values:
 // - error: if the Redis operation fails.
-func (r *redisRepository) RemoveSetMember(key string, member interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) RemoveSetMember(key string, member interface{}) (err error) {
+	defer r.observe(&err, "RemoveSetMember", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
 	return r.client.SRem(ctx, key, member).Err()
 }
 
-// SetExpire sets an expiration time for a cache in redis.
+// GetSetMembers retrieves all the members of a set by using the command `SMEMBERS`.
 //
 // Parameters:
-// - key: the cache key to set expiration for.
-// - ttl: the expiration time for cache in seconds.
+// - key: the set key.
 //
 // Returns:
+// - []string: the members, empty if the set does not exist.
 // - error: an error if something goes wrong, otherwise nil.
-func (r *redisRepository) SetExpire(key string, ttl int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *redisRepository) GetSetMembers(key string) (members []string, err error) {
+	defer r.observe(&err, "GetSetMembers", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
-	return r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Err()
+	return r.client.SMembers(ctx, key).Result()
 }
 
-// Exist checks if a key exists in the Redis database.
+// CountSetMembers retrieves the number of members of a set by using the command `SCARD`.
+//
 // Parameters:
-// - key: the key to check.
+// - key: the set key.
 //
-// Return values:
-// - bool: true if the key exists, false otherwise.
-// - error: if the Redis operation fails.
-func (r *redisRepository) Exist(key string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// Returns:
+// - int64: the number of members, 0 if the set does not exist.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) CountSetMembers(key string) (count int64, err error) {
+	defer r.observe(&err, "CountSetMembers", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
 	defer cancel()
 
-	res := r.client.Exists(ctx, key)
+	return r.client.SCard(ctx, key).Result()
+}
 
-	return res.Val() == 1, res.Err()
+// UnionSets retrieves the union of the sets, the members of any of the sets, by using the command `SUNION`. A missing
+// set is an empty set. On a cluster the sets must be in the same slot, e.g. by a hash tag.
+//
+// Parameters:
+// - keys: the set keys.
+//
+// Returns:
+// - []string: the members of the union.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) UnionSets(keys ...string) (members []string, err error) {
+	defer r.observe(&err, "UnionSets", "", strings.Join(keys, ","), time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.SUnion(ctx, keys...).Result()
+}
+
+// UnionSetsStore saves the union of the sets into the set dst by using the command `SUNIONSTORE`, an existing dst is
+// overwritten and keeps no expiration time. On a cluster dst and the sets must be in the same slot.
+//
+// Parameters:
+// - dst: the key of the set which holds the union.
+// - keys: the set keys.
+//
+// Returns:
+// - int64: the number of members of dst.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) UnionSetsStore(dst string, keys ...string) (count int64, err error) {
+	defer r.observe(&err, "UnionSetsStore", "", dst, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.SUnionStore(ctx, dst, keys...).Result()
+}
+
+// IntersectSets retrieves the intersection of the sets, the members of all the sets, by using the command `SINTER`. A
+// missing set is an empty set. On a cluster the sets must be in the same slot, e.g. by a hash tag.
+//
+// Parameters:
+// - keys: the set keys.
+//
+// Returns:
+// - []string: the members of the intersection.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) IntersectSets(keys ...string) (members []string, err error) {
+	defer r.observe(&err, "IntersectSets", "", strings.Join(keys, ","), time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.SInter(ctx, keys...).Result()
+}
+
+// IntersectSetsStore saves the intersection of the sets into the set dst by using the command `SINTERSTORE`, an
+// existing dst is overwritten and keeps no expiration time. On a cluster dst and the sets must be in the same slot.
+//
+// Parameters:
+// - dst: the key of the set which holds the intersection.
+// - keys: the set keys.
+//
+// Returns:
+// - int64: the number of members of dst.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) IntersectSetsStore(dst string, keys ...string) (count int64, err error) {
+	defer r.observe(&err, "IntersectSetsStore", "", dst, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.SInterStore(ctx, dst, keys...).Result()
+}
+
+// DiffSets retrieves the difference of the sets, the members of the first set which are not in the other sets, by using
+// the command `SDIFF`. A missing set is an empty set. On a cluster the sets must be in the same slot, e.g. by a hash
+// tag.
+//
+// Parameters:
+// - keys: the set keys.
+//
+// Returns:
+// - []string: the members of the difference.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) DiffSets(keys ...string) (members []string, err error) {
+	defer r.observe(&err, "DiffSets", "", strings.Join(keys, ","), time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.SDiff(ctx, keys...).Result()
+}
+
+// DiffSetsStore saves the difference of the sets into the set dst by using the command `SDIFFSTORE`, an existing dst is
+// overwritten and keeps no expiration time. On a cluster dst and the sets must be in the same slot.
+//
+// Parameters:
+// - dst: the key of the set which holds the difference.
+// - keys: the set keys.
+//
+// Returns:
+// - int64: the number of members of dst.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) DiffSetsStore(dst string, keys ...string) (count int64, err error) {
+	defer r.observe(&err, "DiffSetsStore", "", dst, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.SDiffStore(ctx, dst, keys...).Result()
+}
+
+// AddUnique adds the items to a HyperLogLog by using the command `PFADD`, which counts the unique items approximately
+// in a constant memory, e.g. the unique visitors of a page.
+//
+// Parameters:
+// - key: the HyperLogLog key.
+// - ttl: the expiration time for the HyperLogLog in seconds, 0 means no expiration time.
+// - items: the items to be counted.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) AddUnique(key string, ttl int, items ...interface{}) (err error) {
+	defer r.observe(&err, "AddUnique", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	if err := r.client.PFAdd(ctx, key, items...).Err(); err != nil {
+		return err
+	}
+
+	if ttl > 0 {
+		return r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Err()
+	}
+
+	return nil
+}
+
+// CountUnique retrieves the approximate number of unique items of HyperLogLogs by using the command `PFCOUNT`, the
+// standard error of the count is 0.81%.
+//
+// Parameters:
+// - keys: the HyperLogLog keys, the count of several keys is the count of their union, e.g. the unique visitors of a
+// week from the daily keys.
+//
+// Returns:
+// - int64: the approximate number of unique items, 0 if the keys do not exist.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) CountUnique(keys ...string) (count int64, err error) {
+	defer r.observe(&err, "CountUnique", "", strings.Join(keys, ","), time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.PFCount(ctx, keys...).Result()
+}
+
+// SetBit sets or clears the bit at the offset of a bitmap by using the command `SETBIT`, e.g. to mark the user of id
+// offset as active in the bitmap of the day. The bitmap grows to hold the offset, so the offsets must be dense.
+//
+// Parameters:
+// - key: the bitmap key.
+// - offset: the offset of the bit.
+// - value: true to set the bit, false to clear it.
+// - ttl: the expiration time for the bitmap in seconds, 0 means no expiration time.
+//
+// Returns:
+// - bool: the previous value of the bit.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) SetBit(key string, offset int64, value bool, ttl int) (previous bool, err error) {
+	defer r.observe(&err, "SetBit", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	bit := 0
+	if value {
+		bit = 1
+	}
+
+	res, err := r.client.SetBit(ctx, key, offset, bit).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if ttl > 0 {
+		if err := r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Err(); err != nil {
+			return false, err
+		}
+	}
+
+	return res == 1, nil
+}
+
+// GetBit retrieves the bit at the offset of a bitmap by using the command `GETBIT`.
+//
+// Parameters:
+// - key: the bitmap key.
+// - offset: the offset of the bit.
+//
+// Returns:
+// - bool: the value of the bit, false if the bitmap does not exist or is shorter than the offset.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) GetBit(key string, offset int64) (value bool, err error) {
+	defer r.observe(&err, "GetBit", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	res, err := r.client.GetBit(ctx, key, offset).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return res == 1, nil
+}
+
+// CountBits retrieves the number of bits set in a bitmap by using the command `BITCOUNT`.
+//
+// Parameters:
+// - key: the bitmap key.
+//
+// Returns:
+// - int64: the number of bits set, 0 if the bitmap does not exist.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) CountBits(key string) (count int64, err error) {
+	defer r.observe(&err, "CountBits", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.BitCount(ctx, key, nil).Result()
+}
+
+// RandomSetMembers retrieves random members of a set by using the command `SRANDMEMBER`.
+//
+// Parameters:
+// - key: the set key.
+// - n: the number of members, a positive value returns distinct members while a negative value may return duplicates.
+//
+// Returns:
+// - []string: the random members, empty if the set does not exist.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) RandomSetMembers(key string, n int) (members []string, err error) {
+	defer r.observe(&err, "RandomSetMembers", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.SRandMemberN(ctx, key, int64(n)).Result()
+}
+
+// RandomHashFields retrieves random fields of a hash cache by using the command `HRANDFIELD`.
+//
+// Parameters:
+// - key: the cache key.
+// - n: the number of fields, a positive value returns distinct fields while a negative value may return duplicates.
+//
+// Returns:
+// - []string: the random fields, empty if the hash does not exist.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) RandomHashFields(key string, n int) (fields []string, err error) {
+	defer r.observe(&err, "RandomHashFields", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.HRandField(ctx, key, n, false).Result()
+}
+
+// SetExpire sets an expiration time for a cache in redis. RedisKeepTTL keeps the current expiration time, so nothing is
+// sent, rather than the negative ttl which would remove the cache.
+//
+// Parameters:
+// - key: the cache key to set expiration for.
+// - ttl: the expiration time for cache in seconds, RedisKeepTTL keeps the current one.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) SetExpire(key string, ttl int) (err error) {
+	defer r.observe(&err, "SetExpire", "", key, time.Now())
+
+	if ttl == RedisKeepTTL {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Err()
+}
+
+// SetExpireAt sets an absolute expiration time for a cache in redis by using the command `EXPIREAT`, e.g. the end of a
+// day or of a campaign. The time is an instant, so it does not depend on the timezone of the caller, and a time in the
+// past removes the cache.
+//
+// Parameters:
+// - key: the cache key to set expiration for.
+// - at: the time at which the cache expires, truncated to the second.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) SetExpireAt(key string, at time.Time) (err error) {
+	defer r.observe(&err, "SetExpireAt", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.ExpireAt(ctx, key, at).Err()
+}
+
+// CopyCache copies a cache to another key with its expiration time by using the command `COPY` of redis 6.2+, e.g. to
+// promote a staged cache over the live one. On a cluster both keys must be in the same slot, e.g. by a hash tag.
+//
+// Parameters:
+// - src: the key of the cache to be copied.
+// - dst: the key of the copy.
+// - replace: true to overwrite dst if it exists, otherwise the cache is not copied over an existing key.
+//
+// Returns:
+// - bool: true if the cache is copied, false if src does not exist or dst exists and replace is false.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) CopyCache(src string, dst string, replace bool) (copied bool, err error) {
+	defer r.observe(&err, "CopyCache", "", src, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	args := []interface{}{"COPY", src, dst}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+
+	n, err := r.client.Do(ctx, args...).Int()
+
+	return n == 1, err
+}
+
+// RenameCache renames a cache by using the command `RENAME`, an existing dst is overwritten. The cache keeps its
+// expiration time. On a cluster both keys must be in the same slot, e.g. by a hash tag.
+//
+// Parameters:
+// - src: the key of the cache to be renamed.
+// - dst: the new key of the cache.
+//
+// Returns:
+// - error: ErrKeyNotFound if src does not exist, otherwise an error if something goes wrong.
+func (r *redisRepository) RenameCache(src string, dst string) (err error) {
+	defer r.observe(&err, "RenameCache", "", src, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	err = r.client.Rename(ctx, src, dst).Err()
+	// the missing src is only reported by the message, "ERR no such key" on redis
+	if err != nil && strings.Contains(err.Error(), "no such key") {
+		return redis.Nil
+	}
+
+	return err
+}
+
+// Exist checks if a key exists in the Redis database.
+// Parameters:
+// - key: the key to check.
+//
+// Return values:
+// - bool: true if the key exists, false otherwise.
+// - error: if the Redis operation fails.
+func (r *redisRepository) Exist(key string) (exists bool, err error) {
+	defer r.observe(&err, "Exist", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	res := r.client.Exists(ctx, key)
+
+	return res.Val() == 1, res.Err()
+}
+
+// ExistMulti checks which of the keys exist by using the command `EXISTS` for every key in a single pipeline, e.g. to
+// audit thousands of caches without a round trip per key. On a cluster the keys need not share a slot.
+//
+// Parameters:
+// - keys: the keys to check.
+//
+// Returns:
+// - map[string]bool: whether each key exists, by key.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) ExistMulti(keys ...string) (exists map[string]bool, err error) {
+	defer r.observe(&err, "ExistMulti", "", strings.Join(keys, ","), time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	cmds := make([]*redis.IntCmd, len(keys))
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Exists(ctx, key)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	exists = make(map[string]bool, len(keys))
+	for i, cmd := range cmds {
+		exists[keys[i]] = cmd.Val() == 1
+	}
+
+	return exists, nil
+}
+
+// GetTTLMulti retrieves the remaining time to live of the keys by using the command `PTTL` for every key in a single
+// pipeline. On a cluster the keys need not share a slot.
+//
+// Parameters:
+// - keys: the keys.
+//
+// Returns:
+// - map[string]time.Duration: the remaining time to live of each existing key, by key, -1 for the keys without
+// expiration time. The missing keys are not added.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) GetTTLMulti(keys ...string) (ttls map[string]time.Duration, err error) {
+	defer r.observe(&err, "GetTTLMulti", "", strings.Join(keys, ","), time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	cmds := make([]*redis.IntCmd, len(keys))
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = redis.NewIntCmd(ctx, "PTTL", key)
+			_ = pipe.Process(ctx, cmds[i])
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	ttls = make(map[string]time.Duration, len(keys))
+	for i, cmd := range cmds {
+		switch ms := cmd.Val(); {
+		case ms == -1:
+			ttls[keys[i]] = -1
+		case ms >= 0:
+			ttls[keys[i]] = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return ttls, nil
+}
+
+// NamespaceStats counts the keys under a prefix by using the command `SCAN` and estimates their memory usage
+// by sampling up to NamespaceStatsSampleSize keys with the command `MEMORY USAGE`. On a cluster the keys of every
+// master are counted.
+//
+// Parameters:
+// - prefix: the key prefix of the namespace, e.g. "user:".
+//
+// Returns:
+// - *NamespaceStats: the key count and the approximate memory usage of the namespace.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) NamespaceStats(prefix string) (stats *NamespaceStats, err error) {
+	defer r.observe(&err, "NamespaceStats", "", prefix, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	stats = &NamespaceStats{Prefix: prefix}
+
+	if err := forEachRedisNode(ctx, r.client, func(ctx context.Context, node redis.UniversalClient) error {
+		iter := node.Scan(ctx, 0, escapePattern(prefix)+"*", ScanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			stats.KeyCount++
+
+			if stats.SampledKeys >= NamespaceStatsSampleSize {
+				continue
+			}
+
+			size, err := node.MemoryUsage(ctx, iter.Val()).Result()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			stats.SampledKeys++
+			stats.SampledBytes += size
+		}
+
+		return iter.Err()
+	}); err != nil {
+		return nil, err
+	}
+
+	if stats.SampledKeys > 0 {
+		stats.EstimatedBytes = stats.SampledBytes * stats.KeyCount / stats.SampledKeys
+	}
+
+	return stats, nil
+}
+
+// ScanKeys calls fn with every key matching the pattern by using the command `SCAN`, in batches of ScanBatchSize keys,
+// so the server is never blocked as with `KEYS`. The iteration is not bounded by a timeout, as it lasts as long as fn,
+// and a key may be seen more than once when the keyspace changes during the iteration. On a cluster the keys of every
+// master are scanned.
+//
+// Parameters:
+// - pattern: the glob-style pattern of the keys, e.g. "user:*".
+// - fn: the function called with each key, a non-nil error stops the iteration.
+//
+// Returns:
+// - error: the error of fn, otherwise an error if something goes wrong.
+func (r *redisRepository) ScanKeys(pattern string, fn func(key string) error) (err error) {
+	defer r.observe(&err, "ScanKeys", "", pattern, time.Now())
+
+	ctx := r.context()
+
+	return forEachRedisNode(ctx, r.client, func(ctx context.Context, node redis.UniversalClient) error {
+		iter := node.Scan(ctx, 0, pattern, ScanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			if err := fn(iter.Val()); err != nil {
+				return err
+			}
+		}
+
+		return iter.Err()
+	})
+}
+
+// SaveVersionedCache saves a cache together with its version (an etag computed from the content) by using the command `HSET`.
+// Zero expiration time means no expiration time for cache.
+//
+// Parameters:
+// - key: the cache key.
+// - value: the cache value to be saved.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+//
+// Returns:
+// - string: the version of the saved cache.
+// - err: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) SaveVersionedCache(key string, value interface{}, ttl int) (version string, err error) {
+	defer r.observe(&err, "SaveVersionedCache", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	v, err := encode(r.codec, value)
+	if err != nil {
+		return "", err
+	}
+
+	version = cacheVersion(v)
+
+	if _, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, versionedCacheValueField, v, versionedCacheVersionField, version)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, time.Duration(ttl)*time.Second)
+		} else {
+			pipe.Persist(ctx, key)
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
+// SaveVersionedCacheIfMatch saves a versioned cache only if its stored version equals the expected version.
+// The check and the write are done atomically by a lua script.
+//
+// Parameters:
+// - key: the cache key.
+// - version: the expected version of the stored cache, empty string means the cache must not exist.
+// - value: the cache value to be saved.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+//
+// Returns:
+// - string: the new version of the cache.
+// - err: ErrVersionMismatch if the stored version differs from the expected version, otherwise an error if something goes wrong.
+func (r *redisRepository) SaveVersionedCacheIfMatch(key string, version string, value interface{}, ttl int) (newVersion string, err error) {
+	defer r.observe(&err, "SaveVersionedCacheIfMatch", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	v, err := encode(r.codec, value)
+	if err != nil {
+		return "", err
+	}
+
+	newVersion = cacheVersion(v)
+
+	ok, err := saveVersionedCacheIfMatchScript.Run(ctx, r.client, []string{key}, version, v, newVersion, ttl).Int()
+	if err != nil {
+		return "", err
+	}
+
+	if ok == 0 {
+		return "", ErrVersionMismatch
+	}
+
+	return newVersion, nil
+}
+
+// GetVersionedCache retrieves a versioned cache only if its version differs from the given version.
+// The value is left untouched when the cache is not changed.
+//
+// Parameters:
+// - key: the cache key.
+// - version: the version known by the caller, empty string means always retrieve the cache.
+// - value: a pointer to the object that will hold the unmarshalled cache value.
+//
+// Returns:
+// - string: the current version of the cache.
+// - bool: true if the cache has changed and the value is unmarshalled, false otherwise.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) GetVersionedCache(key string, version string, value interface{}) (current string, changed bool, err error) {
+	defer r.observe(&err, "GetVersionedCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	if version != "" {
+		current, err := r.client.HGet(ctx, key, versionedCacheVersionField).Result()
+		if err != nil {
+			return "", false, err
+		}
+
+		if current == version {
+			return current, false, nil
+		}
+	}
+
+	res, err := r.client.HMGet(ctx, key, versionedCacheVersionField, versionedCacheValueField).Result()
+	if err != nil {
+		return "", false, err
+	}
+
+	current, ok := res[0].(string)
+	if !ok {
+		return "", false, redis.Nil
+	}
+
+	v, _ := res[1].(string)
+	if err := r.codec.Unmarshal([]byte(v), value); err != nil {
+		return "", false, err
+	}
+
+	return current, true, nil
+}
+
+// PushList appends the values to the tail of a list by using the command `RPUSH`.
+//
+// Parameters:
+// - key: the list key.
+// - ttl: the expiration time for the list in seconds, 0 means the expiration time is not changed.
+// - values: the values to be appended.
+//
+// Returns:
+// - int64: the length of the list after the push.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) PushList(key string, ttl int, values ...interface{}) (length int64, err error) {
+	defer r.observe(&err, "PushList", "", key, time.Now())
+	ttl = r.jitterTTL(ttl)
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	length, err = r.client.RPush(ctx, key, values...).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl > 0 {
+		if err := r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return length, nil
+}
+
+// PopList removes and returns the head of a list by using the command `LPOP`.
+//
+// Parameters:
+// - key: the list key.
+//
+// Returns:
+// - string: the value of the head.
+// - error: ErrKeyNotFound if the list is empty or does not exist, otherwise an error if something goes wrong.
+func (r *redisRepository) PopList(key string) (value string, err error) {
+	defer r.observe(&err, "PopList", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.LPop(ctx, key).Result()
+}
+
+// BPopList removes and returns the head of the first non-empty list by using the command `BLPOP`, it blocks until
+// a value is pushed or the timeout is reached.
+//
+// Parameters:
+// - timeout: the maximum blocking time in seconds, 0 means blocking indefinitely.
+// - keys: the list keys, checked in the given order.
+//
+// Returns:
+// - string: the key of the list the value was popped from.
+// - string: the value of the head.
+// - error: ErrKeyNotFound if the timeout is reached, otherwise an error if something goes wrong.
+func (r *redisRepository) BPopList(timeout int, keys ...string) (key string, value string, err error) {
+	defer r.observe(&err, "BPopList", "", strings.Join(keys, ","), time.Now())
+
+	ctx := r.context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second+10*time.Second)
+		defer cancel()
+	}
+
+	res, err := r.client.BLPop(ctx, time.Duration(timeout)*time.Second, keys...).Result()
+	if err != nil {
+		return "", "", err
+	}
+
+	return res[0], res[1], nil
+}
+
+// GetListRange retrieves the values of a list between start and stop by using the command `LRANGE`.
+// The indexes are zero-based and inclusive, a negative index counts from the tail, -1 being the last value.
+//
+// Parameters:
+// - key: the list key.
+// - start: the index of the first value.
+// - stop: the index of the last value.
+//
+// Returns:
+// - []string: the values, empty if the list does not exist.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) GetListRange(key string, start int64, stop int64) (values []string, err error) {
+	defer r.observe(&err, "GetListRange", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.LRange(ctx, key, start, stop).Result()
+}
+
+// TrimList keeps only the values of a list between start and stop by using the command `LTRIM`.
+// The indexes follow the same rules as GetListRange.
+//
+// Parameters:
+// - key: the list key.
+// - start: the index of the first value to keep.
+// - stop: the index of the last value to keep.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) TrimList(key string, start int64, stop int64) (err error) {
+	defer r.observe(&err, "TrimList", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return r.client.LTrim(ctx, key, start, stop).Err()
+}
+
+// IncrementCache atomically increments a counter by using the command `INCRBY`, a missing counter starts from 0.
+//
+// Parameters:
+// - key: the counter key.
+// - by: the increment.
+// - ttl: the expiration time for the counter in seconds, it is set only if the counter has no expiration time,
+// 0 means no expiration time.
+//
+// Returns:
+// - int64: the value of the counter after the increment.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) IncrementCache(key string, by int64, ttl int) (value int64, err error) {
+	defer r.observe(&err, "IncrementCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return incrementCacheScript.Run(ctx, r.client, []string{key}, by, ttl).Int64()
+}
+
+// DecrementCache atomically decrements a counter by using the command `INCRBY` with a negative increment, a missing
+// counter starts from 0.
+//
+// Parameters:
+// - key: the counter key.
+// - by: the decrement.
+// - ttl: the expiration time for the counter in seconds, it is set only if the counter has no expiration time,
+// 0 means no expiration time.
+//
+// Returns:
+// - int64: the value of the counter after the decrement.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) DecrementCache(key string, by int64, ttl int) (value int64, err error) {
+	defer r.observe(&err, "DecrementCache", "", key, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return incrementCacheScript.Run(ctx, r.client, []string{key}, -by, ttl).Int64()
+}
+
+// WatchTransaction runs fn in an optimistic transaction by using the commands `WATCH` and `MULTI`/`EXEC`. The reads
+// of fn see the current values of the keys, and the writes of fn are applied atomically only if none of the keys
+// was modified in the meantime, otherwise fn is run again up to retries times.
+//
+// Parameters:
+// - keys: the keys to be watched.
+// - fn: the function which reads the keys and queues the writes, a non-nil error aborts the transaction.
+// - retries: the number of retries on conflict.
+//
+// Returns:
+// - error: ErrTransactionConflict if the keys are still modified after the retries, otherwise the error of fn or an
+// error if something goes wrong.
+func (r *redisRepository) WatchTransaction(keys []string, fn func(tx RedisTx) error, retries int) (err error) {
+	defer r.observe(&err, "WatchTransaction", "", strings.Join(keys, ","), time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	txf := func(tx *redis.Tx) error {
+		rtx := &redisTx{ctx: ctx, tx: tx, codec: r.codec}
+		if err := fn(rtx); err != nil {
+			return err
+		}
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, op := range rtx.ops {
+				op(pipe)
+			}
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i <= retries; i++ {
+		err = r.client.Watch(ctx, txf, keys...)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+
+	return ErrTransactionConflict
+}
+
+// Pipeline sends the writes queued by fn to redis in a single round trip, e.g. the thousands of SETs of a cache
+// warm-up whose latency is dominated by the round trips. The writes are neither atomic nor isolated, a write which
+// fails does not stop the others, and nothing is sent if fn returns an error.
+//
+// Parameters:
+// - fn: the function which queues the writes.
+//
+// Returns:
+// - error: the error of fn, the error of the first write which failed, or nil.
+func (r *redisRepository) Pipeline(fn func(p RedisPipeline) error) (err error) {
+	defer r.observe(&err, "Pipeline", "", "", time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	p := &redisPipeline{ctx: ctx, repo: r}
+	if err := fn(p); err != nil {
+		return err
+	}
+	if len(p.ops) == 0 {
+		return nil
+	}
+
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, op := range p.ops {
+			op(pipe)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// LoadScript registers the Lua script under the name and loads it into the script cache of redis by using the command
+// `SCRIPT LOAD`. A script registered again under the same name replaces the previous one.
+//
+// Parameters:
+// - name: the name of the script.
+// - body: the Lua source of the script.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) LoadScript(name string, body string) (err error) {
+	defer r.observe(&err, "LoadScript", "", name, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	script := redis.NewScript(body)
+	if err := script.Load(ctx, r.client).Err(); err != nil {
+		return err
+	}
+
+	r.scripts.Store(name, script)
+
+	return nil
+}
+
+// RunScript runs the script registered under the name by using the command `EVALSHA`, it falls back to `EVAL` when
+// the script was evicted from the script cache, e.g. after a restart or a failover of redis.
+//
+// Parameters:
+// - name: the name of the script.
+// - keys: the keys passed as KEYS.
+// - args: the arguments passed as ARGV.
+//
+// Returns:
+// - interface{}: the result of the script.
+// - error: ErrScriptNotFound if no script is registered under the name, otherwise an error if something goes wrong.
+func (r *redisRepository) RunScript(name string, keys []string, args ...interface{}) (result interface{}, err error) {
+	defer r.observe(&err, "RunScript", "", name, time.Now())
+
+	script, ok := r.scripts.Load(name)
+	if !ok {
+		return nil, ErrScriptNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	return script.(*redis.Script).Run(ctx, r.client, keys, args...).Result()
+}
+
+// Publish publishes the payload to a channel by using the command `PUBLISH`, the payload is encoded
+// by the codec of the repository.
+//
+// Parameters:
+// - channel: the channel.
+// - payload: the payload to be published.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) Publish(channel string, payload interface{}) (err error) {
+	defer r.observe(&err, "Publish", "", channel, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	v, err := r.codec.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Publish(ctx, channel, v).Err()
+}
+
+// ShardedPublish publishes the payload to a channel by using the command `SPUBLISH` of redis 7+, the payload is encoded
+// by the codec of the repository. On a cluster the message is only propagated within the shard of the channel, instead
+// of being broadcast to every node as with Publish. The messages are received by ShardedSubscribe.
+//
+// Parameters:
+// - channel: the channel.
+// - payload: the payload to be published.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisRepository) ShardedPublish(channel string, payload interface{}) (err error) {
+	defer r.observe(&err, "ShardedPublish", "", channel, time.Now())
+
+	ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+	defer cancel()
+
+	v, err := r.codec.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Do(ctx, "SPUBLISH", channel, v).Err()
+}
+
+// Subscribe subscribes to a channel by using the command `SUBSCRIBE` and calls the handler with the encoded payload of
+// every message until ctx is done. The connection is re-established and the channel subscribed again when the
+// connection is lost, the messages published in the meantime are not received.
+//
+// Parameters:
+// - ctx: the context which stops the subscription.
+// - channel: the channel.
+// - handler: the function which handles the payload, a non-nil error stops the subscription.
+//
+// Returns:
+// - error: the error of the handler, the error of ctx when it is done, or an error if the subscription fails.
+func (r *redisRepository) Subscribe(ctx context.Context, channel string, handler func(payload []byte) error) (err error) {
+	defer r.observe(&err, "Subscribe", "", channel, time.Now())
+
+	pubsub := r.client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			if err := handler([]byte(message.Payload)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PSubscribe subscribes to the channels matching a pattern by using the command `PSUBSCRIBE` and calls the handler
+// with the channel and the encoded payload of every message until ctx is done. The connection is re-established as
+// with Subscribe.
+//
+// Parameters:
+// - ctx: the context which stops the subscription.
+// - pattern: the glob-style pattern of the channels, e.g. "orders:*".
+// - handler: the function which handles the payload, a non-nil error stops the subscription.
+//
+// Returns:
+// - error: the error of the handler, the error of ctx when it is done, or an error if the subscription fails.
+func (r *redisRepository) PSubscribe(ctx context.Context, pattern string, handler func(channel string, payload []byte) error) (err error) {
+	defer r.observe(&err, "PSubscribe", "", pattern, time.Now())
+
+	pubsub := r.client.PSubscribe(ctx, pattern)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			if err := handler(message.Channel, []byte(message.Payload)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// cacheExpiration returns the expiration time of the command `SET` for the ttl in seconds.
+func cacheExpiration(ttl int) time.Duration {
+	if ttl == RedisKeepTTL {
+		return redis.KeepTTL
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+// cacheVersion computes the version of an encoded cache value.
+func cacheVersion(v []byte) string {
+	sum := sha1.Sum(v)
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePattern escapes the glob special characters of the given string so it can be used literally in a `SCAN` pattern.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
 }
@@ -0,0 +1,27 @@
+package repositorysdk
+
+import (
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"testing"
+)
+
+func TestRenameCacheOfAMissingKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo := NewRedisRepository(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	if err := repo.RenameCache("missing", "dst"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("rename of a missing key: got %v, want ErrKeyNotFound", err)
+	}
+
+	if err := repo.SaveCache("src", "value", 0); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := repo.RenameCache("src", "dst"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if !mr.Exists("dst") || mr.Exists("src") {
+		t.Error("the cache was not renamed")
+	}
+}
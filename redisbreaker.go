@@ -0,0 +1,720 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// RedisCircuitBreakerConfig is a struct that holds the settings of a circuit breaker in front of a redis repository.
+type RedisCircuitBreakerConfig struct {
+	// Threshold is the number of consecutive connection failures which open the circuit, 0 means 5. Cooldown is the
+	// time the circuit stays open before a probe call is let through, 0 means 30 seconds.
+	CircuitBreakerConfig `mapstructure:",squash"`
+	// Fallback serves the calls made while the circuit is open as if redis was empty instead of failing them with
+	// ErrCircuitOpen: the reads of a cache miss, the writes and the removals do nothing, and GetOrSetCache calls the
+	// loader. The counters, the conditional writes, the transactions, the scripts, and the locks always fail.
+	Fallback bool `mapstructure:"fallback"`
+	// Degraded serves the calls which fail with a connection error or a timeout as Fallback does, even before the
+	// circuit opens, so redis is an optimization rather than an availability dependency. It implies Fallback.
+	Degraded bool `mapstructure:"degraded"`
+	// Metrics records the calls served by the fallback as RedisCallDegraded, it may be nil.
+	Metrics RedisMetrics `mapstructure:"-"`
+}
+
+// errNoFallback is the fallback of the calls which always fail while the circuit is open.
+var errNoFallback = errors.New("no fallback")
+
+// errFallbackMiss is the fallback of the reads of a cache.
+var errFallbackMiss = translateError(redis.Nil, true)
+
+type breakerRedisRepository struct {
+	RedisRepository
+	breaker  *circuitBreaker
+	fallback bool
+	degraded bool
+	metrics  RedisMetrics
+}
+
+// NewCircuitBreakerRedisRepository function that create a new instance of RedisRepository which stops calling redis
+// after conf.Threshold consecutive connection failures, so the calls fail fast instead of waiting out their timeout
+// while redis is down. A single probe call is let through once the cooldown has elapsed, and the circuit is closed
+// again when it succeeds.
+//
+// Only the connection errors and the timeouts count as failures, a cache miss or a rejected command does not. The
+// commands run on the client of GetClient, GetUniversalClient, Subscribe, PSubscribe, and ShardedSubscribe bypass the breaker.
+func NewCircuitBreakerRedisRepository(repo RedisRepository, conf RedisCircuitBreakerConfig) RedisRepository {
+	if conf.Threshold <= 0 {
+		conf.Threshold = 5
+	}
+
+	return &breakerRedisRepository{
+		RedisRepository: repo,
+		breaker:         newCircuitBreaker(conf.CircuitBreakerConfig),
+		fallback:        conf.Fallback || conf.Degraded,
+		degraded:        conf.Degraded,
+		metrics:         conf.Metrics,
+	}
+}
+
+// valueCodec returns the codec of the wrapped repository.
+func (r *breakerRedisRepository) valueCodec() Codec {
+	return codecOf(r.RedisRepository)
+}
+
+// maxTTLJitter returns the ttl jitter of the wrapped repository.
+func (r *breakerRedisRepository) maxTTLJitter() float64 {
+	return ttlJitterOf(r.RedisRepository)
+}
+
+// redisKey returns the key stored in redis for the key of the repository.
+func (r *breakerRedisRepository) redisKey(key string) string {
+	return redisKey(r.RedisRepository, key)
+}
+
+// WithContext returns a copy of the repository whose calls run under ctx, the copy shares the breaker.
+func (r *breakerRedisRepository) WithContext(ctx context.Context) RedisRepository {
+	c := *r
+	c.RedisRepository = r.RedisRepository.WithContext(ctx)
+
+	return &c
+}
+
+// call runs fn through the breaker. While the circuit is open fn is not run, and the call returns fallback when the
+// fallback is enabled, otherwise ErrCircuitOpen. In degraded mode, fallback is also returned when fn fails with a
+// connection error.
+func (r *breakerRedisRepository) call(method string, fallback error, fn func() error) error {
+	allowed, probe := r.breaker.allow()
+	if !allowed {
+		if r.fallback && fallback != errNoFallback {
+			r.degrade(method, 0)
+			return fallback
+		}
+		return ErrCircuitOpen
+	}
+
+	start := time.Now()
+	err := fn()
+	r.breaker.done(probe, !isConnectionError(err))
+
+	if r.degraded && fallback != errNoFallback && isConnectionError(err) {
+		r.degrade(method, time.Since(start))
+		return fallback
+	}
+
+	return err
+}
+
+// degrade records a call served by the fallback.
+func (r *breakerRedisRepository) degrade(method string, duration time.Duration) {
+	if r.metrics != nil {
+		r.metrics.ObserveRedisCall(method, RedisCallDegraded, duration)
+	}
+}
+
+func (r *breakerRedisRepository) SaveCache(key string, value interface{}, ttl int) error {
+	return r.call("SaveCache", nil, func() error {
+		return r.RedisRepository.SaveCache(key, value, ttl)
+	})
+}
+
+func (r *breakerRedisRepository) SaveCacheD(key string, value interface{}, ttl time.Duration) error {
+	return r.SaveCache(key, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) SaveCacheNX(key string, value interface{}, ttl int) (saved bool, err error) {
+	err = r.call("SaveCacheNX", errNoFallback, func() (err error) {
+		saved, err = r.RedisRepository.SaveCacheNX(key, value, ttl)
+		return err
+	})
+	return saved, err
+}
+
+func (r *breakerRedisRepository) SaveCacheNXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.SaveCacheNX(key, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) SaveCacheXX(key string, value interface{}, ttl int) (saved bool, err error) {
+	err = r.call("SaveCacheXX", errNoFallback, func() (err error) {
+		saved, err = r.RedisRepository.SaveCacheXX(key, value, ttl)
+		return err
+	})
+	return saved, err
+}
+
+func (r *breakerRedisRepository) SaveCacheXXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.SaveCacheXX(key, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) SaveHashCache(key string, field string, value string, ttl int) error {
+	return r.call("SaveHashCache", nil, func() error {
+		return r.RedisRepository.SaveHashCache(key, field, value, ttl)
+	})
+}
+
+func (r *breakerRedisRepository) SaveHashCacheD(key string, field string, value string, ttl time.Duration) error {
+	return r.SaveHashCache(key, field, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) SaveAllHashCache(key string, value map[string]string, ttl int) error {
+	return r.call("SaveAllHashCache", nil, func() error {
+		return r.RedisRepository.SaveAllHashCache(key, value, ttl)
+	})
+}
+
+func (r *breakerRedisRepository) SaveAllHashCacheD(key string, value map[string]string, ttl time.Duration) error {
+	return r.SaveAllHashCache(key, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) AddSetMember(key string, ttl int, member ...interface{}) error {
+	return r.call("AddSetMember", nil, func() error {
+		return r.RedisRepository.AddSetMember(key, ttl, member...)
+	})
+}
+
+func (r *breakerRedisRepository) AddSetMemberD(key string, ttl time.Duration, member ...interface{}) error {
+	return r.AddSetMember(key, ttlSeconds(ttl), member...)
+}
+
+func (r *breakerRedisRepository) GetCache(key string, value interface{}) error {
+	return r.call("GetCache", errFallbackMiss, func() error {
+		return r.RedisRepository.GetCache(key, value)
+	})
+}
+
+func (r *breakerRedisRepository) GetDelCache(key string, dest interface{}) error {
+	return r.call("GetDelCache", errFallbackMiss, func() error {
+		return r.RedisRepository.GetDelCache(key, dest)
+	})
+}
+
+func (r *breakerRedisRepository) SaveMultiCache(values map[string]interface{}, ttl int) error {
+	return r.call("SaveMultiCache", nil, func() error {
+		return r.RedisRepository.SaveMultiCache(values, ttl)
+	})
+}
+
+func (r *breakerRedisRepository) SaveMultiCacheD(values map[string]interface{}, ttl time.Duration) error {
+	return r.SaveMultiCache(values, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) GetMultiCache(keys []string, dest map[string]json.RawMessage) error {
+	return r.call("GetMultiCache", nil, func() error {
+		return r.RedisRepository.GetMultiCache(keys, dest)
+	})
+}
+
+// GetOrSetCache retrieves the cache, or calls the loader without saving its result while the circuit is open and the
+// fallback is enabled, or when redis cannot be reached in degraded mode.
+func (r *breakerRedisRepository) GetOrSetCache(key string, ttl int, dest interface{}, loader func() (interface{}, error)) error {
+	err := r.call("GetOrSetCache", errNoFallback, func() error {
+		return r.RedisRepository.GetOrSetCache(key, ttl, dest, loader)
+	})
+	switch {
+	case r.fallback && errors.Is(err, ErrCircuitOpen):
+		r.degrade("GetOrSetCache", 0)
+	case r.degraded && isConnectionError(err):
+		r.degrade("GetOrSetCache", 0)
+	default:
+		return err
+	}
+
+	value, err := loader()
+	if err != nil {
+		return err
+	}
+
+	codec := codecOf(r.RedisRepository)
+	b, err := encode(codec, value)
+	if err != nil {
+		return err
+	}
+
+	return codec.Unmarshal(b, dest)
+}
+
+func (r *breakerRedisRepository) GetOrSetCacheD(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error {
+	return r.GetOrSetCache(key, ttlSeconds(ttl), dest, loader)
+}
+
+func (r *breakerRedisRepository) GetHashCache(key string, field string) (value string, err error) {
+	err = r.call("GetHashCache", errFallbackMiss, func() (err error) {
+		value, err = r.RedisRepository.GetHashCache(key, field)
+		return err
+	})
+	return value, err
+}
+
+func (r *breakerRedisRepository) GetAllHashCache(key string) (values map[string]string, err error) {
+	err = r.call("GetAllHashCache", nil, func() (err error) {
+		values, err = r.RedisRepository.GetAllHashCache(key)
+		return err
+	})
+	return values, err
+}
+
+func (r *breakerRedisRepository) GetHashFields(key string, fields ...string) (values map[string]string, err error) {
+	err = r.call("GetHashFields", nil, func() (err error) {
+		values, err = r.RedisRepository.GetHashFields(key, fields...)
+		return err
+	})
+	return values, err
+}
+
+func (r *breakerRedisRepository) IncrementHashField(key string, field string, by int64) (value int64, err error) {
+	err = r.call("IncrementHashField", errNoFallback, func() (err error) {
+		value, err = r.RedisRepository.IncrementHashField(key, field, by)
+		return err
+	})
+	return value, err
+}
+
+func (r *breakerRedisRepository) RemoveCache(key string) error {
+	return r.call("RemoveCache", nil, func() error {
+		return r.RedisRepository.RemoveCache(key)
+	})
+}
+
+func (r *breakerRedisRepository) RemoveCacheByPattern(pattern string) (removed int64, err error) {
+	err = r.call("RemoveCacheByPattern", errNoFallback, func() (err error) {
+		removed, err = r.RedisRepository.RemoveCacheByPattern(pattern)
+		return err
+	})
+	return removed, err
+}
+
+func (r *breakerRedisRepository) RemoveSetMember(key string, member interface{}) error {
+	return r.call("RemoveSetMember", nil, func() error {
+		return r.RedisRepository.RemoveSetMember(key, member)
+	})
+}
+
+func (r *breakerRedisRepository) RemoveHashCache(key string, field string) error {
+	return r.call("RemoveHashCache", nil, func() error {
+		return r.RedisRepository.RemoveHashCache(key, field)
+	})
+}
+
+func (r *breakerRedisRepository) SetExpire(key string, ttl int) error {
+	return r.call("SetExpire", nil, func() error {
+		return r.RedisRepository.SetExpire(key, ttl)
+	})
+}
+
+func (r *breakerRedisRepository) SetExpireD(key string, ttl time.Duration) error {
+	return r.SetExpire(key, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) SetExpireAt(key string, at time.Time) error {
+	return r.call("SetExpireAt", nil, func() error {
+		return r.RedisRepository.SetExpireAt(key, at)
+	})
+}
+
+func (r *breakerRedisRepository) CopyCache(src string, dst string, replace bool) (copied bool, err error) {
+	err = r.call("CopyCache", errNoFallback, func() (err error) {
+		copied, err = r.RedisRepository.CopyCache(src, dst, replace)
+		return err
+	})
+	return copied, err
+}
+
+func (r *breakerRedisRepository) RenameCache(src string, dst string) error {
+	return r.call("RenameCache", errNoFallback, func() error {
+		return r.RedisRepository.RenameCache(src, dst)
+	})
+}
+
+func (r *breakerRedisRepository) CheckSetMember(key string, member interface{}) (ok bool, err error) {
+	err = r.call("CheckSetMember", nil, func() (err error) {
+		ok, err = r.RedisRepository.CheckSetMember(key, member)
+		return err
+	})
+	return ok, err
+}
+
+func (r *breakerRedisRepository) Exist(key string) (ok bool, err error) {
+	err = r.call("Exist", nil, func() (err error) {
+		ok, err = r.RedisRepository.Exist(key)
+		return err
+	})
+	return ok, err
+}
+
+func (r *breakerRedisRepository) ExistMulti(keys ...string) (exists map[string]bool, err error) {
+	err = r.call("ExistMulti", nil, func() (err error) {
+		exists, err = r.RedisRepository.ExistMulti(keys...)
+		return err
+	})
+	return exists, err
+}
+
+func (r *breakerRedisRepository) GetTTLMulti(keys ...string) (ttls map[string]time.Duration, err error) {
+	err = r.call("GetTTLMulti", nil, func() (err error) {
+		ttls, err = r.RedisRepository.GetTTLMulti(keys...)
+		return err
+	})
+	return ttls, err
+}
+
+func (r *breakerRedisRepository) NamespaceStats(prefix string) (stats *NamespaceStats, err error) {
+	err = r.call("NamespaceStats", errNoFallback, func() (err error) {
+		stats, err = r.RedisRepository.NamespaceStats(prefix)
+		return err
+	})
+	return stats, err
+}
+
+func (r *breakerRedisRepository) KeyMemoryUsage(key string) (bytes int64, err error) {
+	err = r.call("KeyMemoryUsage", errNoFallback, func() (err error) {
+		bytes, err = r.RedisRepository.KeyMemoryUsage(key)
+		return err
+	})
+	return bytes, err
+}
+
+func (r *breakerRedisRepository) KeyType(key string) (typ string, err error) {
+	err = r.call("KeyType", errNoFallback, func() (err error) {
+		typ, err = r.RedisRepository.KeyType(key)
+		return err
+	})
+	return typ, err
+}
+
+func (r *breakerRedisRepository) ObjectIdleTime(key string) (idle time.Duration, err error) {
+	err = r.call("ObjectIdleTime", errNoFallback, func() (err error) {
+		idle, err = r.RedisRepository.ObjectIdleTime(key)
+		return err
+	})
+	return idle, err
+}
+
+func (r *breakerRedisRepository) TopKeysByMemory(pattern string, n int) (top []KeyMemory, err error) {
+	err = r.call("TopKeysByMemory", errNoFallback, func() (err error) {
+		top, err = r.RedisRepository.TopKeysByMemory(pattern, n)
+		return err
+	})
+	return top, err
+}
+
+func (r *breakerRedisRepository) ScanKeys(pattern string, fn func(key string) error) error {
+	return r.call("ScanKeys", errNoFallback, func() error {
+		return r.RedisRepository.ScanKeys(pattern, fn)
+	})
+}
+
+func (r *breakerRedisRepository) RandomSetMembers(key string, n int) (members []string, err error) {
+	err = r.call("RandomSetMembers", nil, func() (err error) {
+		members, err = r.RedisRepository.RandomSetMembers(key, n)
+		return err
+	})
+	return members, err
+}
+
+func (r *breakerRedisRepository) GetSetMembers(key string) (members []string, err error) {
+	err = r.call("GetSetMembers", nil, func() (err error) {
+		members, err = r.RedisRepository.GetSetMembers(key)
+		return err
+	})
+	return members, err
+}
+
+func (r *breakerRedisRepository) CountSetMembers(key string) (count int64, err error) {
+	err = r.call("CountSetMembers", nil, func() (err error) {
+		count, err = r.RedisRepository.CountSetMembers(key)
+		return err
+	})
+	return count, err
+}
+
+func (r *breakerRedisRepository) UnionSets(keys ...string) (members []string, err error) {
+	err = r.call("UnionSets", nil, func() (err error) {
+		members, err = r.RedisRepository.UnionSets(keys...)
+		return err
+	})
+	return members, err
+}
+
+func (r *breakerRedisRepository) UnionSetsStore(dst string, keys ...string) (count int64, err error) {
+	err = r.call("UnionSetsStore", errNoFallback, func() (err error) {
+		count, err = r.RedisRepository.UnionSetsStore(dst, keys...)
+		return err
+	})
+	return count, err
+}
+
+func (r *breakerRedisRepository) IntersectSets(keys ...string) (members []string, err error) {
+	err = r.call("IntersectSets", nil, func() (err error) {
+		members, err = r.RedisRepository.IntersectSets(keys...)
+		return err
+	})
+	return members, err
+}
+
+func (r *breakerRedisRepository) IntersectSetsStore(dst string, keys ...string) (count int64, err error) {
+	err = r.call("IntersectSetsStore", errNoFallback, func() (err error) {
+		count, err = r.RedisRepository.IntersectSetsStore(dst, keys...)
+		return err
+	})
+	return count, err
+}
+
+func (r *breakerRedisRepository) DiffSets(keys ...string) (members []string, err error) {
+	err = r.call("DiffSets", nil, func() (err error) {
+		members, err = r.RedisRepository.DiffSets(keys...)
+		return err
+	})
+	return members, err
+}
+
+func (r *breakerRedisRepository) DiffSetsStore(dst string, keys ...string) (count int64, err error) {
+	err = r.call("DiffSetsStore", errNoFallback, func() (err error) {
+		count, err = r.RedisRepository.DiffSetsStore(dst, keys...)
+		return err
+	})
+	return count, err
+}
+
+func (r *breakerRedisRepository) AddUnique(key string, ttl int, items ...interface{}) error {
+	return r.call("AddUnique", nil, func() error {
+		return r.RedisRepository.AddUnique(key, ttl, items...)
+	})
+}
+
+func (r *breakerRedisRepository) AddUniqueD(key string, ttl time.Duration, items ...interface{}) error {
+	return r.AddUnique(key, ttlSeconds(ttl), items...)
+}
+
+func (r *breakerRedisRepository) CountUnique(keys ...string) (count int64, err error) {
+	err = r.call("CountUnique", nil, func() (err error) {
+		count, err = r.RedisRepository.CountUnique(keys...)
+		return err
+	})
+	return count, err
+}
+
+func (r *breakerRedisRepository) SetBit(key string, offset int64, value bool, ttl int) (previous bool, err error) {
+	err = r.call("SetBit", errNoFallback, func() (err error) {
+		previous, err = r.RedisRepository.SetBit(key, offset, value, ttl)
+		return err
+	})
+	return previous, err
+}
+
+func (r *breakerRedisRepository) SetBitD(key string, offset int64, value bool, ttl time.Duration) (bool, error) {
+	return r.SetBit(key, offset, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) GetBit(key string, offset int64) (value bool, err error) {
+	err = r.call("GetBit", nil, func() (err error) {
+		value, err = r.RedisRepository.GetBit(key, offset)
+		return err
+	})
+	return value, err
+}
+
+func (r *breakerRedisRepository) CountBits(key string) (count int64, err error) {
+	err = r.call("CountBits", nil, func() (err error) {
+		count, err = r.RedisRepository.CountBits(key)
+		return err
+	})
+	return count, err
+}
+
+func (r *breakerRedisRepository) RandomHashFields(key string, n int) (fields []string, err error) {
+	err = r.call("RandomHashFields", nil, func() (err error) {
+		fields, err = r.RedisRepository.RandomHashFields(key, n)
+		return err
+	})
+	return fields, err
+}
+
+func (r *breakerRedisRepository) SaveVersionedCache(key string, value interface{}, ttl int) (version string, err error) {
+	err = r.call("SaveVersionedCache", errNoFallback, func() (err error) {
+		version, err = r.RedisRepository.SaveVersionedCache(key, value, ttl)
+		return err
+	})
+	return version, err
+}
+
+func (r *breakerRedisRepository) SaveVersionedCacheD(key string, value interface{}, ttl time.Duration) (string, error) {
+	return r.SaveVersionedCache(key, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) SaveVersionedCacheIfMatch(key string, version string, value interface{}, ttl int) (current string, err error) {
+	err = r.call("SaveVersionedCacheIfMatch", errNoFallback, func() (err error) {
+		current, err = r.RedisRepository.SaveVersionedCacheIfMatch(key, version, value, ttl)
+		return err
+	})
+	return current, err
+}
+
+func (r *breakerRedisRepository) SaveVersionedCacheIfMatchD(key string, version string, value interface{}, ttl time.Duration) (string, error) {
+	return r.SaveVersionedCacheIfMatch(key, version, value, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) GetVersionedCache(key string, version string, value interface{}) (current string, changed bool, err error) {
+	err = r.call("GetVersionedCache", errFallbackMiss, func() (err error) {
+		current, changed, err = r.RedisRepository.GetVersionedCache(key, version, value)
+		return err
+	})
+	return current, changed, err
+}
+
+func (r *breakerRedisRepository) PushList(key string, ttl int, values ...interface{}) (length int64, err error) {
+	err = r.call("PushList", errNoFallback, func() (err error) {
+		length, err = r.RedisRepository.PushList(key, ttl, values...)
+		return err
+	})
+	return length, err
+}
+
+func (r *breakerRedisRepository) PushListD(key string, ttl time.Duration, values ...interface{}) (int64, error) {
+	return r.PushList(key, ttlSeconds(ttl), values...)
+}
+
+func (r *breakerRedisRepository) PopList(key string) (value string, err error) {
+	err = r.call("PopList", errFallbackMiss, func() (err error) {
+		value, err = r.RedisRepository.PopList(key)
+		return err
+	})
+	return value, err
+}
+
+func (r *breakerRedisRepository) BPopList(timeout int, keys ...string) (key string, value string, err error) {
+	err = r.call("BPopList", errNoFallback, func() (err error) {
+		key, value, err = r.RedisRepository.BPopList(timeout, keys...)
+		return err
+	})
+	return key, value, err
+}
+
+func (r *breakerRedisRepository) GetListRange(key string, start int64, stop int64) (values []string, err error) {
+	err = r.call("GetListRange", nil, func() (err error) {
+		values, err = r.RedisRepository.GetListRange(key, start, stop)
+		return err
+	})
+	return values, err
+}
+
+func (r *breakerRedisRepository) TrimList(key string, start int64, stop int64) error {
+	return r.call("TrimList", nil, func() error {
+		return r.RedisRepository.TrimList(key, start, stop)
+	})
+}
+
+func (r *breakerRedisRepository) IncrementCache(key string, by int64, ttl int) (value int64, err error) {
+	err = r.call("IncrementCache", errNoFallback, func() (err error) {
+		value, err = r.RedisRepository.IncrementCache(key, by, ttl)
+		return err
+	})
+	return value, err
+}
+
+func (r *breakerRedisRepository) IncrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return r.IncrementCache(key, by, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) DecrementCache(key string, by int64, ttl int) (value int64, err error) {
+	err = r.call("DecrementCache", errNoFallback, func() (err error) {
+		value, err = r.RedisRepository.DecrementCache(key, by, ttl)
+		return err
+	})
+	return value, err
+}
+
+func (r *breakerRedisRepository) DecrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return r.DecrementCache(key, by, ttlSeconds(ttl))
+}
+
+func (r *breakerRedisRepository) WatchTransaction(keys []string, fn func(tx RedisTx) error, retries int) error {
+	return r.call("WatchTransaction", errNoFallback, func() error {
+		return r.RedisRepository.WatchTransaction(keys, fn, retries)
+	})
+}
+
+func (r *breakerRedisRepository) Pipeline(fn func(p RedisPipeline) error) error {
+	return r.call("Pipeline", errNoFallback, func() error {
+		return r.RedisRepository.Pipeline(fn)
+	})
+}
+
+func (r *breakerRedisRepository) LoadScript(name string, body string) error {
+	return r.call("LoadScript", errNoFallback, func() error {
+		return r.RedisRepository.LoadScript(name, body)
+	})
+}
+
+func (r *breakerRedisRepository) RunScript(name string, keys []string, args ...interface{}) (res interface{}, err error) {
+	err = r.call("RunScript", errNoFallback, func() (err error) {
+		res, err = r.RedisRepository.RunScript(name, keys, args...)
+		return err
+	})
+	return res, err
+}
+
+func (r *breakerRedisRepository) Publish(channel string, payload interface{}) error {
+	return r.call("Publish", nil, func() error {
+		return r.RedisRepository.Publish(channel, payload)
+	})
+}
+
+func (r *breakerRedisRepository) ShardedPublish(channel string, payload interface{}) error {
+	return r.call("ShardedPublish", nil, func() error {
+		return r.RedisRepository.ShardedPublish(channel, payload)
+	})
+}
+
+func (r *breakerRedisRepository) AcquireLock(key string, ttl time.Duration) (lock Lock, err error) {
+	err = r.call("AcquireLock", errNoFallback, func() (err error) {
+		lock, err = r.RedisRepository.AcquireLock(key, ttl)
+		return err
+	})
+	return lock, err
+}
+
+func (r *breakerRedisRepository) AcquireSemaphore(key string, limit int, ttl time.Duration) (lock Lock, err error) {
+	err = r.call("AcquireSemaphore", errNoFallback, func() (err error) {
+		lock, err = r.RedisRepository.AcquireSemaphore(key, limit, ttl)
+		return err
+	})
+	return lock, err
+}
+
+func (r *breakerRedisRepository) AcquireSemaphoreWait(key string, limit int, ttl time.Duration, wait time.Duration) (lock Lock, err error) {
+	err = r.call("AcquireSemaphoreWait", errNoFallback, func() (err error) {
+		lock, err = r.RedisRepository.AcquireSemaphoreWait(key, limit, ttl, wait)
+		return err
+	})
+	return lock, err
+}
+
+func (r *breakerRedisRepository) ReleaseSemaphore(permit Lock) error {
+	return r.call("ReleaseSemaphore", errNoFallback, func() error {
+		return r.RedisRepository.ReleaseSemaphore(permit)
+	})
+}
+
+// HealthCheck is passed to the wrapped repository, so the probes see the state of redis rather than the one of the
+// circuit.
+func (r *breakerRedisRepository) HealthCheck(ctx context.Context) error {
+	return r.RedisRepository.HealthCheck(ctx)
+}
+
+// Subscribe is passed to the wrapped repository, the subscription lasts until ctx is done so it is not a call the
+// breaker can fail fast.
+func (r *breakerRedisRepository) Subscribe(ctx context.Context, channel string, handler func(payload []byte) error) error {
+	return r.RedisRepository.Subscribe(ctx, channel, handler)
+}
+
+// ShardedSubscribe is passed to the wrapped repository as Subscribe is.
+func (r *breakerRedisRepository) ShardedSubscribe(ctx context.Context, channel string, handler func(payload []byte) error) error {
+	return r.RedisRepository.ShardedSubscribe(ctx, channel, handler)
+}
+
+// PSubscribe is passed to the wrapped repository as Subscribe is.
+func (r *breakerRedisRepository) PSubscribe(ctx context.Context, pattern string, handler func(channel string, payload []byte) error) error {
+	return r.RedisRepository.PSubscribe(ctx, pattern, handler)
+}
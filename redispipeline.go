@@ -0,0 +1,108 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// RedisPipeline is the view of redis inside Pipeline, the writes are queued and sent to redis in a single round trip
+// once the function returns.
+type RedisPipeline interface {
+	SaveCache(key string, value interface{}, ttl int) error
+	SaveHashCache(key string, field string, value string, ttl int)
+	AddSetMember(key string, ttl int, member ...interface{})
+	SetExpire(key string, ttl int)
+	RemoveCache(key string)
+}
+
+type redisPipeline struct {
+	ctx  context.Context
+	repo *redisRepository
+	ops  []func(pipe redis.Pipeliner)
+}
+
+// SaveCache queues the write of the cache, 0 ttl means no expiration time. The value is encoded right away.
+func (p *redisPipeline) SaveCache(key string, value interface{}, ttl int) error {
+	v, err := encode(p.repo.codec, value)
+	if err != nil {
+		return err
+	}
+
+	ttl = p.repo.jitterTTL(ttl)
+	p.ops = append(p.ops, func(pipe redis.Pipeliner) {
+		pipe.Set(p.ctx, key, v, cacheExpiration(ttl))
+	})
+
+	return nil
+}
+
+// SaveHashCache queues the write of the field of the hash, 0 ttl keeps the expiration time of the hash.
+func (p *redisPipeline) SaveHashCache(key string, field string, value string, ttl int) {
+	ttl = p.repo.jitterTTL(ttl)
+	p.ops = append(p.ops, func(pipe redis.Pipeliner) {
+		pipe.HSet(p.ctx, key, field, value)
+		p.repo.expireHash(p.ctx, pipe, key, ttl, field)
+	})
+}
+
+// AddSetMember queues the addition of the members to the set, 0 ttl keeps the expiration time of the set.
+func (p *redisPipeline) AddSetMember(key string, ttl int, member ...interface{}) {
+	ttl = p.repo.jitterTTL(ttl)
+	p.ops = append(p.ops, func(pipe redis.Pipeliner) {
+		pipe.SAdd(p.ctx, key, member...)
+		if ttl > 0 {
+			pipe.Expire(p.ctx, key, time.Duration(ttl)*time.Second)
+		}
+	})
+}
+
+// SetExpire queues the change of the expiration time of the cache, RedisKeepTTL keeps the current one.
+func (p *redisPipeline) SetExpire(key string, ttl int) {
+	if ttl == RedisKeepTTL {
+		return
+	}
+
+	p.ops = append(p.ops, func(pipe redis.Pipeliner) {
+		pipe.Expire(p.ctx, key, time.Duration(ttl)*time.Second)
+	})
+}
+
+// RemoveCache queues the removal of the cache.
+func (p *redisPipeline) RemoveCache(key string) {
+	p.ops = append(p.ops, func(pipe redis.Pipeliner) {
+		pipe.Del(p.ctx, key)
+	})
+}
+
+// keyRecordingPipeline is a RedisPipeline which records the keys it writes, so the wrappers holding the caches in
+// memory evict them once the pipeline is sent.
+type keyRecordingPipeline struct {
+	RedisPipeline
+	keys []string
+}
+
+func (p *keyRecordingPipeline) SaveCache(key string, value interface{}, ttl int) error {
+	p.keys = append(p.keys, key)
+	return p.RedisPipeline.SaveCache(key, value, ttl)
+}
+
+func (p *keyRecordingPipeline) SaveHashCache(key string, field string, value string, ttl int) {
+	p.keys = append(p.keys, key)
+	p.RedisPipeline.SaveHashCache(key, field, value, ttl)
+}
+
+func (p *keyRecordingPipeline) AddSetMember(key string, ttl int, member ...interface{}) {
+	p.keys = append(p.keys, key)
+	p.RedisPipeline.AddSetMember(key, ttl, member...)
+}
+
+func (p *keyRecordingPipeline) SetExpire(key string, ttl int) {
+	p.keys = append(p.keys, key)
+	p.RedisPipeline.SetExpire(key, ttl)
+}
+
+func (p *keyRecordingPipeline) RemoveCache(key string) {
+	p.keys = append(p.keys, key)
+	p.RedisPipeline.RemoveCache(key)
+}
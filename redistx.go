@@ -0,0 +1,71 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// RedisTx is the view of redis inside WatchTransaction, the reads are run right away and the writes are queued until
+// the transaction commits.
+type RedisTx interface {
+	GetCache(key string, value interface{}) error
+	GetHashCache(key string, field string) (string, error)
+	SaveCache(key string, value interface{}, ttl int) error
+	SaveHashCache(key string, field string, value string, ttl int)
+	RemoveCache(key string)
+}
+
+type redisTx struct {
+	ctx   context.Context
+	tx    *redis.Tx
+	codec Codec
+	ops   []func(pipe redis.Pipeliner)
+}
+
+// GetCache retrieves the cache and unmarshal it into value, ErrCacheMiss is returned if the cache does not exist.
+func (t *redisTx) GetCache(key string, value interface{}) error {
+	v, err := t.tx.Get(t.ctx, key).Result()
+	if err != nil {
+		return translateError(err, true)
+	}
+
+	return t.codec.Unmarshal([]byte(v), value)
+}
+
+// GetHashCache retrieves the field of the hash, ErrCacheMiss is returned if the field does not exist.
+func (t *redisTx) GetHashCache(key string, field string) (string, error) {
+	v, err := t.tx.HGet(t.ctx, key, field).Result()
+	return v, translateError(err, true)
+}
+
+// SaveCache queues the write of the cache, 0 ttl means no expiration time. The value is encoded right away.
+func (t *redisTx) SaveCache(key string, value interface{}, ttl int) error {
+	v, err := encode(t.codec, value)
+	if err != nil {
+		return err
+	}
+
+	t.ops = append(t.ops, func(pipe redis.Pipeliner) {
+		pipe.Set(t.ctx, key, v, cacheExpiration(ttl))
+	})
+
+	return nil
+}
+
+// SaveHashCache queues the write of the field of the hash, 0 ttl keeps the expiration time of the hash.
+func (t *redisTx) SaveHashCache(key string, field string, value string, ttl int) {
+	t.ops = append(t.ops, func(pipe redis.Pipeliner) {
+		pipe.HSet(t.ctx, key, field, value)
+		if ttl > 0 {
+			pipe.Expire(t.ctx, key, time.Duration(ttl)*time.Second)
+		}
+	})
+}
+
+// RemoveCache queues the removal of the cache.
+func (t *redisTx) RemoveCache(key string) {
+	t.ops = append(t.ops, func(pipe redis.Pipeliner) {
+		pipe.Del(t.ctx, key)
+	})
+}
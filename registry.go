@@ -0,0 +1,267 @@
+package repositorysdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EntitySchema is a struct that holds the declaration of an entity in a SchemaRegistry.
+type EntitySchema struct {
+	// Name is the name used by the generated code, e.g. NewUserRepository, it defaults to the name of the type.
+	Name string
+	// Type is the type of the entity, e.g. *model.User.
+	Type reflect.Type
+	// Table is the name returned by TableName, it is also the name of the search index.
+	Table string
+	// CacheKeyPrefix is the prefix of the cache keys of the entity, it defaults to Table + ":".
+	CacheKeyPrefix string
+
+	prototype Entity
+	repoType  reflect.Type
+}
+
+// EntityOption is a function that customizes the declaration of an entity.
+type EntityOption func(*EntitySchema)
+
+// WithEntityName sets the name used by the generated code instead of the name of the type.
+func WithEntityName(name string) EntityOption {
+	return func(s *EntitySchema) {
+		s.Name = name
+	}
+}
+
+// WithCacheKeyPrefix sets the prefix of the cache keys of the entity instead of its table name.
+func WithCacheKeyPrefix(prefix string) EntityOption {
+	return func(s *EntitySchema) {
+		s.CacheKeyPrefix = prefix
+	}
+}
+
+// Entity returns a new zero value of the entity, e.g. to be migrated.
+func (s EntitySchema) Entity() Entity {
+	if s.Type.Kind() == reflect.Pointer {
+		return reflect.New(s.Type.Elem()).Interface().(Entity)
+	}
+
+	return s.prototype
+}
+
+// CacheKey returns the cache key of the entity with the given id.
+func (s EntitySchema) CacheKey(id string) string {
+	return s.CacheKeyPrefix + id
+}
+
+// SearchMapping returns the OpenSearch mapping of the fields tagged by `searchindex:"true"`, or nil if the entity has
+// no such field. The type of a field is derived from its Go type, the tag `searchtype` overrides it, e.g.
+// `searchtype:"keyword"`.
+func (s EntitySchema) SearchMapping() map[string]interface{} {
+	properties := map[string]interface{}{}
+	collectSearchMapping(indirectType(s.Type), properties)
+	if len(properties) == 0 {
+		return nil
+	}
+
+	properties["id"] = map[string]interface{}{"type": "keyword"}
+
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": properties,
+		},
+	}
+}
+
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas []EntitySchema
+}
+
+// NewSchemaRegistry function that create a new instance of SchemaRegistry, where a service declares its entities once
+// for the migrations, the maintenance commands, and the code generated by Generate.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{}
+}
+
+// RegisterEntity declares the entity T in the registry, it panics if an entity of the same name is already declared
+// as the registry is meant to be filled at init.
+//
+// Parameters:
+// - r: the registry.
+// - opts: the options of the declaration.
+//
+// Returns:
+// - EntitySchema: the declaration of the entity.
+func RegisterEntity[T Entity](r *SchemaRegistry, opts ...EntityOption) EntitySchema {
+	var zero T
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	s := EntitySchema{
+		Name:      indirectType(t).Name(),
+		Type:      t,
+		prototype: zero,
+		repoType:  reflect.TypeOf((*GormRepository[T])(nil)).Elem(),
+	}
+	s.Table = s.Entity().TableName()
+	s.CacheKeyPrefix = s.Table + ":"
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, registered := range r.schemas {
+		if registered.Name == s.Name {
+			panic(fmt.Sprintf("repositorysdk: entity %s is already registered", s.Name))
+		}
+	}
+	r.schemas = append(r.schemas, s)
+
+	return s
+}
+
+// Schemas returns the declarations of the entities in the order they were registered.
+func (r *SchemaRegistry) Schemas() []EntitySchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]EntitySchema(nil), r.schemas...)
+}
+
+// Schema returns the declaration of the entity with the given name.
+func (r *SchemaRegistry) Schema(name string) (EntitySchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.schemas {
+		if s.Name == name {
+			return s, true
+		}
+	}
+
+	return EntitySchema{}, false
+}
+
+// Entities returns a new zero value of every entity, e.g. for AutoMigrate or MaintenanceTool.Entities.
+func (r *SchemaRegistry) Entities() []Entity {
+	schemas := r.Schemas()
+
+	entities := make([]Entity, len(schemas))
+	for i, s := range schemas {
+		entities[i] = s.Entity()
+	}
+
+	return entities
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// collectSearchMapping walks the fields of t as collectSearchFields does.
+func collectSearchMapping(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Tag.Get("searchindex") != "true" {
+			if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+				collectSearchMapping(indirectType(field.Type), properties)
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+
+		if typ := field.Tag.Get("searchtype"); typ != "" {
+			properties[name] = map[string]interface{}{"type": typ}
+			continue
+		}
+		if mapping := searchFieldMapping(field.Type, map[reflect.Type]bool{}); mapping != nil {
+			properties[name] = mapping
+		}
+	}
+}
+
+// searchFieldMapping returns the mapping of a field of type t, the strings are mapped as the dynamic mapping of
+// OpenSearch does, to a text with a keyword sub-field. It returns nil for the types encoded by their own MarshalJSON,
+// which are left to the dynamic mapping.
+func searchFieldMapping(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	t = indirectType(t)
+
+	if n, ok := reflect.Zero(t).Interface().(interface{ valueType() reflect.Type }); ok {
+		return searchFieldMapping(n.valueType(), seen)
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "date"}
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+		// uuid.UUID and the like, encoded as a string
+		return map[string]interface{}{"type": "keyword"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{
+			"type": "text",
+			"fields": map[string]interface{}{
+				"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+			},
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "long"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "double"}
+	case reflect.Slice, reflect.Array:
+		return searchFieldMapping(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if mapping := searchFieldMapping(field.Type, seen); mapping != nil {
+				properties[name] = mapping
+			}
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	default:
+		return map[string]interface{}{"type": "object"}
+	}
+}
@@ -0,0 +1,229 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"time"
+)
+
+// requeueStaleScript puts back into the queue KEYS[1] the messages of the processing list KEYS[2] whose deadline, kept
+// in the sorted set KEYS[3], has passed. A message moved to the processing list by a consumer which crashed before
+// setting its deadline is given a deadline of ARGV[1] milliseconds from now. It returns the number of messages put back.
+var requeueStaleScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local requeued = 0
+for _, id in ipairs(redis.call('LRANGE', KEYS[2], 0, -1)) do
+	local deadline = redis.call('ZSCORE', KEYS[3], id)
+	if not deadline then
+		redis.call('ZADD', KEYS[3], now + tonumber(ARGV[1]), id)
+	elseif tonumber(deadline) <= now then
+		redis.call('LREM', KEYS[2], 1, id)
+		redis.call('ZREM', KEYS[3], id)
+		redis.call('RPUSH', KEYS[1], id)
+		requeued = requeued + 1
+	end
+end
+
+return requeued
+`)
+
+// startProcessingScript sets the deadline of the message ARGV[2] moved to the processing list, ARGV[1] milliseconds
+// from now in the sorted set KEYS[1], and returns its payload kept in the hash KEYS[2].
+var startProcessingScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[1]), ARGV[2])
+
+return redis.call('HGET', KEYS[2], ARGV[2]) or ''
+`)
+
+// ReliableMessage is a struct that holds a message taken from a reliable queue.
+type ReliableMessage struct {
+	ID      string
+	Payload []byte
+}
+
+type ReliableQueue interface {
+	EnqueueReliable(payload interface{}) (string, error)
+	DequeueToProcessing(timeout int) (*ReliableMessage, error)
+	AckProcessed(id string) error
+	RequeueStale() (int64, error)
+	Len() (int64, error)
+	Processing() (int64, error)
+}
+
+type reliableQueue struct {
+	repo       RedisRepository
+	key        string
+	visibility time.Duration
+}
+
+// NewReliableQueue function that create a new instance of ReliableQueue which processes its messages at least once,
+// backed by the lists of redis. A dequeued message is moved atomically to a processing list, and is put back into the
+// queue by RequeueStale when it is not acked within the visibility timeout, e.g. when its worker crashed. A zero
+// visibility timeout means 1 minute.
+func NewReliableQueue(repo RedisRepository, name string, visibility time.Duration) ReliableQueue {
+	if visibility <= 0 {
+		visibility = time.Minute
+	}
+
+	return &reliableQueue{
+		repo:       repo,
+		key:        ReliableQueueKeyPrefix + "{" + name + "}",
+		visibility: visibility,
+	}
+}
+
+func (q *reliableQueue) keys() []string {
+	key := redisKey(q.repo, q.key)
+	return []string{key, key + ":processing", key + ":deadlines", key + ":payloads"}
+}
+
+// EnqueueReliable appends a message to the queue by using the command `LPUSH`, the payload is encoded.
+//
+// Parameters:
+// - payload: the payload of the message.
+//
+// Returns:
+// - string: the id of the message, e.g. to ack it.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *reliableQueue) EnqueueReliable(payload interface{}) (id string, err error) {
+	defer wrapError(&err, "EnqueueReliable", "", q.key, time.Now())
+
+	v, err := encode(codecOf(q.repo), payload)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	id = uuid.NewString()
+	keys := q.keys()
+	if _, err := q.repo.GetUniversalClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keys[3], id, v)
+		pipe.LPush(ctx, keys[0], id)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// DequeueToProcessing takes the oldest message of the queue and moves it to the processing list by using the command
+// `BRPOPLPUSH`, it blocks until a message is enqueued or the timeout is reached. The message must be acked once it is
+// processed, or it is put back into the queue by RequeueStale after the visibility timeout.
+//
+// Parameters:
+// - timeout: the timeout in seconds, 0 blocks indefinitely.
+//
+// Returns:
+// - *ReliableMessage: the message.
+// - error: ErrKeyNotFound if the timeout is reached, otherwise an error if something goes wrong.
+func (q *reliableQueue) DequeueToProcessing(timeout int) (message *ReliableMessage, err error) {
+	defer wrapError(&err, "DequeueToProcessing", "", q.key, time.Now())
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second+10*time.Second)
+		defer cancel()
+	}
+
+	keys := q.keys()
+	id, err := q.repo.GetUniversalClient().BRPopLPush(ctx, keys[0], keys[1], time.Duration(timeout)*time.Second).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a crash before the deadline is set is caught by RequeueStale, which sets it
+	payload, err := startProcessingScript.Run(ctx, q.repo.GetUniversalClient(), keys[2:], q.visibility.Milliseconds(), id).Text()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReliableMessage{ID: id, Payload: []byte(payload)}, nil
+}
+
+// AckProcessed removes a processed message from the processing list and drops its payload. A message which was put
+// back into the queue after its visibility timeout stays in the queue, and is processed again.
+//
+// Parameters:
+// - id: the id of the message.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (q *reliableQueue) AckProcessed(id string) (err error) {
+	defer wrapError(&err, "AckProcessed", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys := q.keys()
+	var removed *redis.IntCmd
+	if _, err := q.repo.GetUniversalClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.LRem(ctx, keys[1], 1, id)
+		pipe.ZRem(ctx, keys[2], id)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if removed.Val() == 0 {
+		// requeued, the payload is still needed
+		return nil
+	}
+
+	return q.repo.GetUniversalClient().HDel(ctx, keys[3], id).Err()
+}
+
+// RequeueStale puts back into the queue the messages of the processing list which are not acked within the
+// visibility timeout by using a Lua script, it is meant to be run periodically by one or more instances.
+//
+// Returns:
+// - int64: the number of messages put back into the queue.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *reliableQueue) RequeueStale() (n int64, err error) {
+	defer wrapError(&err, "RequeueStale", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return requeueStaleScript.Run(ctx, q.repo.GetUniversalClient(), q.keys()[:3], q.visibility.Milliseconds()).Int64()
+}
+
+// Len returns the number of messages waiting in the queue by using the command `LLEN`.
+//
+// Returns:
+// - int64: the number of messages waiting in the queue.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *reliableQueue) Len() (n int64, err error) {
+	defer wrapError(&err, "Len", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return q.repo.GetUniversalClient().LLen(ctx, q.keys()[0]).Result()
+}
+
+// Processing returns the number of messages dequeued and not acked yet by using the command `LLEN`.
+//
+// Returns:
+// - int64: the number of messages being processed.
+// - error: an error if something goes wrong, otherwise nil.
+func (q *reliableQueue) Processing() (n int64, err error) {
+	defer wrapError(&err, "Processing", "", q.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return q.repo.GetUniversalClient().LLen(ctx, q.keys()[1]).Result()
+}
@@ -0,0 +1,258 @@
+package repositorysdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// TunableConfig is a struct that holds the settings which can be changed at runtime without reconnecting. The zero
+// value of a pool setting means its default value, as in PostgresDatabaseConfig. The redis timeouts and the cache TTLs
+// are fixed when the client is created, so they are not tunable.
+type TunableConfig struct {
+	MaxIdleConn     int  `mapstructure:"max_idle_conn" json:"max_idle_conn"`
+	MaxOpenConn     int  `mapstructure:"max_open_conn" json:"max_open_conn"`
+	ConnMaxLifetime int  `mapstructure:"conn_max_lifetime" json:"conn_max_lifetime"`
+	ConnMaxIdleTime int  `mapstructure:"conn_max_idle_time" json:"conn_max_idle_time"`
+	Debug           bool `mapstructure:"debug" json:"debug"`
+}
+
+// ConfigLoader reads the current tunable settings, it is called on every reload.
+type ConfigLoader func() (*TunableConfig, error)
+
+// ConfigFile returns a ConfigLoader which reads the tunable settings from a json file.
+func ConfigFile(path string) ConfigLoader {
+	return func() (*TunableConfig, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		conf := &TunableConfig{}
+		if err := json.Unmarshal(b, conf); err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+
+		return conf, nil
+	}
+}
+
+// ConfigEnv returns a ConfigLoader which reads the tunable settings from the environment variables named after the
+// upper-cased mapstructure tags with the given prefix, e.g. APP_MAX_OPEN_CONN for the prefix APP_.
+func ConfigEnv(prefix string) ConfigLoader {
+	return func() (*TunableConfig, error) {
+		conf := &TunableConfig{}
+
+		ints := map[string]*int{
+			"MAX_IDLE_CONN":      &conf.MaxIdleConn,
+			"MAX_OPEN_CONN":      &conf.MaxOpenConn,
+			"CONN_MAX_LIFETIME":  &conf.ConnMaxLifetime,
+			"CONN_MAX_IDLE_TIME": &conf.ConnMaxIdleTime,
+		}
+		for name, field := range ints {
+			v, ok := os.LookupEnv(prefix + name)
+			if !ok {
+				continue
+			}
+
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse env %s%s: %w", prefix, name, err)
+			}
+			*field = n
+		}
+
+		if v, ok := os.LookupEnv(prefix + "DEBUG"); ok {
+			debug, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse env %sDEBUG: %w", prefix, err)
+			}
+			conf.Debug = debug
+		}
+
+		return conf, nil
+	}
+}
+
+type ConfigWatcher interface {
+	OnReload(fn func(conf *TunableConfig))
+	Current() *TunableConfig
+	Reload() error
+	Run(ctx context.Context, interval time.Duration) error
+}
+
+type configWatcher struct {
+	load ConfigLoader
+
+	mu      sync.Mutex
+	current atomic.Pointer[TunableConfig]
+	hooks   []func(conf *TunableConfig)
+}
+
+// NewConfigWatcher function that create a new instance of ConfigWatcher which re-reads the tunable settings with load
+// and applies them through the hooks registered by OnReload.
+func NewConfigWatcher(load ConfigLoader) ConfigWatcher {
+	w := &configWatcher{load: load}
+	w.current.Store(&TunableConfig{})
+
+	return w
+}
+
+// OnReload registers a hook which applies the settings, it is called on every successful reload in the order of
+// registration.
+func (w *configWatcher) OnReload(fn func(conf *TunableConfig)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.hooks = append(w.hooks, fn)
+}
+
+// Current returns the settings of the last successful reload, the zero settings before the first one.
+func (w *configWatcher) Current() *TunableConfig {
+	return w.current.Load()
+}
+
+// Reload reads the settings and applies them, the current settings are kept if they cannot be read.
+//
+// Returns:
+// - error: an error if the settings cannot be read, otherwise nil.
+func (w *configWatcher) Reload() (err error) {
+	defer wrapError(&err, "Reload", "", "", time.Now())
+
+	conf, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.current.Store(conf)
+	for _, hook := range w.hooks {
+		hook(conf)
+	}
+
+	return nil
+}
+
+// Run reloads the settings once, then on every SIGHUP and every interval until ctx is done. A zero interval reloads
+// only on SIGHUP. The failed reloads after the first one are ignored and the current settings are kept.
+//
+// Parameters:
+// - ctx: the context which stops the watcher.
+// - interval: the interval between the reloads, 0 means reloading only on SIGHUP.
+//
+// Returns:
+// - error: the error of the first reload, otherwise the error of ctx when it is done.
+func (w *configWatcher) Run(ctx context.Context, interval time.Duration) error {
+	if err := w.Reload(); err != nil {
+		return err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-hup:
+		case <-tick:
+		}
+
+		_ = w.Reload()
+	}
+}
+
+// ApplyPostgresPool returns a hook which applies the pool settings to the connection pool of db.
+//
+// Parameters:
+// - db: the GORM database connection.
+//
+// Returns:
+// - func(conf *TunableConfig): the hook to be registered by OnReload.
+// - error: an error if the connection pool cannot be retrieved, otherwise nil.
+func ApplyPostgresPool(db *gorm.DB) (func(conf *TunableConfig), error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(conf *TunableConfig) {
+		pool := &PostgresDatabaseConfig{MaxIdleConn: conf.MaxIdleConn, MaxOpenConn: conf.MaxOpenConn}
+
+		sqlDB.SetMaxIdleConns(pool.GetMaxIdleConn())
+		sqlDB.SetMaxOpenConns(pool.GetMaxOpenConn())
+		sqlDB.SetConnMaxLifetime(time.Duration(conf.ConnMaxLifetime) * time.Second)
+		sqlDB.SetConnMaxIdleTime(time.Duration(conf.ConnMaxIdleTime) * time.Second)
+	}, nil
+}
+
+// ApplyDebugMode replaces the logger of db with a logger whose level follows the Debug setting, and returns the hook
+// which switches the level. It must be called before db is shared between goroutines.
+//
+// Parameters:
+// - db: the GORM database connection.
+//
+// Returns:
+// - func(conf *TunableConfig): the hook to be registered by OnReload.
+func ApplyDebugMode(db *gorm.DB) func(conf *TunableConfig) {
+	logger := &switchableLogger{}
+	logger.current.Store(&loggerHolder{db.Logger})
+	db.Logger = logger
+
+	return func(conf *TunableConfig) {
+		level := gormLogger.Silent
+		if conf.Debug {
+			level = gormLogger.Info
+		}
+
+		logger.current.Store(&loggerHolder{gormLogger.Default.LogMode(level)})
+	}
+}
+
+type loggerHolder struct {
+	gormLogger.Interface
+}
+
+// switchableLogger is a GORM logger which delegates to a logger that can be replaced concurrently.
+type switchableLogger struct {
+	current atomic.Pointer[loggerHolder]
+}
+
+func (l *switchableLogger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	return l.current.Load().LogMode(level)
+}
+
+func (l *switchableLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	l.current.Load().Info(ctx, msg, data...)
+}
+
+func (l *switchableLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	l.current.Load().Warn(ctx, msg, data...)
+}
+
+func (l *switchableLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	l.current.Load().Error(ctx, msg, data...)
+}
+
+func (l *switchableLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.current.Load().Trace(ctx, begin, fc, err)
+}
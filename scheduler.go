@@ -0,0 +1,206 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"time"
+)
+
+// claimDueScript claims at most ARGV[1] tasks of the sorted set KEYS[1] whose time has come, it removes them with their
+// payload kept in the hash KEYS[2] and returns the ids and the payloads in turn. The time is taken from the redis
+// server so the instances share the same clock.
+var claimDueScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now, 'LIMIT', 0, ARGV[1])
+local tasks = {}
+for _, id in ipairs(due) do
+	tasks[#tasks + 1] = id
+	tasks[#tasks + 1] = redis.call('HGET', KEYS[2], id) or ''
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', KEYS[2], id)
+end
+
+return tasks
+`)
+
+// DelayedSchedulerConfig is a struct that holds the settings of a delayed scheduler.
+type DelayedSchedulerConfig struct {
+	// PollInterval is the interval between the polls of ConsumeDue when no task is due, 0 means 1 second.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of tasks claimed per poll, 0 means 100.
+	BatchSize int64
+	// RetryDelay is the delay after which a task whose handler failed is run again, 0 means 1 minute.
+	RetryDelay time.Duration
+}
+
+// DelayedTaskHandler handles the encoded payload of a due task.
+type DelayedTaskHandler func(ctx context.Context, id string, payload []byte) error
+
+type DelayedScheduler interface {
+	ScheduleTask(payload interface{}, at time.Time) (string, error)
+	Cancel(id string) error
+	Len() (int64, error)
+	ConsumeDue(ctx context.Context, handler DelayedTaskHandler) error
+}
+
+type delayedScheduler struct {
+	repo RedisRepository
+	key  string
+	conf DelayedSchedulerConfig
+}
+
+// NewDelayedScheduler function that create a new instance of DelayedScheduler which runs the tasks at their time, e.g.
+// the delayed retries and the reminders, backed by a sorted set of redis whose scores are the times of the tasks. The
+// due tasks are claimed atomically, so a task is handed to a single consumer among the instances.
+func NewDelayedScheduler(repo RedisRepository, name string, conf DelayedSchedulerConfig) DelayedScheduler {
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = time.Second
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 100
+	}
+	if conf.RetryDelay <= 0 {
+		conf.RetryDelay = time.Minute
+	}
+
+	return &delayedScheduler{
+		repo: repo,
+		key:  DelayedSchedulerKeyPrefix + "{" + name + "}",
+		conf: conf,
+	}
+}
+
+func (s *delayedScheduler) keys() []string {
+	key := redisKey(s.repo, s.key)
+	return []string{key, key + ":payloads"}
+}
+
+// ScheduleTask schedules a task to be run at the given time by using the command `ZADD`, the payload is encoded.
+//
+// Parameters:
+// - payload: the payload of the task.
+// - at: the time of the task, a time in the past makes the task due right away.
+//
+// Returns:
+// - string: the id of the task, e.g. to cancel it.
+// - error: an error if something goes wrong, otherwise nil.
+func (s *delayedScheduler) ScheduleTask(payload interface{}, at time.Time) (id string, err error) {
+	defer wrapError(&err, "ScheduleTask", "", s.key, time.Now())
+
+	v, err := encode(codecOf(s.repo), payload)
+	if err != nil {
+		return "", err
+	}
+
+	id = uuid.NewString()
+	if err := s.schedule(id, v, at); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *delayedScheduler) schedule(id string, payload []byte, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys := s.keys()
+	_, err := s.repo.GetUniversalClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keys[1], id, payload)
+		pipe.ZAdd(ctx, keys[0], &redis.Z{Score: float64(at.UnixMilli()), Member: id})
+		return nil
+	})
+
+	return err
+}
+
+// Cancel removes a task which is not claimed yet, cancelling an unknown task does nothing.
+//
+// Parameters:
+// - id: the id of the task.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (s *delayedScheduler) Cancel(id string) (err error) {
+	defer wrapError(&err, "Cancel", "", s.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys := s.keys()
+	_, err = s.repo.GetUniversalClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, keys[0], id)
+		pipe.HDel(ctx, keys[1], id)
+		return nil
+	})
+
+	return err
+}
+
+// Len returns the number of scheduled tasks, due or not, by using the command `ZCARD`.
+//
+// Returns:
+// - int64: the number of scheduled tasks.
+// - error: an error if something goes wrong, otherwise nil.
+func (s *delayedScheduler) Len() (n int64, err error) {
+	defer wrapError(&err, "Len", "", s.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.repo.GetUniversalClient().ZCard(ctx, s.keys()[0]).Result()
+}
+
+// ConsumeDue runs the polling loop of the scheduler until ctx is done, the due tasks are claimed by a Lua script and
+// handed to the handler one by one. A task whose handler fails is scheduled again after the retry delay, and the
+// errors of redis are retried after the poll interval. A task claimed by an instance which crashes before handling it
+// is lost.
+//
+// Parameters:
+// - ctx: the context which stops the loop.
+// - handler: the handler of the tasks.
+//
+// Returns:
+// - error: the error of ctx when it is done.
+func (s *delayedScheduler) ConsumeDue(ctx context.Context, handler DelayedTaskHandler) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		tasks, err := s.claimDue()
+		if err != nil || len(tasks) == 0 {
+			if !sleepContext(ctx, s.conf.PollInterval) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		for i := 0; i+1 < len(tasks); i += 2 {
+			id, payload := tasks[i], []byte(tasks[i+1])
+			if ctx.Err() != nil {
+				// the loop is stopped, the rest of the batch is given back
+				_ = s.schedule(id, payload, time.Now())
+				continue
+			}
+
+			if err := handler(ctx, id, payload); err != nil {
+				// a failed reschedule loses the task, as a crash would
+				_ = s.schedule(id, payload, time.Now().Add(s.conf.RetryDelay))
+			}
+		}
+	}
+}
+
+// claimDue claims the due tasks, it returns their ids and their payloads in turn.
+func (s *delayedScheduler) claimDue() (tasks []string, err error) {
+	defer wrapError(&err, "ConsumeDue", "", s.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return claimDueScript.Run(ctx, s.repo.GetUniversalClient(), s.keys(), s.conf.BatchSize).StringSlice()
+}
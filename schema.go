@@ -0,0 +1,151 @@
+package repositorysdk
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+)
+
+// SchemaDriftKind is the kind of difference between an entity definition and the live database schema.
+type SchemaDriftKind string
+
+const (
+	SchemaDriftMissingTable  SchemaDriftKind = "missing_table"
+	SchemaDriftMissingColumn SchemaDriftKind = "missing_column"
+	SchemaDriftTypeMismatch  SchemaDriftKind = "type_mismatch"
+	SchemaDriftMissingIndex  SchemaDriftKind = "missing_index"
+)
+
+// SchemaDrift is a struct that holds a single difference between an entity definition and the live database schema.
+type SchemaDrift struct {
+	Kind     SchemaDriftKind
+	Table    string
+	Column   string
+	Index    string
+	Expected string
+	Actual   string
+}
+
+// String returns the human-readable description of the drift.
+func (d SchemaDrift) String() string {
+	switch d.Kind {
+	case SchemaDriftMissingTable:
+		return fmt.Sprintf("table %s does not exist", d.Table)
+	case SchemaDriftMissingColumn:
+		return fmt.Sprintf("column %s.%s does not exist, expected type %s", d.Table, d.Column, d.Expected)
+	case SchemaDriftTypeMismatch:
+		return fmt.Sprintf("column %s.%s has type %s, expected type %s", d.Table, d.Column, d.Actual, d.Expected)
+	case SchemaDriftMissingIndex:
+		return fmt.Sprintf("index %s on table %s does not exist", d.Index, d.Table)
+	}
+
+	return string(d.Kind)
+}
+
+// CheckSchema compares the GORM definitions of the entities against the live database schema and reports the missing
+// tables, missing columns, column type mismatches, and missing indexes.
+//
+// Parameters:
+// - db: the GORM database connection.
+// - entities: the entities to be checked.
+//
+// Returns:
+// - []SchemaDrift: the differences found, empty if the schema matches the entities.
+// - error: an error if the schema cannot be inspected, otherwise nil.
+func CheckSchema(db *gorm.DB, entities ...Entity) ([]SchemaDrift, error) {
+	var drifts []SchemaDrift
+
+	for _, entity := range entities {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(entity); err != nil {
+			return nil, err
+		}
+		table := tableName(stmt)
+		if table != stmt.Schema.Table {
+			// parse again under the migrated name, so the default index names match the ones created by AutoMigrate
+			stmt = &gorm.Statement{DB: db}
+			if err := stmt.ParseWithSpecialTableName(entity, table); err != nil {
+				return nil, err
+			}
+		}
+		migrator := db.Table(table).Migrator()
+
+		if !migrator.HasTable(table) {
+			drifts = append(drifts, SchemaDrift{Kind: SchemaDriftMissingTable, Table: table})
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(entity)
+		if err != nil {
+			return nil, err
+		}
+
+		columns := make(map[string]gorm.ColumnType, len(columnTypes))
+		for _, columnType := range columnTypes {
+			columns[columnType.Name()] = columnType
+		}
+
+		for _, name := range stmt.Schema.DBNames {
+			field := stmt.Schema.FieldsByDBName[name]
+			if field.IgnoreMigration {
+				continue
+			}
+
+			expected := db.Dialector.DataTypeOf(field)
+
+			column, ok := columns[name]
+			if !ok {
+				drifts = append(drifts, SchemaDrift{Kind: SchemaDriftMissingColumn, Table: table, Column: name, Expected: expected})
+				continue
+			}
+
+			if actual := column.DatabaseTypeName(); normalizeColumnType(expected) != normalizeColumnType(actual) {
+				drifts = append(drifts, SchemaDrift{Kind: SchemaDriftTypeMismatch, Table: table, Column: name, Expected: expected, Actual: actual})
+			}
+		}
+
+		for _, index := range stmt.Schema.ParseIndexes() {
+			if !migrator.HasIndex(entity, index.Name) {
+				drifts = append(drifts, SchemaDrift{Kind: SchemaDriftMissingIndex, Table: table, Index: index.Name})
+			}
+		}
+	}
+
+	return drifts, nil
+}
+
+var columnTypeModifier = regexp.MustCompile(`\(.*\)`)
+
+// columnTypeAliases maps the SQL type names to the internal names reported by PostgreSQL.
+var columnTypeAliases = map[string]string{
+	"bigint":                      "int8",
+	"bigserial":                   "int8",
+	"integer":                     "int4",
+	"int":                         "int4",
+	"serial":                      "int4",
+	"smallint":                    "int2",
+	"smallserial":                 "int2",
+	"boolean":                     "bool",
+	"double precision":            "float8",
+	"real":                        "float4",
+	"decimal":                     "numeric",
+	"character varying":           "varchar",
+	"character":                   "bpchar",
+	"char":                        "bpchar",
+	"timestamp with time zone":    "timestamptz",
+	"timestamp without time zone": "timestamp",
+	"time with time zone":         "timetz",
+	"time without time zone":      "time",
+}
+
+// normalizeColumnType removes the modifiers of a column type and resolves its aliases, so the types declared by GORM
+// and the types reported by the database can be compared.
+func normalizeColumnType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(columnTypeModifier.ReplaceAllString(t, "")))
+	if alias, ok := columnTypeAliases[t]; ok {
+		return alias
+	}
+
+	return t
+}
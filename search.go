@@ -0,0 +1,350 @@
+package repositorysdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"gorm.io/gorm"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SearchSyncMode is the way the search index is kept in sync with the entities.
+type SearchSyncMode int
+
+const (
+	// SearchSyncInline indexes the entity right after it is written.
+	SearchSyncInline SearchSyncMode = iota
+	// SearchSyncOutbox writes the indexing into the outbox in the same transaction as the entity, it is applied by
+	// the OutboxRelay with the handlers registered by RegisterSearchOutboxHandlers.
+	SearchSyncOutbox
+)
+
+const (
+	SearchIndexTopic  = "search.index"
+	SearchRemoveTopic = "search.remove"
+)
+
+// SearchIndexer indexes the documents of the entities in a search engine.
+type SearchIndexer interface {
+	Index(ctx context.Context, index string, id string, document interface{}) error
+	Remove(ctx context.Context, index string, id string) error
+}
+
+// SearchDocumentReader reads the documents of a search engine, it is implemented by the indexer of
+// NewOpenSearchIndexer.
+type SearchDocumentReader interface {
+	GetDocuments(ctx context.Context, index string, ids []string) (map[string]map[string]interface{}, error)
+}
+
+type openSearchIndexer struct {
+	client *opensearch.Client
+}
+
+// NewOpenSearchIndexer function that create a new instance of SearchIndexer backed by OpenSearch
+func NewOpenSearchIndexer(client *opensearch.Client) SearchIndexer {
+	return &openSearchIndexer{client: client}
+}
+
+// Index creates or replaces the document with the given id in the index.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - index: the name of the index.
+// - id: the id of the document.
+// - document: the document, encoded in json.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (i *openSearchIndexer) Index(ctx context.Context, index string, id string, document interface{}) (err error) {
+	defer wrapError(&err, "Index", index, id, time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	res, err := opensearchapi.IndexRequest{
+		Index:      index,
+		DocumentID: id,
+		Body:       bytes.NewReader(body),
+	}.Do(ctx, i.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("index document %s/%s: %s", index, id, res.String())
+	}
+
+	return nil
+}
+
+// Remove deletes the document with the given id from the index, a missing document is not an error.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - index: the name of the index.
+// - id: the id of the document.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (i *openSearchIndexer) Remove(ctx context.Context, index string, id string) (err error) {
+	defer wrapError(&err, "Remove", index, id, time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res, err := opensearchapi.DeleteRequest{
+		Index:      index,
+		DocumentID: id,
+	}.Do(ctx, i.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("remove document %s/%s: %s", index, id, res.String())
+	}
+
+	return nil
+}
+
+// GetDocuments retrieves the documents with the given ids from the index in a single request.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - index: the name of the index.
+// - ids: the ids of the documents.
+//
+// Returns:
+// - map[string]map[string]interface{}: the documents by their id, the missing documents are not added.
+// - error: an error if something goes wrong, otherwise nil.
+func (i *openSearchIndexer) GetDocuments(ctx context.Context, index string, ids []string) (documents map[string]map[string]interface{}, err error) {
+	defer wrapError(&err, "GetDocuments", index, "", time.Now())
+
+	documents = map[string]map[string]interface{}{}
+	if len(ids) == 0 {
+		return documents, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := opensearchapi.MgetRequest{
+		Index: index,
+		Body:  bytes.NewReader(body),
+	}.Do(ctx, i.client)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return documents, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("get documents %s: %s", index, res.String())
+	}
+
+	var result struct {
+		Docs []struct {
+			ID     string                 `json:"_id"`
+			Found  bool                   `json:"found"`
+			Source map[string]interface{} `json:"_source"`
+		} `json:"docs"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	for _, doc := range result.Docs {
+		if doc.Found {
+			documents[doc.ID] = doc.Source
+		}
+	}
+
+	return documents, nil
+}
+
+// WithSearchIndex enables indexing the entities which have fields tagged with `searchindex:"true"`, the entity is
+// indexed on Create and Update and removed on Delete. The index is named after the table of the entity. In
+// SearchSyncInline mode the write is already committed when the indexing fails, so the error is reported to the
+// handler of WithSearchErrorHandler rather than returned.
+func WithSearchIndex(indexer SearchIndexer, mode SearchSyncMode) GormOption {
+	return func(o *gormOptions) {
+		o.indexer = indexer
+		o.searchSyncMode = mode
+	}
+}
+
+// WithSearchErrorHandler sets the handler of the entities which cannot be indexed inline after their write is
+// committed, e.g. to index them again later. The errors are passed to the error handler of OpenTelemetry,
+// otel.Handle, when no handler is set.
+func WithSearchErrorHandler(fn func(op Operation, entity interface{}, err error)) GormOption {
+	return func(o *gormOptions) {
+		o.onSearchError = fn
+	}
+}
+
+// RegisterSearchOutboxHandlers registers the handlers which apply the indexing written into the outbox by the
+// repositories in SearchSyncOutbox mode.
+func RegisterSearchOutboxHandlers(relay OutboxRelay, indexer SearchIndexer) {
+	relay.Handle(SearchIndexTopic, func(ctx context.Context, message *OutboxMessage) error {
+		var p searchOutboxPayload
+		if err := json.Unmarshal(message.Payload, &p); err != nil {
+			return err
+		}
+
+		return indexer.Index(ctx, p.Index, p.ID, p.Document)
+	})
+
+	relay.Handle(SearchRemoveTopic, func(ctx context.Context, message *OutboxMessage) error {
+		var p searchOutboxPayload
+		if err := json.Unmarshal(message.Payload, &p); err != nil {
+			return err
+		}
+
+		return indexer.Remove(ctx, p.Index, p.ID)
+	})
+}
+
+// BackfillSearchIndex indexes the existing rows of the entity in batches.
+//
+// Parameters:
+// - db: the GORM database connection.
+// - indexer: the search indexer.
+// - batchSize: the number of rows read per query, 0 means MaximumQueryEntities.
+//
+// Returns:
+// - int: the number of documents indexed.
+// - error: an error if something goes wrong, otherwise nil.
+func BackfillSearchIndex[T Entity](db *gorm.DB, indexer SearchIndexer, batchSize int) (int, error) {
+	return backfillSearchIndex(db, indexer, &[]T{}, batchSize)
+}
+
+// backfillSearchIndex indexes the existing rows in batches, entities is a pointer to a slice of the entity type which
+// receives each batch.
+func backfillSearchIndex(db *gorm.DB, indexer SearchIndexer, entities interface{}, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = MaximumQueryEntities
+	}
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var indexed int
+
+	if err := db.FindInBatches(entities, batchSize, func(tx *gorm.DB, batch int) error {
+		rows := reflect.ValueOf(entities).Elem()
+		for i := 0; i < rows.Len(); i++ {
+			entity := rows.Index(i).Interface().(Entity)
+
+			document, ok := searchDocument(entity)
+			if !ok {
+				continue
+			}
+
+			if err := indexer.Index(ctx, entity.TableName(), entityID(entity), document); err != nil {
+				return err
+			}
+			indexed++
+		}
+		return nil
+	}).Error; err != nil {
+		return indexed, err
+	}
+
+	return indexed, nil
+}
+
+type searchOutboxPayload struct {
+	Index    string                 `json:"index"`
+	ID       string                 `json:"id"`
+	Document map[string]interface{} `json:"document,omitempty"`
+}
+
+// syncSearchIndex indexes or removes the document of the entity, the tx is used to write the outbox in
+// SearchSyncOutbox mode.
+func (r *gormRepository[T]) syncSearchIndex(tx *gorm.DB, op Operation, entity T) error {
+	document, ok := searchDocument(entity)
+	if !ok {
+		return nil
+	}
+
+	index, id := entity.TableName(), entityID(entity)
+
+	if r.searchSyncMode == SearchSyncOutbox {
+		if op == OperationDelete {
+			return EnqueueOutbox(tx, SearchRemoveTopic, id, searchOutboxPayload{Index: index, ID: id})
+		}
+
+		return EnqueueOutbox(tx, SearchIndexTopic, id, searchOutboxPayload{Index: index, ID: id, Document: document})
+	}
+
+	if op == OperationDelete {
+		return r.indexer.Remove(r.context(), index, id)
+	}
+
+	return r.indexer.Index(r.context(), index, id, document)
+}
+
+// searchDocument builds the search document from the fields of the entity tagged with `searchindex:"true"`, the
+// fields are named after their json tag. It returns false if the entity has no tagged field.
+func searchDocument(entity interface{}) (map[string]interface{}, bool) {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	document := map[string]interface{}{}
+	collectSearchFields(v, document)
+	if len(document) == 0 {
+		return nil, false
+	}
+
+	document["id"] = entityID(entity)
+
+	return document, true
+}
+
+func collectSearchFields(v reflect.Value, document map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Tag.Get("searchindex") != "true" {
+			if field.Anonymous && reflect.Indirect(v.Field(i)).Kind() == reflect.Struct {
+				collectSearchFields(reflect.Indirect(v.Field(i)), document)
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+
+		document[name] = v.Field(i).Interface()
+	}
+}
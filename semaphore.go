@@ -0,0 +1,265 @@
+package repositorysdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// acquireSemaphoreScript gives a permit of the semaphore KEYS[1], a sorted set of the holders scored by their
+// expiration time, to the holder ARGV[1] in the order of the tickets. A holder which does not get a permit takes a
+// ticket from the counter KEYS[4] and waits in the sorted set KEYS[2] of the queue, scored by its ticket, and its
+// waiting deadline ARGV[5] is kept in the sorted set KEYS[3]. A permit is given only to the ARGV[2] - holders first
+// tickets of the queue, so a newcomer never overtakes an older waiter. The holders and the waiters whose deadline has
+// elapsed are removed first, so the permits and the places of the dead ones are given back. A try, ARGV[4] = 0, leaves
+// the queue right away when it gets no permit. The time is taken from the redis server so the instances share the same
+// clock.
+var acquireSemaphoreScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+for _, waiter in ipairs(redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', now)) do
+	redis.call('ZREM', KEYS[2], waiter)
+	redis.call('ZREM', KEYS[3], waiter)
+end
+
+if not redis.call('ZSCORE', KEYS[2], ARGV[1]) then
+	redis.call('ZADD', KEYS[2], redis.call('INCR', KEYS[4]), ARGV[1])
+end
+
+local acquired = redis.call('ZRANK', KEYS[2], ARGV[1]) < tonumber(ARGV[2]) - redis.call('ZCARD', KEYS[1])
+if acquired then
+	redis.call('ZREM', KEYS[2], ARGV[1])
+	redis.call('ZREM', KEYS[3], ARGV[1])
+	redis.call('ZADD', KEYS[1], now + tonumber(ARGV[3]), ARGV[1])
+elseif ARGV[4] == '0' then
+	redis.call('ZREM', KEYS[2], ARGV[1])
+	redis.call('ZREM', KEYS[3], ARGV[1])
+else
+	redis.call('ZADD', KEYS[3], now + tonumber(ARGV[5]), ARGV[1])
+end
+
+local ttl = math.max(tonumber(ARGV[3]), tonumber(ARGV[5]))
+for _, key in ipairs(KEYS) do
+	if redis.call('PTTL', key) < ttl then
+		redis.call('PEXPIRE', key, ttl)
+	end
+end
+
+if acquired then
+	return 1
+end
+return 0
+`)
+
+// extendSemaphoreScript sets the expiration time of the holder ARGV[1] of the semaphore KEYS[1] to ARGV[2] milliseconds
+// from now, only if its ttl has not elapsed.
+var extendSemaphoreScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local expiry = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not expiry or tonumber(expiry) <= now then
+	return 0
+end
+
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+
+return 1
+`)
+
+// semaphoreWaitTTL is the time a waiter keeps its place in the queue of a semaphore without polling, so the places
+// of the waiters which died are given back.
+const semaphoreWaitTTL = 5 * time.Second
+
+// semaphorePollInterval is the interval between the tries of a waiter of a semaphore.
+const semaphorePollInterval = 50 * time.Millisecond
+
+type redisSemaphore struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+}
+
+// semaphoreKeys returns the keys of the holders, the queue, the waiting deadlines, and the ticket counter of the
+// semaphore.
+func semaphoreKeys(key string) []string {
+	return []string{key, key + ":queue", key + ":waiters", key + ":ticket"}
+}
+
+// AcquireSemaphore acquires one of the limit permits of the semaphore of the key by using a Lua script, e.g. to cap
+// the concurrent calls to a fragile API across the instances. The holders are kept in a sorted set scored by their
+// expiration time, so the permit of a holder which died is given back once its ttl elapses. The permits are given in
+// the order of the tickets, so a free permit goes to the oldest waiter of AcquireSemaphoreWait rather than to this
+// call. The permit is released with ReleaseSemaphore or with the Release of the returned Lock.
+//
+// The queue, the waiters, and the ticket counter are kept in the keys suffixed by `:queue`, `:waiters`, and `:ticket`,
+// so on a cluster the key must contain a hash tag, e.g. semaphore:{partner-api}.
+//
+// Parameters:
+// - key: the semaphore key.
+// - limit: the maximum number of holders.
+// - ttl: the expiration time of the permit.
+//
+// Returns:
+// - Lock: the acquired permit.
+// - error: ErrLockNotAcquired if no permit is free for this call, otherwise an error if something goes wrong.
+func (r *redisRepository) AcquireSemaphore(key string, limit int, ttl time.Duration) (lock Lock, err error) {
+	defer r.observe(&err, "AcquireSemaphore", "", key, time.Now())
+
+	return r.acquireSemaphore(key, limit, ttl, 0)
+}
+
+// AcquireSemaphoreWait acquires one of the limit permits of the semaphore of the key as AcquireSemaphore does, but
+// waits in the queue of the semaphore for at most wait when no permit is free. The waiters get the permits in the
+// order of their arrival, first come first served.
+//
+// Parameters:
+// - key: the semaphore key.
+// - limit: the maximum number of holders.
+// - ttl: the expiration time of the permit.
+// - wait: the maximum waiting time.
+//
+// Returns:
+// - Lock: the acquired permit.
+// - error: ErrLockNotAcquired if no permit is given within wait, otherwise an error if something goes wrong.
+func (r *redisRepository) AcquireSemaphoreWait(key string, limit int, ttl time.Duration, wait time.Duration) (lock Lock, err error) {
+	defer r.observe(&err, "AcquireSemaphoreWait", "", key, time.Now())
+
+	return r.acquireSemaphore(key, limit, ttl, wait)
+}
+
+// acquireSemaphore tries to acquire a permit until wait elapses, the place of the waiter in the queue is given back
+// when it does not get a permit.
+func (r *redisRepository) acquireSemaphore(key string, limit int, ttl time.Duration, wait time.Duration) (Lock, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+
+	keys := semaphoreKeys(key)
+	deadline := time.Now().Add(wait)
+	waiting := 0
+	if wait > 0 {
+		waiting = 1
+	}
+
+	for {
+		ctx, cancel := context.WithTimeout(r.context(), 10*time.Second)
+		n, err := acquireSemaphoreScript.Run(ctx, r.client, keys, token, limit, ttl.Milliseconds(), waiting,
+			semaphoreWaitTTL.Milliseconds()).Int64()
+		cancel()
+		if err != nil {
+			r.leaveSemaphoreQueue(keys, token)
+			return nil, err
+		}
+		if n == 1 {
+			return &redisSemaphore{client: r.client, key: key, token: token}, nil
+		}
+		if waiting == 0 {
+			return nil, ErrLockNotAcquired
+		}
+
+		if time.Now().Add(semaphorePollInterval).After(deadline) {
+			r.leaveSemaphoreQueue(keys, token)
+			return nil, ErrLockNotAcquired
+		}
+
+		select {
+		case <-r.context().Done():
+			r.leaveSemaphoreQueue(keys, token)
+			return nil, r.context().Err()
+		case <-time.After(semaphorePollInterval):
+		}
+	}
+}
+
+// leaveSemaphoreQueue gives the place of the waiter back, so the next waiters are not held up until its deadline.
+func (r *redisRepository) leaveSemaphoreQueue(keys []string, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, keys[1], token)
+		pipe.ZRem(ctx, keys[2], token)
+		return nil
+	})
+}
+
+// ReleaseSemaphore gives the permit back to its semaphore by using the command `ZREM`, as the Release of the permit.
+//
+// Parameters:
+// - permit: the permit returned by AcquireSemaphore or AcquireSemaphoreWait.
+//
+// Returns:
+// - error: ErrLockNotHeld if the permit has expired and was given to someone else, otherwise an error if something goes
+// wrong.
+func (r *redisRepository) ReleaseSemaphore(permit Lock) (err error) {
+	defer r.observe(&err, "ReleaseSemaphore", "", permit.Key(), time.Now())
+
+	semaphore, ok := permit.(*redisSemaphore)
+	if !ok {
+		return fmt.Errorf("%T is not a permit of a semaphore", permit)
+	}
+
+	return semaphore.Release()
+}
+
+// Key returns the key of the semaphore.
+func (s *redisSemaphore) Key() string {
+	return s.key
+}
+
+// Release gives the permit back to the semaphore by using the command `ZREM`.
+//
+// Returns:
+// - error: ErrLockNotHeld if the permit has expired and was given to someone else, otherwise an error if something goes
+// wrong.
+func (s *redisSemaphore) Release() (err error) {
+	defer wrapError(&err, "ReleaseSemaphore", "", s.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := s.client.ZRem(ctx, s.key, s.token).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Extend resets the expiration time of the permit to ttl if it has not expired, e.g. for a long call.
+//
+// Parameters:
+// - ttl: the new expiration time of the permit.
+//
+// Returns:
+// - error: ErrLockNotHeld if the permit has expired, otherwise an error if something goes wrong.
+func (s *redisSemaphore) Extend(ttl time.Duration) (err error) {
+	defer wrapError(&err, "ExtendSemaphore", "", s.key, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := extendSemaphoreScript.Run(ctx, s.client, []string{s.key}, s.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package repositorysdk
+
+import (
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreGivesTheFreedPermitToTheOldestWaiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo := NewRedisRepository(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	holder, err := repo.AcquireSemaphore("semaphore:{api}", 1, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	waited := make(chan error, 1)
+	go func() {
+		permit, err := repo.AcquireSemaphoreWait("semaphore:{api}", 1, time.Minute, 5*time.Second)
+		if err == nil {
+			err = permit.Release()
+		}
+		waited <- err
+	}()
+
+	for deadline := time.Now().Add(2 * time.Second); !mr.Exists("semaphore:{api}:queue"); {
+		if time.Now().After(deadline) {
+			t.Fatal("the waiter did not join the queue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := repo.ReleaseSemaphore(holder); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+
+	// the permit is free, but the waiter is ahead of this call
+	if _, err := repo.AcquireSemaphore("semaphore:{api}", 1, time.Minute); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("a newcomer overtook the waiter: got %v, want ErrLockNotAcquired", err)
+	}
+
+	if err := <-waited; err != nil {
+		t.Fatalf("the waiter did not get the freed permit: %v", err)
+	}
+}
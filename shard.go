@@ -0,0 +1,151 @@
+package repositorysdk
+
+import (
+	"gorm.io/gorm"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// ShardKeyFunc maps a shard key to the index of a shard, the result must be in the range [0, shards).
+type ShardKeyFunc func(key string, shards int) int
+
+// HashShardKey is the default ShardKeyFunc which distributes the keys with the FNV-1a hash, every key maps to 0 when
+// there is no shard.
+func HashShardKey(key string, shards int) int {
+	if shards <= 0 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(shards))
+}
+
+type ShardedGormRepository[T Entity] interface {
+	Shard(key string) GormRepository[T]
+	Shards() []GormRepository[T]
+	FindOne(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	Create(key string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	Update(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	Delete(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error
+	FanOut(fn func(shard int, repo GormRepository[T]) error) error
+	FanOutFind(entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) error
+}
+
+type shardedGormRepository[T Entity] struct {
+	shards   []GormRepository[T]
+	shardKey ShardKeyFunc
+}
+
+// NewShardedGormRepository function that create a new instance of shardedGormRepository[T] which routes the queries
+// to one gorm repository per database connection.
+// The shard of an entity is chosen by the shardKey function, HashShardKey is used if it is nil. It panics when dbs is
+// empty.
+func NewShardedGormRepository[T Entity](dbs []*gorm.DB, shardKey ShardKeyFunc, opts ...GormOption) ShardedGormRepository[T] {
+	if len(dbs) == 0 {
+		panic("repositorysdk: NewShardedGormRepository needs at least one database")
+	}
+
+	if shardKey == nil {
+		shardKey = HashShardKey
+	}
+
+	shards := make([]GormRepository[T], len(dbs))
+	for i, db := range dbs {
+		shards[i] = NewGormRepository[T](db, opts...)
+	}
+
+	return &shardedGormRepository[T]{
+		shards:   shards,
+		shardKey: shardKey,
+	}
+}
+
+// Shard returns the gorm repository of the shard which owns the given key.
+func (r *shardedGormRepository[T]) Shard(key string) GormRepository[T] {
+	return r.shards[r.shardKey(key, len(r.shards))]
+}
+
+// Shards returns the gorm repositories of all shards.
+func (r *shardedGormRepository[T]) Shards() []GormRepository[T] {
+	return r.shards
+}
+
+// FindOne finds a single entity with the given id on the shard which owns the key.
+func (r *shardedGormRepository[T]) FindOne(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	return r.Shard(key).FindOne(id, entity, scope...)
+}
+
+// Create a new entity on the shard which owns the key.
+func (r *shardedGormRepository[T]) Create(key string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	return r.Shard(key).Create(entity, scope...)
+}
+
+// Update an existing entity with the given id on the shard which owns the key.
+func (r *shardedGormRepository[T]) Update(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	return r.Shard(key).Update(id, entity, scope...)
+}
+
+// Delete an existing entity with the given id from the shard which owns the key.
+func (r *shardedGormRepository[T]) Delete(key string, id string, entity T, scope ...func(db *gorm.DB) *gorm.DB) error {
+	return r.Shard(key).Delete(id, entity, scope...)
+}
+
+// FanOut runs the function against every shard concurrently.
+//
+// Parameters:
+// - fn: the function to be executed with the index and the repository of each shard.
+//
+// Returns:
+// - error: the first error returned by the function, otherwise nil.
+func (r *shardedGormRepository[T]) FanOut(fn func(shard int, repo GormRepository[T]) error) error {
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	for i, repo := range r.shards {
+		wg.Add(1)
+		go func(i int, repo GormRepository[T]) {
+			defer wg.Done()
+
+			if err := fn(i, repo); err != nil {
+				once.Do(func() { firstErr = err })
+			}
+		}(i, repo)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+// FanOutFind finds the entities matching the scopes on every shard and merges the results in shard order. Each shard
+// is queried as FindAll does, after the authorization of the operation by the options of the repository.
+//
+// Parameters:
+// - entities: a pointer to the slice that will hold the entities of all shards.
+// - scope: the scopes applied to the query of each shard.
+//
+// Returns:
+// - error: the first error returned by a shard, otherwise nil.
+func (r *shardedGormRepository[T]) FanOutFind(entities *[]T, scope ...func(db *gorm.DB) *gorm.DB) (err error) {
+	defer wrapError(&err, "FanOutFind", entityTypeName[T](), "", time.Now())
+
+	results := make([][]T, len(r.shards))
+
+	if err := r.FanOut(func(shard int, repo GormRepository[T]) error {
+		return repo.(*gormRepository[T]).find(&results[shard], scope...)
+	}); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		*entities = append(*entities, result...)
+	}
+
+	return nil
+}
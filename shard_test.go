@@ -0,0 +1,59 @@
+package repositorysdk
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+func TestFanOutFindAuthorizesTheQuery(t *testing.T) {
+	rows := func(slug string) func(query string) ([]string, [][]driver.Value) {
+		return func(query string) ([]string, [][]driver.Value) {
+			if strings.HasPrefix(query, "SELECT") {
+				return []string{"id", "tenant_id", "slug"}, [][]driver.Value{
+					{"9b2f1d0e-6c1a-4a57-8f1e-2d0f5b6c7a81", "tenant-a", slug},
+				}
+			}
+			return nil, nil
+		}
+	}
+	shards := []*stubDB{newStubDB(t, rows("first")), newStubDB(t, rows("second"))}
+
+	denyFindAll := AuthorizerFunc(func(_ context.Context, op Operation, _ string, _ interface{}) error {
+		if op == OperationFindAll {
+			return ErrForbidden
+		}
+		return nil
+	})
+
+	for _, tc := range []struct {
+		name       string
+		authorizer Authorizer
+		wantErr    error
+		wantSlugs  []string
+	}{
+		{name: "allowed", authorizer: tenantAuthorizer, wantSlugs: []string{"first", "second"}},
+		{name: "denied", authorizer: denyFindAll, wantErr: ErrForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := NewShardedGormRepository[*upsertItem]([]*gorm.DB{shards[0].db, shards[1].db}, nil, WithAuthorizer(tc.authorizer))
+
+			var items []*upsertItem
+			err := repo.FanOutFind(&items)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("fan-out find: got %v, want %v", err, tc.wantErr)
+			}
+
+			slugs := make([]string, len(items))
+			for i, item := range items {
+				slugs[i] = item.Slug
+			}
+			if strings.Join(slugs, ",") != strings.Join(tc.wantSlugs, ",") {
+				t.Errorf("found %v, want %v", slugs, tc.wantSlugs)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package repositorysdk
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// errShardMoved is returned when redis ends a sharded subscription, e.g. when the slot of the channel is migrated to
+// another shard, the channel is then subscribed again on its new shard.
+var errShardMoved = errors.New("sharded subscription ended by the server")
+
+// ShardedSubscribe subscribes to a channel by using the command `SSUBSCRIBE` of redis 7+ and calls the handler with the
+// encoded payload of every message published by ShardedPublish until ctx is done. On a cluster the channel is
+// subscribed on the master of its shard only. go-redis v8 cannot subscribe by `SSUBSCRIBE`, so the subscription holds
+// a dedicated connection of its own, outside the pool of the client. The connection is re-established and the channel
+// subscribed again when the connection is lost or when the slot of the channel moves to another shard, the messages
+// published in the meantime are not received.
+//
+// Parameters:
+// - ctx: the context which stops the subscription.
+// - channel: the channel.
+// - handler: the function which handles the payload, a non-nil error stops the subscription.
+//
+// Returns:
+// - error: the error of the handler, the error of ctx when it is done, or an error if the subscription fails.
+func (r *redisRepository) ShardedSubscribe(ctx context.Context, channel string, handler func(payload []byte) error) (err error) {
+	defer r.observe(&err, "ShardedSubscribe", "", channel, time.Now())
+
+	for {
+		err := r.shardedSubscribe(ctx, channel, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !errors.Is(err, errShardMoved) && !isConnectionError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// shardedSubscribe subscribes to the channel over a connection to the master of its shard, and handles the messages
+// until the connection is lost, the subscription is ended by the server, or the handler fails.
+func (r *redisRepository) shardedSubscribe(ctx context.Context, channel string, handler func(payload []byte) error) error {
+	var node *redis.Client
+	switch client := r.client.(type) {
+	case *redis.Client:
+		node = client
+	case *redis.ClusterClient:
+		master, err := client.MasterForKey(ctx, channel)
+		if err != nil {
+			return err
+		}
+		node = master
+	default:
+		return fmt.Errorf("sharded pub/sub is not supported by %T", r.client)
+	}
+
+	opt := node.Options()
+	conn, err := opt.Dialer(ctx, opt.Network, opt.Addr)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// the connection is closed to unblock the read once ctx is done
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+
+	rd := bufio.NewReader(conn)
+	if opt.Password != "" {
+		args := []string{"AUTH", opt.Password}
+		if opt.Username != "" {
+			args = []string{"AUTH", opt.Username, opt.Password}
+		}
+		if _, err := respCall(conn, rd, args...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := respCall(conn, rd, "SSUBSCRIBE", channel); err != nil {
+		return err
+	}
+
+	for {
+		reply, err := readRESP(rd)
+		if err != nil {
+			return err
+		}
+
+		message, ok := reply.([]interface{})
+		if !ok || len(message) < 3 {
+			continue
+		}
+
+		switch kind, _ := message[0].(string); kind {
+		case "smessage":
+			payload, _ := message[2].(string)
+			if err := handler([]byte(payload)); err != nil {
+				return err
+			}
+		case "sunsubscribe":
+			return errShardMoved
+		}
+	}
+}
+
+// respCall writes the command in RESP and reads its reply, an error reply is returned as an error.
+func respCall(conn net.Conn, rd *bufio.Reader, args ...string) (interface{}, error) {
+	b := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b = append(b, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	return readRESP(rd)
+}
+
+// readRESP reads a RESP2 reply, the strings are returned as string, the integers as int64, the arrays as
+// []interface{}, and the nulls as nil.
+func readRESP(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return nil, errors.New(value)
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		elems := make([]interface{}, n)
+		for i := range elems {
+			if elems[i], err = readRESP(rd); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	}
+
+	return nil, fmt.Errorf("unsupported reply %q", line)
+}
@@ -0,0 +1,110 @@
+package repositorysdk
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// pgUniqueViolation is the PostgreSQL error code of a unique constraint violation.
+const pgUniqueViolation = "23505"
+
+// GRPCCode maps an error returned by the SDK to a gRPC status code. An error which already carries a gRPC status keeps
+// its code, and the errors which are not recognized are mapped to codes.Internal.
+//
+// Parameters:
+// - err: the error to be mapped.
+//
+// Returns:
+// - codes.Code: the gRPC status code, codes.OK if err is nil.
+func GRPCCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, ErrKeyNotFound), errors.Is(err, redis.Nil):
+		return codes.NotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey), errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation:
+		return codes.AlreadyExists
+	case errors.Is(err, ErrVersionMismatch):
+		return codes.FailedPrecondition
+	case errors.Is(err, ErrTransactionConflict), errors.Is(err, ErrLockNotAcquired), errors.Is(err, ErrRequestInProgress):
+		return codes.Aborted
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrReadOnly):
+		return codes.PermissionDenied
+	case errors.Is(err, ErrQuotaExceeded):
+		return codes.ResourceExhausted
+	case errors.Is(err, ErrMissingTenant), errors.Is(err, ErrInvalidKey), errors.Is(err, ErrInvalidCursor):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrCircuitOpen):
+		return codes.Unavailable
+	case errors.Is(err, ErrModuleUnavailable):
+		return codes.Unimplemented
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	}
+
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+
+	return codes.Internal
+}
+
+// GRPCError converts an error returned by the SDK to a gRPC status error with the code of GRPCCode, the message is
+// the message of err.
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return status.Error(GRPCCode(err), err.Error())
+}
+
+// HTTPStatus maps an error returned by the SDK to an HTTP status code, following the mapping of GRPCCode.
+//
+// Parameters:
+// - err: the error to be mapped.
+//
+// Returns:
+// - int: the HTTP status code, http.StatusOK if err is nil.
+func HTTPStatus(err error) int {
+	switch GRPCCode(err) {
+	case codes.OK:
+		return http.StatusOK
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Canceled:
+		return http.StatusRequestTimeout
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,333 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+// StreamMessage is a struct that holds an entry of a redis stream.
+type StreamMessage struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// StreamHandler handles a message of a stream, a nil error acknowledges the message.
+type StreamHandler func(ctx context.Context, message StreamMessage) error
+
+// StreamConsumerConfig is a struct that holds the settings of the consumer loop of RedisStreamRepository.Consume.
+type StreamConsumerConfig struct {
+	// Count is the maximum number of messages read per call, 0 means 10.
+	Count int64
+	// Block is the maximum blocking time of a read, 0 means 5 seconds.
+	Block time.Duration
+	// MinIdle is the idle time after which a pending message of another consumer is claimed, 0 means 1 minute.
+	MinIdle time.Duration
+	// MaxRetries is the number of retries of a failed message before it is left pending or moved to the dead letter stream.
+	MaxRetries int
+	// Backoff is the waiting time before the first retry, it is doubled on every retry up to MaxBackoff, 0 means 100ms.
+	Backoff time.Duration
+	// MaxBackoff is the maximum waiting time between the retries, 0 means 10 seconds.
+	MaxBackoff time.Duration
+	// DeadLetterStream is the stream which receives the messages which failed after the retries, empty means the
+	// messages are left pending to be claimed again.
+	DeadLetterStream string
+}
+
+type RedisStreamRepository interface {
+	AddToStream(stream string, values map[string]interface{}, maxLen int64) (string, error)
+	CreateGroup(stream string, group string) error
+	ReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) ([]StreamMessage, error)
+	Ack(stream string, group string, ids ...string) error
+	Claim(stream string, group string, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error)
+	Consume(ctx context.Context, stream string, group string, consumer string, handler StreamHandler, conf StreamConsumerConfig) error
+}
+
+type redisStreamRepository struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStreamRepository function that create a new instance of RedisStreamRepository
+func NewRedisStreamRepository(client redis.UniversalClient) RedisStreamRepository {
+	return &redisStreamRepository{client: client}
+}
+
+// AddToStream appends a message to a stream by using the command `XADD`.
+//
+// Parameters:
+// - stream: the stream key.
+// - values: the fields of the message.
+// - maxLen: the approximate maximum length of the stream, 0 means the stream is not trimmed.
+//
+// Returns:
+// - string: the id of the message.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisStreamRepository) AddToStream(stream string, values map[string]interface{}, maxLen int64) (id string, err error) {
+	defer wrapError(&err, "AddToStream", "", stream, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: maxLen > 0,
+		Values: values,
+	}).Result()
+}
+
+// CreateGroup creates a consumer group which reads the new messages of a stream by using the command `XGROUP CREATE`,
+// the stream is created if it does not exist. An existing group is not an error.
+//
+// Parameters:
+// - stream: the stream key.
+// - group: the name of the group.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisStreamRepository) CreateGroup(stream string, group string) (err error) {
+	defer wrapError(&err, "CreateGroup", "", stream, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = r.client.XGroupCreateMkStream(ctx, stream, group, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+
+	return err
+}
+
+// ReadGroup reads the new messages of a stream for a consumer of a group by using the command `XREADGROUP`.
+//
+// Parameters:
+// - ctx: the context of the request.
+// - stream: the stream key.
+// - group: the name of the group.
+// - consumer: the name of the consumer.
+// - count: the maximum number of messages.
+// - block: the maximum blocking time, 0 means the call does not block.
+//
+// Returns:
+// - []StreamMessage: the messages, empty if no message was received within the blocking time.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisStreamRepository) ReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) (messages []StreamMessage, err error) {
+	defer wrapError(&err, "ReadGroup", "", stream, time.Now())
+
+	if block <= 0 {
+		block = -1
+	}
+
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range streams {
+		messages = append(messages, streamMessages(s.Messages)...)
+	}
+
+	return messages, nil
+}
+
+// Ack acknowledges the messages of a group by using the command `XACK`, the messages are removed from the pending
+// entries of the group.
+//
+// Parameters:
+// - stream: the stream key.
+// - group: the name of the group.
+// - ids: the ids of the messages.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisStreamRepository) Ack(stream string, group string, ids ...string) (err error) {
+	defer wrapError(&err, "Ack", "", stream, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.client.XAck(ctx, stream, group, ids...).Err()
+}
+
+// Claim transfers to the consumer the pending messages of the group which have been idle for at least minIdle, by
+// using the command `XAUTOCLAIM`. It is used to take over the messages of a consumer which crashed.
+//
+// Parameters:
+// - stream: the stream key.
+// - group: the name of the group.
+// - consumer: the name of the consumer.
+// - minIdle: the minimum idle time of the messages.
+// - count: the maximum number of messages.
+//
+// Returns:
+// - []StreamMessage: the claimed messages.
+// - error: an error if something goes wrong, otherwise nil.
+func (r *redisStreamRepository) Claim(stream string, group string, consumer string, minIdle time.Duration, count int64) (messages []StreamMessage, err error) {
+	defer wrapError(&err, "Claim", "", stream, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	claimed, _, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return streamMessages(claimed), nil
+}
+
+// Consume runs the consumer loop of a group until ctx is done. The group is created if it does not exist, the idle
+// pending messages are claimed before the new messages are read, and every message is acknowledged once the handler
+// succeeds. A failed message is retried with an exponential backoff, and then moved to the dead letter stream or left
+// pending. The errors of redis are retried with the same backoff.
+//
+// Parameters:
+// - ctx: the context which stops the consumer.
+// - stream: the stream key.
+// - group: the name of the group.
+// - consumer: the name of the consumer, unique within the group.
+// - handler: the handler of the messages.
+// - conf: the settings of the consumer loop.
+//
+// Returns:
+// - error: the error of ctx when it is done, or an error if the group cannot be created.
+func (r *redisStreamRepository) Consume(ctx context.Context, stream string, group string, consumer string, handler StreamHandler, conf StreamConsumerConfig) error {
+	conf = conf.withDefaults()
+
+	if err := r.CreateGroup(stream, group); err != nil {
+		return err
+	}
+
+	backoff := conf.Backoff
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		messages, err := r.Claim(stream, group, consumer, conf.MinIdle, conf.Count)
+		if err == nil && len(messages) == 0 {
+			messages, err = r.ReadGroup(ctx, stream, group, consumer, conf.Count, conf.Block)
+		}
+		if err != nil {
+			if !sleepContext(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = nextBackoff(backoff, conf.MaxBackoff)
+			continue
+		}
+		backoff = conf.Backoff
+
+		for _, message := range messages {
+			if err := r.handle(ctx, stream, group, message, handler, conf); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle runs the handler on the message with the retries, and acknowledges the message once it is handled or moved
+// to the dead letter stream. It returns an error only when ctx is done.
+func (r *redisStreamRepository) handle(ctx context.Context, stream string, group string, message StreamMessage, handler StreamHandler, conf StreamConsumerConfig) error {
+	backoff := conf.Backoff
+	for attempt := 0; ; attempt++ {
+		if err := handler(ctx, message); err == nil {
+			// a failed ack leaves the message pending, it is claimed again after MinIdle
+			_ = r.Ack(stream, group, message.ID)
+			return nil
+		}
+
+		if attempt >= conf.MaxRetries {
+			break
+		}
+
+		if !sleepContext(ctx, backoff) {
+			return ctx.Err()
+		}
+		backoff = nextBackoff(backoff, conf.MaxBackoff)
+	}
+
+	if conf.DeadLetterStream == "" {
+		return nil
+	}
+
+	values := map[string]interface{}{"stream": stream, "id": message.ID}
+	for k, v := range message.Values {
+		values[k] = v
+	}
+
+	if _, err := r.AddToStream(conf.DeadLetterStream, values, 0); err == nil {
+		_ = r.Ack(stream, group, message.ID)
+	}
+
+	return nil
+}
+
+func (c StreamConsumerConfig) withDefaults() StreamConsumerConfig {
+	if c.Count <= 0 {
+		c.Count = 10
+	}
+	if c.Block <= 0 {
+		c.Block = 5 * time.Second
+	}
+	if c.MinIdle <= 0 {
+		c.MinIdle = time.Minute
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = 100 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 10 * time.Second
+	}
+
+	return c
+}
+
+func streamMessages(messages []redis.XMessage) []StreamMessage {
+	result := make([]StreamMessage, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, StreamMessage{ID: m.ID, Values: m.Values})
+	}
+
+	return result
+}
+
+// nextBackoff doubles the backoff up to max.
+func nextBackoff(backoff time.Duration, max time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > max {
+		return max
+	}
+
+	return backoff
+}
+
+// sleepContext waits for d, it returns false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,102 @@
+package repositorysdk
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx which carries the tenant id.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant id carried by ctx, and whether it is present.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// NewTenantRedisRepository function that create a new instance of RedisRepository whose keys and channels are scoped by
+// the tenant of ctx, the keys are prefixed by `repositorysdk:tenant:{<tenant id>}:` so the keys of a tenant share the
+// same hash slot. The client returned by GetUniversalClient is not scoped.
+//
+// Parameters:
+// - repo: the redis repository to be scoped.
+// - ctx: the context which carries the tenant id.
+//
+// Returns:
+// - RedisRepository: the repository of the tenant.
+// - error: ErrMissingTenant if ctx carries no tenant id.
+func NewTenantRedisRepository(repo RedisRepository, ctx context.Context) (RedisRepository, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	return newPrefixedRedisRepository(repo, tenantKeyPrefix(tenantID)), nil
+}
+
+// InvalidateTenant removes every key of the tenant, it is used to wipe the cached data of a tenant on offboarding.
+// The keys are found with `SCAN` and removed with `UNLINK` in batches.
+//
+// Parameters:
+// - cache: the redis repository that holds the keys of the tenant, not scoped by NewTenantRedisRepository.
+// - tenantID: the tenant id.
+//
+// Returns:
+// - int64: the number of keys removed.
+// - error: an error if something goes wrong, otherwise nil.
+func InvalidateTenant(cache RedisRepository, tenantID string) (removed int64, err error) {
+	defer wrapError(&err, "InvalidateTenant", "", tenantID, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return unlinkByPattern(ctx, cache.GetUniversalClient(), escapePattern(redisKey(cache, tenantKeyPrefix(tenantID)))+"*")
+}
+
+func tenantKeyPrefix(tenantID string) string {
+	return TenantKeyPrefix + "{" + tenantID + "}:"
+}
+
+// unlinkByPattern removes the keys matching the pattern with `UNLINK`, in batches of ScanBatchSize keys. On a
+// cluster the keys of every master are removed.
+func unlinkByPattern(ctx context.Context, client redis.UniversalClient, pattern string) (int64, error) {
+	var removed int64
+
+	err := forEachRedisNode(ctx, client, func(ctx context.Context, node redis.UniversalClient) error {
+		iter := node.Scan(ctx, 0, pattern, ScanBatchSize).Iterator()
+		batch := make([]string, 0, ScanBatchSize)
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) < ScanBatchSize {
+				continue
+			}
+
+			n, err := unlinkKeys(ctx, client, batch...)
+			if err != nil {
+				return err
+			}
+			removed += n
+			batch = batch[:0]
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+
+		if len(batch) > 0 {
+			n, err := unlinkKeys(ctx, client, batch...)
+			if err != nil {
+				return err
+			}
+			removed += n
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,5 @@
+package model
+
+type User struct{ ID string }
+
+func (*User) TableName() string { return "users" }
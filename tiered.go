@@ -0,0 +1,407 @@
+package repositorysdk
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// TieredCacheConfig is a struct that holds the settings of the in-process tier of a tiered cache.
+type TieredCacheConfig struct {
+	// MaxEntries is the maximum number of caches held in memory, the least recently used is evicted first. 0 means
+	// 10000.
+	MaxEntries int
+	// TTL is the time a cache is held in memory, it bounds the staleness when an invalidation is lost. 0 means 1
+	// minute.
+	TTL time.Duration
+	// Channel is the channel of the invalidations, it must be the same for all the instances sharing the caches. An
+	// empty channel means TieredCacheChannel.
+	Channel string
+}
+
+type TieredCache interface {
+	RedisRepository
+	Close() error
+}
+
+// tieredInvalidation is the message broadcast when caches are written, All invalidates every cache.
+type tieredInvalidation struct {
+	Keys []string `json:"keys,omitempty"`
+	All  bool     `json:"all,omitempty"`
+}
+
+type tieredCache struct {
+	RedisRepository
+	conf   TieredCacheConfig
+	local  *lruCache
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTieredCache function that create a new instance of RedisRepository which holds the caches read by GetCache and
+// GetOrSetCache in a bounded in-process LRU in front of redis. The writes of the caches through the repository are
+// broadcast to the other instances by pub/sub, which evict them from their memory.
+//
+// The caches written around the repository, e.g. by WatchTransaction, RunScript, or another service, are only evicted
+// when their TTL in memory lapses. Close stops the subscription to the invalidations.
+func NewTieredCache(repo RedisRepository, conf TieredCacheConfig) TieredCache {
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = 10000
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = time.Minute
+	}
+	if conf.Channel == "" {
+		conf.Channel = TieredCacheChannel
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &tieredCache{
+		RedisRepository: repo,
+		conf:            conf,
+		local:           newLRUCache(conf.MaxEntries),
+		cancel:          cancel,
+		done:            make(chan struct{}),
+	}
+	go c.subscribe(ctx)
+
+	return c
+}
+
+// valueCodec returns the codec of the wrapped repository.
+func (c *tieredCache) valueCodec() Codec {
+	return codecOf(c.RedisRepository)
+}
+
+// maxTTLJitter returns the ttl jitter of the wrapped repository.
+func (c *tieredCache) maxTTLJitter() float64 {
+	return ttlJitterOf(c.RedisRepository)
+}
+
+// redisKey returns the key stored in redis for the key of the repository.
+func (c *tieredCache) redisKey(key string) string {
+	return redisKey(c.RedisRepository, key)
+}
+
+// GetCache retrieves the cache from memory, or from redis when it is not held in memory.
+func (c *tieredCache) GetCache(key string, value interface{}) (err error) {
+	if v, ok := c.local.get(key); ok {
+		return codecOf(c.RedisRepository).Unmarshal(v, value)
+	}
+
+	defer wrapError(&err, "GetCache", "", key, time.Now())
+
+	gen := c.local.generation()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	v, err := c.GetUniversalClient().Get(ctx, redisKey(c.RedisRepository, key)).Bytes()
+	if err != nil {
+		return err
+	}
+	c.local.set(key, v, c.conf.TTL, gen)
+
+	return codecOf(c.RedisRepository).Unmarshal(v, value)
+}
+
+// GetOrSetCache retrieves the cache from memory, or from redis or the loader when it is not held in memory.
+func (c *tieredCache) GetOrSetCache(key string, ttl int, dest interface{}, loader func() (interface{}, error)) error {
+	codec := codecOf(c.RedisRepository)
+	if v, ok := c.local.get(key); ok {
+		return codec.Unmarshal(v, dest)
+	}
+
+	gen := c.local.generation()
+	if err := c.RedisRepository.GetOrSetCache(key, ttl, dest, loader); err != nil {
+		return err
+	}
+
+	if v, err := codec.Marshal(dest); err == nil {
+		c.local.set(key, v, c.conf.TTL, gen)
+	}
+
+	return nil
+}
+
+// GetOrSetCacheD is GetOrSetCache with the ttl as a duration.
+func (c *tieredCache) GetOrSetCacheD(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error {
+	return c.GetOrSetCache(key, ttlSeconds(ttl), dest, loader)
+}
+
+// SaveCache saves the cache and invalidates it in memory.
+func (c *tieredCache) SaveCache(key string, value interface{}, ttl int) error {
+	defer c.invalidate(key)
+	return c.RedisRepository.SaveCache(key, value, ttl)
+}
+
+// SaveCacheD is SaveCache with the ttl as a duration.
+func (c *tieredCache) SaveCacheD(key string, value interface{}, ttl time.Duration) error {
+	return c.SaveCache(key, value, ttlSeconds(ttl))
+}
+
+// SaveCacheNX saves the cache if it does not exist and invalidates it in memory.
+func (c *tieredCache) SaveCacheNX(key string, value interface{}, ttl int) (bool, error) {
+	defer c.invalidate(key)
+	return c.RedisRepository.SaveCacheNX(key, value, ttl)
+}
+
+// SaveCacheNXD is SaveCacheNX with the ttl as a duration.
+func (c *tieredCache) SaveCacheNXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.SaveCacheNX(key, value, ttlSeconds(ttl))
+}
+
+// SaveCacheXX saves the cache if it exists and invalidates it in memory.
+func (c *tieredCache) SaveCacheXX(key string, value interface{}, ttl int) (bool, error) {
+	defer c.invalidate(key)
+	return c.RedisRepository.SaveCacheXX(key, value, ttl)
+}
+
+// SaveCacheXXD is SaveCacheXX with the ttl as a duration.
+func (c *tieredCache) SaveCacheXXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.SaveCacheXX(key, value, ttlSeconds(ttl))
+}
+
+// SaveMultiCache saves the caches and invalidates them in memory.
+func (c *tieredCache) SaveMultiCache(values map[string]interface{}, ttl int) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	defer c.invalidate(keys...)
+
+	return c.RedisRepository.SaveMultiCache(values, ttl)
+}
+
+// SaveMultiCacheD is SaveMultiCache with the ttl as a duration.
+func (c *tieredCache) SaveMultiCacheD(values map[string]interface{}, ttl time.Duration) error {
+	return c.SaveMultiCache(values, ttlSeconds(ttl))
+}
+
+// GetDelCache retrieves and removes the cache from redis and invalidates it in memory.
+func (c *tieredCache) GetDelCache(key string, dest interface{}) error {
+	defer c.invalidate(key)
+	return c.RedisRepository.GetDelCache(key, dest)
+}
+
+// RemoveCache removes the cache and invalidates it in memory.
+func (c *tieredCache) RemoveCache(key string) error {
+	defer c.invalidate(key)
+	return c.RedisRepository.RemoveCache(key)
+}
+
+// CopyCache copies the cache and invalidates the copy in memory.
+func (c *tieredCache) CopyCache(src string, dst string, replace bool) (bool, error) {
+	defer c.invalidate(dst)
+	return c.RedisRepository.CopyCache(src, dst, replace)
+}
+
+// RenameCache renames the cache and invalidates both keys in memory.
+func (c *tieredCache) RenameCache(src string, dst string) error {
+	defer c.invalidate(src, dst)
+	return c.RedisRepository.RenameCache(src, dst)
+}
+
+// RemoveCacheByPattern removes the caches matching the pattern and invalidates every cache in memory.
+func (c *tieredCache) RemoveCacheByPattern(pattern string) (int64, error) {
+	defer c.invalidateAll()
+	return c.RedisRepository.RemoveCacheByPattern(pattern)
+}
+
+// IncrementCache increments the counter and invalidates it in memory.
+func (c *tieredCache) IncrementCache(key string, by int64, ttl int) (int64, error) {
+	defer c.invalidate(key)
+	return c.RedisRepository.IncrementCache(key, by, ttl)
+}
+
+// IncrementCacheD is IncrementCache with the ttl as a duration.
+func (c *tieredCache) IncrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return c.IncrementCache(key, by, ttlSeconds(ttl))
+}
+
+// DecrementCache decrements the counter and invalidates it in memory.
+func (c *tieredCache) DecrementCache(key string, by int64, ttl int) (int64, error) {
+	defer c.invalidate(key)
+	return c.RedisRepository.DecrementCache(key, by, ttl)
+}
+
+// DecrementCacheD is DecrementCache with the ttl as a duration.
+func (c *tieredCache) DecrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return c.DecrementCache(key, by, ttlSeconds(ttl))
+}
+
+// Pipeline sends the writes of the pipeline and invalidates their keys in memory.
+func (c *tieredCache) Pipeline(fn func(p RedisPipeline) error) error {
+	recorded := &keyRecordingPipeline{}
+	defer func() {
+		if len(recorded.keys) > 0 {
+			c.invalidate(recorded.keys...)
+		}
+	}()
+
+	return c.RedisRepository.Pipeline(func(p RedisPipeline) error {
+		recorded.RedisPipeline = p
+		return fn(recorded)
+	})
+}
+
+// WithContext returns a copy of the repository whose calls run under ctx, the copy shares the memory and the
+// subscription of the repository.
+func (c *tieredCache) WithContext(ctx context.Context) RedisRepository {
+	t := *c
+	t.RedisRepository = c.RedisRepository.WithContext(ctx)
+
+	return &t
+}
+
+// Close stops the subscription to the invalidations and clears the memory.
+func (c *tieredCache) Close() error {
+	c.cancel()
+	<-c.done
+	c.local.clear()
+
+	return nil
+}
+
+// invalidate evicts the keys from memory and broadcasts the invalidation, a lost broadcast is bounded by the TTL.
+func (c *tieredCache) invalidate(keys ...string) {
+	for _, key := range keys {
+		c.local.remove(key)
+	}
+
+	_ = c.RedisRepository.Publish(c.conf.Channel, tieredInvalidation{Keys: keys})
+}
+
+func (c *tieredCache) invalidateAll() {
+	c.local.clear()
+
+	_ = c.RedisRepository.Publish(c.conf.Channel, tieredInvalidation{All: true})
+}
+
+// subscribe evicts the caches invalidated by the other instances until ctx is done. The memory is cleared whenever
+// the subscription is made again, as the invalidations broadcast in the meantime are lost.
+func (c *tieredCache) subscribe(ctx context.Context) {
+	defer close(c.done)
+
+	codec := codecOf(c.RedisRepository)
+	for {
+		c.local.clear()
+
+		_ = c.RedisRepository.Subscribe(ctx, c.conf.Channel, func(payload []byte) error {
+			var msg tieredInvalidation
+			if err := codec.Unmarshal(payload, &msg); err != nil {
+				// skip the malformed message
+				return nil
+			}
+
+			if msg.All {
+				c.local.clear()
+			}
+			for _, key := range msg.Keys {
+				c.local.remove(key)
+			}
+			return nil
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// lruCache is a bounded map of the encoded caches which evicts the least recently used entry first. Its generation is
+// incremented by every invalidation, so a value read from redis before an invalidation is not held.
+type lruCache struct {
+	mu      sync.Mutex
+	gen     uint64
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func newLRUCache(max int) *lruCache {
+	return &lruCache{
+		max:     max,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (l *lruCache) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		l.order.Remove(e)
+		delete(l.entries, key)
+		return nil, false
+	}
+	l.order.MoveToFront(e)
+
+	return entry.value, true
+}
+
+func (l *lruCache) generation() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.gen
+}
+
+// set holds the value unless the memory was invalidated since the generation gen.
+func (l *lruCache) set(key string, value []byte, ttl time.Duration, gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.gen != gen {
+		return
+	}
+
+	if e, ok := l.entries[key]; ok {
+		e.Value = &lruEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+		l.order.MoveToFront(e)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: time.Now().Add(ttl)})
+	if l.order.Len() > l.max {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (l *lruCache) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gen++
+	if e, ok := l.entries[key]; ok {
+		l.order.Remove(e)
+		delete(l.entries, key)
+	}
+}
+
+func (l *lruCache) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gen++
+	l.order.Init()
+	l.entries = map[string]*list.Element{}
+}
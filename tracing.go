@@ -0,0 +1,133 @@
+package repositorysdk
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const (
+	// TraceMethodKey is the attribute of a span which holds the method of the repository.
+	TraceMethodKey = attribute.Key("repositorysdk.method")
+	// TraceKeyKey is the attribute of a span which holds the key of the call, it is not set for the calls on several keys.
+	TraceKeyKey = attribute.Key("repositorysdk.key")
+	// TraceResultKey is the attribute of a span which holds the RedisCallResult of the call.
+	TraceResultKey = attribute.Key("repositorysdk.result")
+)
+
+// redisCommands are the main commands run by the methods of the repository, set as the operation of their spans.
+var redisCommands = map[string]string{
+	"SaveCache":                 "SET",
+	"SaveCacheNX":               "SET",
+	"SaveCacheXX":               "SET",
+	"SaveHashCache":             "HSET",
+	"SaveAllHashCache":          "HSET",
+	"GetHashCache":              "HGET",
+	"GetAllHashCache":           "HGETALL",
+	"GetHashFields":             "HMGET",
+	"IncrementHashField":        "HINCRBY",
+	"RemoveHashCache":           "HDEL",
+	"GetCache":                  "GET",
+	"GetDelCache":               "GETDEL",
+	"SaveMultiCache":            "SET",
+	"GetMultiCache":             "MGET",
+	"GetOrSetCache":             "GET",
+	"RemoveCache":               "DEL",
+	"RemoveCacheByPattern":      "SCAN",
+	"CheckSetMember":            "SISMEMBER",
+	"AddSetMember":              "SADD",
+	"RemoveSetMember":           "SREM",
+	"GetSetMembers":             "SMEMBERS",
+	"CountSetMembers":           "SCARD",
+	"UnionSets":                 "SUNION",
+	"UnionSetsStore":            "SUNIONSTORE",
+	"IntersectSets":             "SINTER",
+	"IntersectSetsStore":        "SINTERSTORE",
+	"DiffSets":                  "SDIFF",
+	"DiffSetsStore":             "SDIFFSTORE",
+	"AddUnique":                 "PFADD",
+	"CountUnique":               "PFCOUNT",
+	"SetBit":                    "SETBIT",
+	"GetBit":                    "GETBIT",
+	"CountBits":                 "BITCOUNT",
+	"RandomSetMembers":          "SRANDMEMBER",
+	"RandomHashFields":          "HRANDFIELD",
+	"SetExpire":                 "EXPIRE",
+	"SetExpireAt":               "EXPIREAT",
+	"CopyCache":                 "COPY",
+	"RenameCache":               "RENAME",
+	"Exist":                     "EXISTS",
+	"ExistMulti":                "EXISTS",
+	"GetTTLMulti":               "PTTL",
+	"NamespaceStats":            "SCAN",
+	"KeyMemoryUsage":            "MEMORY",
+	"KeyType":                   "TYPE",
+	"ObjectIdleTime":            "OBJECT",
+	"TopKeysByMemory":           "SCAN",
+	"ScanKeys":                  "SCAN",
+	"SaveVersionedCache":        "HSET",
+	"SaveVersionedCacheIfMatch": "EVALSHA",
+	"GetVersionedCache":         "HGETALL",
+	"PushList":                  "RPUSH",
+	"PopList":                   "LPOP",
+	"BPopList":                  "BLPOP",
+	"GetListRange":              "LRANGE",
+	"TrimList":                  "LTRIM",
+	"IncrementCache":            "INCRBY",
+	"DecrementCache":            "INCRBY",
+	"WatchTransaction":          "EXEC",
+	"Pipeline":                  "PIPELINE",
+	"LoadScript":                "SCRIPT LOAD",
+	"RunScript":                 "EVALSHA",
+	"Publish":                   "PUBLISH",
+	"ShardedPublish":            "SPUBLISH",
+	"ShardedSubscribe":          "SSUBSCRIBE",
+	"Subscribe":                 "SUBSCRIBE",
+	"PSubscribe":                "PSUBSCRIBE",
+	"HealthCheck":               "PING",
+	"AcquireLock":               "SET",
+	"AcquireSemaphore":          "EVALSHA",
+	"AcquireSemaphoreWait":      "EVALSHA",
+	"ReleaseSemaphore":          "ZREM",
+}
+
+// WithTracer creates a span with the tracer for every call of the repository, e.g. otel.Tracer("repositorysdk"). The
+// spans follow the semantic conventions of the database clients, with the method, the key, and the RedisCallResult of
+// the call as extra attributes, and the failed calls are recorded as errors.
+func WithTracer(tracer trace.Tracer) RedisOption {
+	return func(o *redisOptions) {
+		o.tracer = tracer
+	}
+}
+
+// traceCall records a span for a call which started at start, as a child of the span of the context set by
+// WithContext.
+func (r *redisRepository) traceCall(method string, key string, result RedisCallResult, start time.Time, err error) {
+	operation := redisCommands[method]
+	if operation == "" {
+		operation = method
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemRedis,
+		semconv.DBOperationKey.String(operation),
+		TraceMethodKey.String(method),
+		TraceResultKey.String(string(result)),
+	}
+	if key != "" {
+		attrs = append(attrs, TraceKeyKey.String(key))
+	}
+
+	_, span := r.tracer.Start(r.context(), "redis "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+	if result == RedisCallError {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,100 @@
+package repositorysdk
+
+import (
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// ttlSeconds returns the ttl in seconds of a ttl given as a duration, it is rounded up to the second so a short ttl
+// does not become no expiration time. redis.KeepTTL is RedisKeepTTL, and a ttl of 0 or less is 0.
+func ttlSeconds(ttl time.Duration) int {
+	if ttl == redis.KeepTTL {
+		return RedisKeepTTL
+	}
+	if ttl <= 0 {
+		return 0
+	}
+
+	return int((ttl + time.Second - 1) / time.Second)
+}
+
+// SaveCacheD is SaveCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveCacheD(key string, value interface{}, ttl time.Duration) error {
+	return r.SaveCache(key, value, ttlSeconds(ttl))
+}
+
+// SaveCacheNXD is SaveCacheNX with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveCacheNXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.SaveCacheNX(key, value, ttlSeconds(ttl))
+}
+
+// SaveCacheXXD is SaveCacheXX with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveCacheXXD(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.SaveCacheXX(key, value, ttlSeconds(ttl))
+}
+
+// SaveHashCacheD is SaveHashCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveHashCacheD(key string, field string, value string, ttl time.Duration) error {
+	return r.SaveHashCache(key, field, value, ttlSeconds(ttl))
+}
+
+// SaveAllHashCacheD is SaveAllHashCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveAllHashCacheD(key string, value map[string]string, ttl time.Duration) error {
+	return r.SaveAllHashCache(key, value, ttlSeconds(ttl))
+}
+
+// AddSetMemberD is AddSetMember with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) AddSetMemberD(key string, ttl time.Duration, member ...interface{}) error {
+	return r.AddSetMember(key, ttlSeconds(ttl), member...)
+}
+
+// SaveMultiCacheD is SaveMultiCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveMultiCacheD(values map[string]interface{}, ttl time.Duration) error {
+	return r.SaveMultiCache(values, ttlSeconds(ttl))
+}
+
+// GetOrSetCacheD is GetOrSetCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) GetOrSetCacheD(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error {
+	return r.GetOrSetCache(key, ttlSeconds(ttl), dest, loader)
+}
+
+// SetExpireD is SetExpire with the ttl as a duration, it is rounded up to the second. redis.KeepTTL keeps the current
+// expiration time.
+func (r *redisRepository) SetExpireD(key string, ttl time.Duration) error {
+	return r.SetExpire(key, ttlSeconds(ttl))
+}
+
+// AddUniqueD is AddUnique with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) AddUniqueD(key string, ttl time.Duration, items ...interface{}) error {
+	return r.AddUnique(key, ttlSeconds(ttl), items...)
+}
+
+// SetBitD is SetBit with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SetBitD(key string, offset int64, value bool, ttl time.Duration) (bool, error) {
+	return r.SetBit(key, offset, value, ttlSeconds(ttl))
+}
+
+// SaveVersionedCacheD is SaveVersionedCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveVersionedCacheD(key string, value interface{}, ttl time.Duration) (string, error) {
+	return r.SaveVersionedCache(key, value, ttlSeconds(ttl))
+}
+
+// SaveVersionedCacheIfMatchD is SaveVersionedCacheIfMatch with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) SaveVersionedCacheIfMatchD(key string, version string, value interface{}, ttl time.Duration) (string, error) {
+	return r.SaveVersionedCacheIfMatch(key, version, value, ttlSeconds(ttl))
+}
+
+// PushListD is PushList with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) PushListD(key string, ttl time.Duration, values ...interface{}) (int64, error) {
+	return r.PushList(key, ttlSeconds(ttl), values...)
+}
+
+// IncrementCacheD is IncrementCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) IncrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return r.IncrementCache(key, by, ttlSeconds(ttl))
+}
+
+// DecrementCacheD is DecrementCache with the ttl as a duration, it is rounded up to the second.
+func (r *redisRepository) DecrementCacheD(key string, by int64, ttl time.Duration) (int64, error) {
+	return r.DecrementCache(key, by, ttlSeconds(ttl))
+}
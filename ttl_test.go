@@ -0,0 +1,34 @@
+package repositorysdk
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+func TestSetExpireKeepTTLKeepsTheCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo := NewRedisRepository(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	if err := repo.SaveCache("session", "value", 60); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if err := repo.SetExpireD("session", redis.KeepTTL); err != nil {
+		t.Fatalf("set expire with KeepTTL: %v", err)
+	}
+	if err := repo.Pipeline(func(p RedisPipeline) error {
+		p.SetExpire("session", RedisKeepTTL)
+		return nil
+	}); err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+
+	if !mr.Exists("session") {
+		t.Fatal("the cache was removed by a ttl keeping its expiration time")
+	}
+	if ttl := mr.TTL("session"); ttl != time.Minute {
+		t.Errorf("ttl = %s, want the current 1m0s", ttl)
+	}
+}
@@ -0,0 +1,101 @@
+package repositorysdk
+
+import (
+	"encoding/json"
+)
+
+// GetCacheT retrieves the cache and unmarshal it into a value of type T.
+//
+// Parameters:
+// - r: the redis repository that holds the cache.
+// - key: the cache key.
+//
+// Returns:
+// - T: the cache value.
+// - error: ErrCacheMiss if the cache does not exist, otherwise an error if something goes wrong.
+func GetCacheT[T any](r RedisRepository, key string) (T, error) {
+	var value T
+	if err := r.GetCache(key, &value); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// SaveCacheT saves the value of type T to the cache, 0 ttl means no expiration time.
+//
+// Parameters:
+// - r: the redis repository that holds the cache.
+// - key: the cache key.
+// - value: the cache value to be saved.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+//
+// Returns:
+// - error: an error if something goes wrong, otherwise nil.
+func SaveCacheT[T any](r RedisRepository, key string, value T, ttl int) error {
+	return r.SaveCache(key, value, ttl)
+}
+
+// GetOrSetCacheT retrieves the cache, or calls the loader and saves its result when the cache does not exist, see
+// RedisRepository.GetOrSetCache.
+//
+// Parameters:
+// - r: the redis repository that holds the cache.
+// - key: the cache key.
+// - ttl: the expiration time for cache in seconds, 0 means no expiration time.
+// - loader: the function which loads the value when the cache does not exist.
+//
+// Returns:
+// - T: the cache value.
+// - error: the error of the loader, otherwise an error if something goes wrong.
+func GetOrSetCacheT[T any](r RedisRepository, key string, ttl int, loader func() (T, error)) (T, error) {
+	var value T
+	if err := r.GetOrSetCache(key, ttl, &value, func() (interface{}, error) {
+		return loader()
+	}); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// GetManyTyped retrieves several caches in a single round trip and unmarshal them into values of type T, the keys
+// which missed are returned in their order, so the caller can load only the missing values and save them back. A
+// cache which cannot be unmarshalled is returned as missing.
+//
+// Parameters:
+// - r: the redis repository that holds the caches.
+// - keys: the cache keys.
+//
+// Returns:
+// - map[string]T: the cache values by their key.
+// - []string: the keys of the missing caches.
+// - error: an error if something goes wrong, otherwise nil.
+func GetManyTyped[T any](r RedisRepository, keys []string) (found map[string]T, missing []string, err error) {
+	raw := make(map[string]json.RawMessage, len(keys))
+	if err := r.GetMultiCache(keys, raw); err != nil {
+		return nil, nil, err
+	}
+
+	codec := codecOf(r)
+
+	found = make(map[string]T, len(raw))
+	for _, key := range keys {
+		v, ok := raw[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+
+		var value T
+		if err := codec.Unmarshal(v, &value); err != nil {
+			missing = append(missing, key)
+			continue
+		}
+		found[key] = value
+	}
+
+	return found, missing, nil
+}
@@ -0,0 +1,346 @@
+package repositorysdk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DropPolicy is the write dropped when the queue of a write-ahead repository is full.
+type DropPolicy int
+
+const (
+	// DropOldest drops the oldest queued write to make room for the new one.
+	DropOldest DropPolicy = iota
+	// DropNewest drops the new write and keeps the queued ones.
+	DropNewest
+)
+
+// WriteAheadConfig is a struct that holds the settings of a write-ahead repository.
+type WriteAheadConfig struct {
+	// MaxPending is the maximum number of queued writes, 0 means 1000.
+	MaxPending int
+	// DropPolicy is the write dropped when the queue is full.
+	DropPolicy DropPolicy
+	// RetryInterval is the interval between the attempts to flush the queue, 0 means 1 second.
+	RetryInterval time.Duration
+	// OnDrop is called with the key of every write dropped from the queue, e.g. to count them, it may be nil.
+	OnDrop func(key string)
+	// OnError is called with the error of a queued write which is rejected by redis once flushed, the write is
+	// dropped. It may be nil.
+	OnError func(key string, err error)
+}
+
+type WriteAheadRedisRepository interface {
+	RedisRepository
+	Pending() int
+	Flush() error
+	Close() error
+}
+
+type writeAheadOp struct {
+	seq uint64
+	key string
+	run func(repo RedisRepository) error
+}
+
+type writeAheadRepository struct {
+	RedisRepository
+	*writeAheadQueue
+}
+
+// writeAheadQueue is the queue of a writeAheadRepository, shared by its copies made by WithContext.
+type writeAheadQueue struct {
+	// repo is the repository the queued writes are replayed on.
+	repo RedisRepository
+	conf WriteAheadConfig
+
+	mu      sync.Mutex
+	pending []writeAheadOp
+	seq     uint64
+	closed  bool
+	done    chan struct{}
+	flushMu sync.Mutex
+}
+
+// NewWriteAheadRedisRepository function that create a new instance of RedisRepository whose cache writes are queued
+// in-process when redis cannot be reached, and replayed in order once it is back, so the transient outages are not
+// surfaced as errors. The writes made while the queue is not empty are queued behind it right away, without waiting
+// for redis, while the writes made while it is empty are run as they come, concurrently. The writes which are queued
+// are SaveCache, SaveMultiCache, SaveHashCache, SaveAllHashCache, RemoveCache, RemoveHashCache, SetExpire, SetExpireAt,
+// and their variants taking a duration. The reads and the other commands, including the writes which return a result
+// such as SaveCacheNX, IncrementCache, or PushList, are passed to repo as they are, so they are not ordered against the
+// queued writes of the same key.
+//
+// The queued writes are only held in memory and their ttl starts when they are replayed, so the mode is meant for the
+// non-critical caches. Close flushes the queue and stops the retries.
+func NewWriteAheadRedisRepository(repo RedisRepository, conf WriteAheadConfig) WriteAheadRedisRepository {
+	if conf.MaxPending <= 0 {
+		conf.MaxPending = 1000
+	}
+	if conf.RetryInterval <= 0 {
+		conf.RetryInterval = time.Second
+	}
+
+	r := &writeAheadRepository{
+		RedisRepository: repo,
+		writeAheadQueue: &writeAheadQueue{
+			repo: repo,
+			conf: conf,
+			done: make(chan struct{}),
+		},
+	}
+	go r.run()
+
+	return r
+}
+
+// valueCodec returns the codec of the wrapped repository.
+func (r *writeAheadRepository) valueCodec() Codec {
+	return codecOf(r.RedisRepository)
+}
+
+// maxTTLJitter returns the ttl jitter of the wrapped repository.
+func (r *writeAheadRepository) maxTTLJitter() float64 {
+	return ttlJitterOf(r.RedisRepository)
+}
+
+// redisKey returns the key stored in redis for the key of the repository.
+func (r *writeAheadRepository) redisKey(key string) string {
+	return redisKey(r.RedisRepository, key)
+}
+
+// SaveCache saves the cache, or queues the write when redis cannot be reached. The value is encoded right away.
+func (r *writeAheadRepository) SaveCache(key string, value interface{}, ttl int) error {
+	v, err := codecOf(r.RedisRepository).Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return r.write(key, func(repo RedisRepository) error {
+		return repo.SaveCache(key, encodedValue(v), ttl)
+	})
+}
+
+// SaveCacheD is SaveCache with the ttl as a duration.
+func (r *writeAheadRepository) SaveCacheD(key string, value interface{}, ttl time.Duration) error {
+	return r.SaveCache(key, value, ttlSeconds(ttl))
+}
+
+// SaveMultiCache saves the caches, or queues the write when redis cannot be reached. The values are encoded right
+// away.
+func (r *writeAheadRepository) SaveMultiCache(values map[string]interface{}, ttl int) error {
+	codec := codecOf(r.RedisRepository)
+
+	encoded := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		v, err := codec.Marshal(value)
+		if err != nil {
+			return err
+		}
+		encoded[key] = encodedValue(v)
+	}
+
+	return r.write("", func(repo RedisRepository) error {
+		return repo.SaveMultiCache(encoded, ttl)
+	})
+}
+
+// SaveMultiCacheD is SaveMultiCache with the ttl as a duration.
+func (r *writeAheadRepository) SaveMultiCacheD(values map[string]interface{}, ttl time.Duration) error {
+	return r.SaveMultiCache(values, ttlSeconds(ttl))
+}
+
+// SaveHashCache saves the field of the hash, or queues the write when redis cannot be reached.
+func (r *writeAheadRepository) SaveHashCache(key string, field string, value string, ttl int) error {
+	return r.write(key, func(repo RedisRepository) error {
+		return repo.SaveHashCache(key, field, value, ttl)
+	})
+}
+
+// SaveHashCacheD is SaveHashCache with the ttl as a duration.
+func (r *writeAheadRepository) SaveHashCacheD(key string, field string, value string, ttl time.Duration) error {
+	return r.SaveHashCache(key, field, value, ttlSeconds(ttl))
+}
+
+// SaveAllHashCache saves the fields of the hash, or queues the write when redis cannot be reached.
+func (r *writeAheadRepository) SaveAllHashCache(key string, value map[string]string, ttl int) error {
+	fields := make(map[string]string, len(value))
+	for k, v := range value {
+		fields[k] = v
+	}
+
+	return r.write(key, func(repo RedisRepository) error {
+		return repo.SaveAllHashCache(key, fields, ttl)
+	})
+}
+
+// SaveAllHashCacheD is SaveAllHashCache with the ttl as a duration.
+func (r *writeAheadRepository) SaveAllHashCacheD(key string, value map[string]string, ttl time.Duration) error {
+	return r.SaveAllHashCache(key, value, ttlSeconds(ttl))
+}
+
+// RemoveCache removes the cache, or queues the removal when redis cannot be reached.
+func (r *writeAheadRepository) RemoveCache(key string) error {
+	return r.write(key, func(repo RedisRepository) error {
+		return repo.RemoveCache(key)
+	})
+}
+
+// RemoveHashCache removes the field of the hash, or queues the removal when redis cannot be reached.
+func (r *writeAheadRepository) RemoveHashCache(key string, field string) error {
+	return r.write(key, func(repo RedisRepository) error {
+		return repo.RemoveHashCache(key, field)
+	})
+}
+
+// SetExpire sets the expiration time of the cache, or queues the write when redis cannot be reached.
+func (r *writeAheadRepository) SetExpire(key string, ttl int) error {
+	return r.write(key, func(repo RedisRepository) error {
+		return repo.SetExpire(key, ttl)
+	})
+}
+
+// SetExpireD is SetExpire with the ttl as a duration.
+func (r *writeAheadRepository) SetExpireD(key string, ttl time.Duration) error {
+	return r.SetExpire(key, ttlSeconds(ttl))
+}
+
+// SetExpireAt sets the absolute expiration time of the cache, or queues the write when redis cannot be reached.
+func (r *writeAheadRepository) SetExpireAt(key string, at time.Time) error {
+	return r.write(key, func(repo RedisRepository) error {
+		return repo.SetExpireAt(key, at)
+	})
+}
+
+// WithContext returns a copy of the repository whose calls run under ctx, its writes are queued in the queue of the
+// repository and replayed without ctx.
+func (r *writeAheadRepository) WithContext(ctx context.Context) RedisRepository {
+	return &writeAheadRepository{
+		RedisRepository: r.RedisRepository.WithContext(ctx),
+		writeAheadQueue: r.writeAheadQueue,
+	}
+}
+
+// Pending returns the number of queued writes.
+func (r *writeAheadRepository) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pending)
+}
+
+// Flush replays the queued writes in order, it stops at the first write which fails because redis cannot be reached.
+//
+// Returns:
+// - error: the error of the connection if the queue cannot be drained, otherwise nil.
+func (r *writeAheadRepository) Flush() error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	for {
+		r.mu.Lock()
+		if len(r.pending) == 0 {
+			r.mu.Unlock()
+			return nil
+		}
+		op := r.pending[0]
+		r.mu.Unlock()
+
+		err := op.run(r.repo)
+		if isConnectionError(err) {
+			return err
+		}
+
+		r.mu.Lock()
+		// the write may have been dropped by DropOldest while it was replayed
+		if len(r.pending) > 0 && r.pending[0].seq == op.seq {
+			r.pending = r.pending[1:]
+		}
+		r.mu.Unlock()
+
+		if err != nil && r.conf.OnError != nil {
+			r.conf.OnError(op.key, err)
+		}
+	}
+}
+
+// Close stops the retries and flushes the queue, the writes made after it are passed to the repository as they are.
+//
+// Returns:
+// - error: the error of the connection if the queue cannot be drained, the writes left in the queue are lost.
+func (r *writeAheadRepository) Close() error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+	}
+	r.mu.Unlock()
+
+	return r.Flush()
+}
+
+// write runs the write, or queues it when the queue is not empty or redis cannot be reached. The queue is checked and
+// the write appended under the same lock, so a write made while the queue is not empty cannot overtake it.
+func (r *writeAheadRepository) write(key string, run func(repo RedisRepository) error) error {
+	r.mu.Lock()
+	closed, queued := r.closed, len(r.pending) > 0
+	if queued && !closed {
+		dropped, ok := r.enqueue(key, run)
+		r.mu.Unlock()
+		r.dropped(dropped, ok)
+		return nil
+	}
+	r.mu.Unlock()
+
+	err := run(r.RedisRepository)
+	if closed || !isConnectionError(err) {
+		return err
+	}
+
+	r.mu.Lock()
+	dropped, ok := r.enqueue(key, run)
+	r.mu.Unlock()
+	r.dropped(dropped, ok)
+
+	return nil
+}
+
+// dropped reports the key of the write dropped from the queue, if any, to OnDrop.
+func (r *writeAheadRepository) dropped(key string, ok bool) {
+	if ok && r.conf.OnDrop != nil {
+		r.conf.OnDrop(key)
+	}
+}
+
+// enqueue appends the write to the queue under the lock, it returns the key of the write dropped to make room.
+func (r *writeAheadRepository) enqueue(key string, run func(repo RedisRepository) error) (dropped string, ok bool) {
+	if len(r.pending) >= r.conf.MaxPending {
+		if r.conf.DropPolicy == DropNewest {
+			return key, true
+		}
+
+		dropped, ok = r.pending[0].key, true
+		r.pending = r.pending[1:]
+	}
+
+	r.seq++
+	r.pending = append(r.pending, writeAheadOp{seq: r.seq, key: key, run: run})
+
+	return dropped, ok
+}
+
+func (r *writeAheadRepository) run() {
+	ticker := time.NewTicker(r.conf.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		}
+
+		_ = r.Flush()
+	}
+}
@@ -0,0 +1,106 @@
+package repositorysdk
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyRedis is a RedisRepository which records the writes it receives, and fails them with a connection error while
+// it is down.
+type flakyRedis struct {
+	RedisRepository
+
+	mu     sync.Mutex
+	down   bool
+	writes []string
+	// gate, if set, holds every SaveCache until it is closed, entered counts the calls held
+	gate    chan struct{}
+	entered int
+}
+
+func (r *flakyRedis) do(write string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.down {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	r.writes = append(r.writes, write)
+	return nil
+}
+
+func (r *flakyRedis) SaveCache(key string, value interface{}, _ int) error {
+	if r.gate != nil {
+		r.mu.Lock()
+		r.entered++
+		r.mu.Unlock()
+		<-r.gate
+	}
+	return r.do("SET " + key + " " + string(value.(encodedValue)))
+}
+
+func (r *flakyRedis) RemoveCache(key string) error {
+	return r.do("DEL " + key)
+}
+
+func (r *flakyRedis) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+func TestWriteAheadQueuesTheWritesBehindTheQueue(t *testing.T) {
+	server := &flakyRedis{down: true}
+	repo := NewWriteAheadRedisRepository(server, WriteAheadConfig{RetryInterval: time.Hour})
+	defer repo.Close()
+
+	if err := repo.SaveCache("user:1", "alice", 0); err != nil {
+		t.Fatalf("save while redis is down: %v", err)
+	}
+
+	// redis is back, but the removal is queued behind the write which is still pending
+	server.setDown(false)
+	if err := repo.RemoveCache("user:1"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if repo.Pending() != 2 {
+		t.Fatalf("pending = %d, want the removal queued behind the write", repo.Pending())
+	}
+
+	if err := repo.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := strings.Join(server.writes, ", "); got != `SET user:1 "alice", DEL user:1` {
+		t.Errorf("replayed %s, want the write then the removal", got)
+	}
+}
+
+func TestWriteAheadRunsTheHealthyWritesConcurrently(t *testing.T) {
+	server := &flakyRedis{gate: make(chan struct{})}
+	repo := NewWriteAheadRedisRepository(server, WriteAheadConfig{RetryInterval: time.Hour})
+	defer repo.Close()
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"user:1", "user:2"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			_ = repo.SaveCache(key, "alice", 0)
+		}(key)
+	}
+
+	// both writes wait on redis at once, neither holds the other back
+	time.Sleep(50 * time.Millisecond)
+	server.mu.Lock()
+	entered := server.entered
+	server.mu.Unlock()
+	close(server.gate)
+	wg.Wait()
+
+	if entered != 2 {
+		t.Errorf("%d writes were sent to redis at once, want 2", entered)
+	}
+}